@@ -0,0 +1,147 @@
+// auth.go
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/openapi2mcp"
+)
+
+// handleAuthCommand runs `openapi-mcp auth login <openapi-spec-path>`: an interactive OAuth 2.0 login
+// whose refresh token is stored in the credential file, for the server to use and refresh.
+// `openapi-mcp auth hash-password <user>` prints a line for the --auth-users-file file instead.
+func handleAuthCommand(flags *cliFlags, args []string) {
+	if len(args) == 3 && args[1] == "hash-password" {
+		hashPassword(args[2])
+	}
+	if len(args) < 3 || args[1] != "login" {
+		fmt.Fprintln(os.Stderr, "Usage: openapi-mcp [flags] auth login <openapi-spec-path>")
+		fmt.Fprintln(os.Stderr, "       openapi-mcp auth hash-password <user>  (reads the password from stdin)")
+		os.Exit(1)
+	}
+	doc, err := openapi2mcp.LoadOpenAPISpecWithOverlays(args[2], flags.overlays...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Could not load OpenAPI spec: %v\n", err)
+		os.Exit(1)
+	}
+	schemeName, flows, err := loginScheme(doc, flags.oauthScheme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	opts := openapi2mcp.OAuth2LoginOptions{
+		ClientID:     os.Getenv("OAUTH_CLIENT_ID"),
+		ClientSecret: os.Getenv("OAUTH_CLIENT_SECRET"),
+		Scopes:       strings.FieldsFunc(os.Getenv("OAUTH_SCOPES"), func(r rune) bool { return r == ',' || r == ' ' }),
+		RedirectPort: flags.oauthRedirectPort,
+		Prompt:       func(msg string) { fmt.Fprintln(os.Stderr, msg) },
+		OpenBrowser:  openBrowser,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	device := openapi2mcp.DeviceAuthorization(flows)
+	var cred *openapi2mcp.OAuth2Credential
+	switch {
+	case flows.AuthorizationCode != nil && !(flags.oauthDevice && device != nil):
+		cred, err = openapi2mcp.LoginAuthorizationCode(ctx, flows.AuthorizationCode, opts)
+	case device != nil:
+		cred, err = openapi2mcp.LoginDeviceCode(ctx, device, opts)
+	default:
+		err = fmt.Errorf("security scheme %q has no authorizationCode or deviceAuthorization flow", schemeName)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Login failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := os.Getenv("OAUTH_CREDENTIAL_FILE")
+	if path == "" {
+		path = openapi2mcp.DefaultCredentialFilePath()
+	}
+	if err := openapi2mcp.OpenCredentialFile(path).Put(cred); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if cred.RefreshToken == "" {
+		fmt.Fprintln(os.Stderr, "[WARN] The authorization server did not return a refresh token; you will need to log in again when the token expires.")
+	}
+	fmt.Fprintf(os.Stderr, "Logged in with %q; credentials saved to %s\n", schemeName, path)
+	os.Exit(0)
+}
+
+// hashPassword reads a password from stdin and prints a users file entry for it.
+func hashPassword(user string) {
+	if strings.Contains(user, ":") {
+		fmt.Fprintln(os.Stderr, "Error: user names cannot contain ':'")
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Password for %s: ", user)
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	fmt.Fprintln(os.Stderr)
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		fmt.Fprintf(os.Stderr, "Error: no password read: %v\n", err)
+		os.Exit(1)
+	}
+	hash, err := openapi2mcp.HashPassword(password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s:%s\n", user, hash)
+	os.Exit(0)
+}
+
+// loginScheme selects the oauth2 security scheme to log in with: the named one, or the only one
+// with a user-delegated flow.
+func loginScheme(doc *openapi3.T, name string) (string, *openapi3.OAuthFlows, error) {
+	var candidates []string
+	if doc.Components != nil {
+		for schemeName, ref := range doc.Components.SecuritySchemes {
+			if ref == nil || ref.Value == nil || ref.Value.Type != "oauth2" || ref.Value.Flows == nil {
+				continue
+			}
+			flows := ref.Value.Flows
+			if flows.AuthorizationCode == nil && openapi2mcp.DeviceAuthorization(flows) == nil {
+				continue
+			}
+			if name == schemeName {
+				return schemeName, flows, nil
+			}
+			candidates = append(candidates, schemeName)
+		}
+	}
+	sort.Strings(candidates)
+	switch {
+	case len(candidates) == 0:
+		return "", nil, fmt.Errorf("the spec has no oauth2 security scheme with an authorizationCode or deviceAuthorization flow")
+	case name != "":
+		return "", nil, fmt.Errorf("unknown security scheme %q (available: %s)", name, strings.Join(candidates, ", "))
+	case len(candidates) > 1:
+		return "", nil, fmt.Errorf("several oauth2 security schemes found, select one with --oauth-scheme: %s", strings.Join(candidates, ", "))
+	}
+	return candidates[0], doc.Components.SecuritySchemes[candidates[0]].Value.Flows, nil
+}
+
+// openBrowser opens a URL with the platform's default browser.
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
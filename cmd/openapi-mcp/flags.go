@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 )
 
 // cliFlags holds all parsed CLI flags and arguments.
@@ -16,8 +17,19 @@ type cliFlags struct {
 	machine            bool
 	apiKeyFlag         string
 	baseURLFlag        string
+	serverVars         multiFlag // Values of server URL variables (name=value), overriding their defaults
 	bearerToken        string
 	basicAuth          string
+	oauthClientID      string
+	oauthClientSecret  string
+	oauthTokenURL      string
+	oauthScopes        string
+	oauthTenant        string
+	oauthTenants       string // Tenants clients may select with the X-MCP-Tenant header (comma-separated)
+	oauthScheme        string // Security scheme used by 'auth login'
+	oauthDevice        bool   // Use the device flow in 'auth login'
+	oauthRedirectPort  int    // Loopback redirect port used by 'auth login'
+	credentialFile     string // File storing the tokens obtained by 'auth login'
 	httpAddr           string
 	httpTransport      string // new: sse (default) or streamable
 	includeDescRegex   string
@@ -33,10 +45,44 @@ type cliFlags struct {
 	noConfirmDangerous bool
 	headers            multiFlag // Custom headers to pass through to API requests
 	args               []string
-	mounts             mountFlags // slice of mountFlag
-	functionListFile   string     // Path to file listing functions to include (for filter command)
-	logFile            string     // Path to file for logging MCP requests and responses
-	noLogTruncation    bool       // Disable truncation in human-readable MCP logs
+	mounts             mountFlags    // slice of mountFlag
+	functionListFile   string        // Path to file listing functions to include (for filter command)
+	logFile            string        // Path to file for logging MCP requests and responses
+	noLogTruncation    bool          // Disable truncation in human-readable MCP logs
+	recordHAR          string        // Path to a HAR file recording all upstream HTTP exchanges
+	replayHAR          string        // Path to a HAR file to serve upstream responses from (no network)
+	replayMatch        string        // Comma-separated request parts matched during HAR replay
+	mock               bool          // Simulate API responses from the spec instead of calling the API
+	overlays           multiFlag     // OpenAPI Overlay files applied to the spec, in order
+	pins               multiFlag     // Parameter values removed from tool schemas and always sent
+	defaults           multiFlag     // Parameter values sent when the model omits them
+	coerceArgs         bool          // Convert mistyped tool arguments to the schema's types before validation
+	credentials        multiFlag     // Credentials bound to security scheme names
+	credentialTTL      time.Duration // How long file and helper credentials are cached
+	signers            multiFlag     // Request signers bound to security scheme names
+	authTokens         multiFlag     // Static bearer tokens accepted from MCP clients (subject=token)
+	authUsersFile      string        // Users allowed to authenticate to the MCP endpoint with HTTP basic auth
+	authJWKSFile       string        // JWKS file verifying the JWTs presented by MCP clients
+	authIssuer         string        // Required issuer of client JWTs
+	authAudience       string        // Accepted audiences of client JWTs (comma-separated)
+	authResourceURL    string        // Public URL of this server, advertised as an OAuth 2.0 protected resource
+	authServers        multiFlag     // Authorization servers advertised in the protected resource metadata
+	authScopes         string        // Scopes advertised in the protected resource metadata (comma-separated)
+	stdioWorkers       int           // Maximum number of stdio requests handled concurrently
+	policy             string        // Policy file restricting the tools each client may list and call
+	tlsCert            string        // PEM certificate chain served by the HTTP listeners
+	tlsKey             string        // PEM private key of the TLS certificate
+	tlsClientCA        string        // PEM CAs issuing the certificates required from clients (mTLS)
+	auditLog           string        // File receiving the policy decisions on tool calls ("-" for stderr)
+	async              multiFlag     // Operations whose 202 Accepted responses are polled until completion ("*" for all)
+	asyncDeadline      time.Duration // How long a tool call waits for an asynchronous operation
+	streamMaxEvents    int           // Events relayed from a streaming response before the tool returns
+	streamMaxDuration  time.Duration // How long a streaming response is relayed before the tool returns
+	streamTerminator   string        // Regular expression matching the event data that ends a streaming response
+	paginate           multiFlag     // GET operations whose following pages are fetched and merged ("*" for all)
+	paginateMaxItems   int           // Items after which no more pages are fetched
+	paginateMaxPages   int           // Pages fetched by a tool call
+	paginateMaxBytes   int64         // Page bytes after which no more pages are fetched
 }
 
 type mountFlag struct {
@@ -74,8 +120,36 @@ func parseFlags() *cliFlags {
 	flags.machine = true
 	flag.StringVar(&flags.apiKeyFlag, "api-key", "", "API key for authenticated endpoints (overrides API_KEY env)")
 	flag.StringVar(&flags.baseURLFlag, "base-url", "", "Override the base URL for HTTP calls (overrides OPENAPI_BASE_URL env)")
+	flag.Var(&flags.serverVars, "server-var", "Value of a server URL variable such as {region}: name=value (repeatable; default: the spec's default)")
 	flag.StringVar(&flags.bearerToken, "bearer-token", "", "Bearer token for Authorization header (overrides BEARER_TOKEN env)")
 	flag.StringVar(&flags.basicAuth, "basic-auth", "", "Basic auth (user:pass) for Authorization header (overrides BASIC_AUTH env)")
+	flag.Var(&flags.credentials, "credential", "Credential for a security scheme: schemeName=value, schemeName=env:<VAR>, schemeName=file:<path> or schemeName=exec:<helper command> (repeatable; overrides API_KEY, BEARER_TOKEN and BASIC_AUTH for that scheme)")
+	flag.DurationVar(&flags.credentialTTL, "credential-ttl", 0, "How long file: and exec: credentials are cached before being read again (default 5m)")
+	flag.Var(&flags.signers, "signer", "Sign requests for a security scheme: schemeName=kind[,key=value...] with kind aws-sigv4 or hmac (repeatable)")
+	flag.StringVar(&flags.oauthClientID, "oauth-client-id", "", "OAuth2 client id for the client credentials grant (overrides OAUTH_CLIENT_ID env)")
+	flag.StringVar(&flags.oauthClientSecret, "oauth-client-secret", "", "OAuth2 client secret (overrides OAUTH_CLIENT_SECRET env)")
+	flag.StringVar(&flags.oauthTokenURL, "oauth-token-url", "", "OAuth2 token endpoint, overriding the spec's tokenUrl; {tenant} is replaced (overrides OAUTH_TOKEN_URL env)")
+	flag.StringVar(&flags.oauthScopes, "oauth-scopes", "", "OAuth2 scopes to request, overriding those required by each operation (overrides OAUTH_SCOPES env)")
+	flag.StringVar(&flags.oauthTenant, "oauth-tenant", "", "OAuth2 tenant, substituted for {tenant} in the token URL, or identity:<claim> for the client's (overrides OAUTH_TENANT env)")
+	flag.StringVar(&flags.oauthTenants, "oauth-tenants", "", "Tenants MCP clients may select with the X-MCP-Tenant header, comma-separated (overrides OAUTH_TENANTS env)")
+	flag.StringVar(&flags.oauthScheme, "oauth-scheme", "", "Security scheme to log in with (auth login; default: the only oauth2 scheme with a user flow)")
+	flag.BoolVar(&flags.oauthDevice, "oauth-device", false, "Use the device authorization flow instead of a browser redirect (auth login)")
+	flag.IntVar(&flags.oauthRedirectPort, "oauth-redirect-port", 0, "Loopback port for the authorization code redirect (auth login; default: random)")
+	flag.StringVar(&flags.credentialFile, "credential-file", "", "File storing the tokens obtained by 'auth login' (overrides OAUTH_CREDENTIAL_FILE env)")
+	flag.Var(&flags.authTokens, "auth-token", "Require MCP clients (HTTP) to authenticate; accept this bearer token: subject=token (repeatable, $VAR expanded)")
+	flag.StringVar(&flags.authUsersFile, "auth-users-file", "", "Require MCP clients (HTTP) to authenticate; accept HTTP basic auth for the users of this file (see 'auth hash-password')")
+	flag.StringVar(&flags.authJWKSFile, "auth-jwks-file", "", "Require MCP clients (HTTP) to authenticate; accept bearer JWTs signed by the keys of this JWKS file")
+	flag.StringVar(&flags.authIssuer, "auth-issuer", "", "Required issuer (iss) of client JWTs")
+	flag.StringVar(&flags.authAudience, "auth-audience", "", "Accepted audiences (aud) of client JWTs, comma-separated")
+	flag.StringVar(&flags.authResourceURL, "auth-resource-url", "", "Public URL of this server (e.g. https://mcp.example.com): publish OAuth protected resource metadata for its MCP endpoints, and require tokens issued for them")
+	flag.Var(&flags.authServers, "auth-authorization-server", "Authorization server advertised to MCP clients (repeatable; default: --auth-issuer)")
+	flag.StringVar(&flags.authScopes, "auth-scopes", "", "Scopes advertised to MCP clients, comma-separated")
+	flag.IntVar(&flags.stdioWorkers, "stdio-workers", 0, "Maximum number of requests handled concurrently in stdio mode (default 8; 1: one at a time)")
+	flag.StringVar(&flags.tlsCert, "tls-cert", "", "Serve HTTP over TLS with this PEM certificate chain (reloaded on SIGHUP)")
+	flag.StringVar(&flags.tlsKey, "tls-key", "", "PEM private key of --tls-cert")
+	flag.StringVar(&flags.tlsClientCA, "tls-client-ca", "", "Require clients to present a certificate issued by one of these PEM CAs (mTLS)")
+	flag.StringVar(&flags.policy, "policy", "", "Policy file (YAML or JSON) restricting the tools each client may list and call")
+	flag.StringVar(&flags.auditLog, "audit-log", "", "Append the policy decisions on tool calls to this file as JSON lines (default: stderr when --policy is set)")
 	flag.StringVar(&flags.httpAddr, "http", "", "Serve over HTTP on this address (e.g., :8080). For MCP server: serves tools via HTTP. For validate/lint: creates REST API endpoints.")
 	flag.StringVar(&flags.httpTransport, "http-transport", "streamable", "HTTP transport to use for MCP server: 'streamable' (default) or 'sse'")
 	flag.StringVar(&flags.includeDescRegex, "include-desc-regex", "", "Only include APIs whose description matches this regex (overrides INCLUDE_DESC_REGEX env)")
@@ -93,6 +167,23 @@ func parseFlags() *cliFlags {
 	flag.StringVar(&flags.functionListFile, "function-list-file", "", "File with list of function (operationId) names to include (one per line, for filter command)")
 	flag.StringVar(&flags.logFile, "log-file", "", "File path to log all MCP requests and responses for debugging")
 	flag.BoolVar(&flags.noLogTruncation, "no-log-truncation", false, "Disable truncation of long values in human-readable MCP logs")
+	flag.StringVar(&flags.recordHAR, "record-har", "", "Record every upstream HTTP exchange (with secrets redacted) to this HAR file")
+	flag.StringVar(&flags.replayHAR, "replay-har", "", "Serve upstream responses from this HAR file instead of the network")
+	flag.StringVar(&flags.replayMatch, "replay-match", "method,path,query", "Request parts that must match a recorded entry during --replay-har: method, path, query, body")
+	flag.Var(&flags.overlays, "overlay", "Apply an OpenAPI Overlay file to the spec before generating tools (repeatable, applied in order)")
+	flag.Var(&flags.pins, "pin", "Pin a parameter value, hiding it from tools: [spec:<mount>:][operation:<id>:|tag:<tag>:][override:]name=value (repeatable, $VAR expanded, identity:<claim> from the client)")
+	flag.Var(&flags.defaults, "default", "Default a parameter value when omitted: [spec:<mount>:][operation:<id>:|tag:<tag>:][override:]name=value (repeatable, $VAR expanded, identity:<claim> from the client)")
+	flag.BoolVar(&flags.coerceArgs, "coerce-args", false, "Convert mistyped tool arguments (e.g. \"5\" for an integer) to the schema's types before validation")
+	flag.Var(&flags.async, "async", "Wait for the 202 Accepted operations of this operationId to complete, polling their status monitor (\"*\" for all, repeatable)")
+	flag.DurationVar(&flags.asyncDeadline, "async-deadline", 0, "How long a tool call waits for an asynchronous operation before returning a resume handle (default 1m)")
+	flag.IntVar(&flags.streamMaxEvents, "stream-max-events", 0, "Stop relaying a streaming (SSE or NDJSON) response after this many events (default 1000)")
+	flag.DurationVar(&flags.streamMaxDuration, "stream-max-duration", 0, "Stop relaying a streaming (SSE or NDJSON) response after this long (default 1m)")
+	flag.StringVar(&flags.streamTerminator, "stream-terminator", "", "Stop relaying a streaming response at the first event whose data matches this regular expression (e.g. '^\\[DONE\\]$')")
+	flag.Var(&flags.paginate, "paginate", "Fetch and merge the following pages of the lists returned by this GET operationId (\"*\" for all, repeatable)")
+	flag.IntVar(&flags.paginateMaxItems, "paginate-max-items", 0, "Stop fetching pages once a tool call has this many items (default 1000)")
+	flag.IntVar(&flags.paginateMaxPages, "paginate-max-pages", 0, "Fetch at most this many pages per tool call (default 10)")
+	flag.Int64Var(&flags.paginateMaxBytes, "paginate-max-bytes", 0, "Stop fetching pages once a tool call has received this many bytes (default 1048576)")
+	flag.BoolVar(&flags.mock, "mock", false, "Simulate API responses from the spec's examples and schemas instead of calling the API")
 	flag.Var(&flags.headers, "header", "Add custom header to API requests (format: 'Key: Value') (repeatable)")
 	flag.Parse()
 	flags.args = flag.Args()
@@ -117,6 +208,27 @@ func setEnvFromFlags(flags *cliFlags) {
 	if flags.basicAuth != "" {
 		os.Setenv("BASIC_AUTH", flags.basicAuth)
 	}
+	if flags.oauthClientID != "" {
+		os.Setenv("OAUTH_CLIENT_ID", flags.oauthClientID)
+	}
+	if flags.oauthClientSecret != "" {
+		os.Setenv("OAUTH_CLIENT_SECRET", flags.oauthClientSecret)
+	}
+	if flags.oauthTokenURL != "" {
+		os.Setenv("OAUTH_TOKEN_URL", flags.oauthTokenURL)
+	}
+	if flags.oauthScopes != "" {
+		os.Setenv("OAUTH_SCOPES", flags.oauthScopes)
+	}
+	if flags.oauthTenant != "" {
+		os.Setenv("OAUTH_TENANT", flags.oauthTenant)
+	}
+	if flags.oauthTenants != "" {
+		os.Setenv("OAUTH_TENANTS", flags.oauthTenants)
+	}
+	if flags.credentialFile != "" {
+		os.Setenv("OAUTH_CREDENTIAL_FILE", flags.credentialFile)
+	}
 	if flags.includeDescRegex != "" {
 		os.Setenv("INCLUDE_DESC_REGEX", flags.includeDescRegex)
 	}
@@ -140,18 +252,23 @@ Usage:
   openapi-mcp [flags] filter <openapi-spec-path>
   openapi-mcp [flags] validate <openapi-spec-path>
   openapi-mcp [flags] lint <openapi-spec-path>
+  openapi-mcp [flags] auth login <openapi-spec-path>
   openapi-mcp [flags] <openapi-spec-path>
 
 Commands:
   filter <openapi-spec-path>    Output a filtered list of operations as JSON, applying --tag, --include-desc-regex, --exclude-desc-regex, and --function-list-file (no server)
   validate <openapi-spec-path>  Validate the OpenAPI spec and report actionable errors (with --http: starts validation API server)
   lint <openapi-spec-path>      Perform detailed OpenAPI linting with comprehensive suggestions (with --http: starts linting API server)
+  auth login <openapi-spec-path> Log in with the spec's OAuth2 authorizationCode (PKCE) or device flow and store the tokens
+  auth hash-password <user>     Print a --auth-users-file entry for a password read from stdin
 
 Examples:
 
   Basic MCP Server (stdio):
     openapi-mcp api.yaml                          # Start stdio MCP server
     openapi-mcp --api-key=key123 api.yaml         # With API authentication
+    openapi-mcp --credential=appKey=key123 --credential=userToken=env:USER_TOKEN api.yaml  # One credential per scheme
+    openapi-mcp --credential=appKey=file:/run/secrets/app-key --credential='bearer=exec:vault-helper' api.yaml
 
   MCP Server over HTTP (single API):
     openapi-mcp --http=:8080 api.yaml             # HTTP server on port 8080
@@ -163,6 +280,13 @@ Examples:
     # Each API is served at its own base path (e.g., /petstore, /books) using StreamableHTTP by default
     # If --mount is used, positional OpenAPI spec arguments are ignored in HTTP mode.
 
+    # Require MCP clients to authenticate:
+    openapi-mcp --http=:8080 --auth-token='ci=$MCP_TOKEN' api.yaml
+    openapi-mcp --http=:8080 --auth-jwks-file=jwks.json --auth-issuer=https://idp.example.com --auth-audience=openapi-mcp api.yaml
+    openapi-mcp --http=:8080 --auth-jwks-file=jwks.json --auth-issuer=https://idp.example.com --auth-resource-url=https://mcp.example.com api.yaml
+    openapi-mcp --http=:8080 --auth-users-file=users.txt --policy=policy.yaml --audit-log=audit.jsonl api.yaml
+    openapi-mcp --http=:8443 --tls-cert=server.pem --tls-key=server.key --tls-client-ca=clients-ca.pem api.yaml
+
     # With authentication via HTTP headers:
     curl -H "X-API-Key: your_key" http://localhost:8080/mcp -d '...'
     curl -H "Authorization: Bearer your_token" http://localhost:8080/mcp -d '...'
@@ -182,6 +306,7 @@ Examples:
     openapi-mcp filter --tag=admin api.yaml              # Output only admin-tagged operations as JSON
     openapi-mcp filter --include-desc-regex=foo api.yaml # Output operations whose description matches 'foo'
     openapi-mcp filter --function-list-file=funcs.txt api.yaml # Output only operations listed in funcs.txt
+    openapi-mcp filter --overlay=llm.yaml api.yaml       # Output the spec with an overlay applied
 
   Advanced Configuration:
     openapi-mcp --base-url=https://api.prod.com api.yaml    # Override base URL
@@ -190,18 +315,78 @@ Examples:
     openapi-mcp --http-transport=sse --http=:8080 api.yaml  # Use SSE transport
     openapi-mcp --header="X-Custom-Header: value" --header="X-Another: value2" api.yaml  # Add custom headers
 
+  Recording & Replay:
+    openapi-mcp --record-har=session.har api.yaml           # Record upstream traffic
+    openapi-mcp --replay-har=session.har api.yaml           # Replay it offline
+    openapi-mcp --replay-har=session.har --replay-match=method,path,query,body api.yaml
+    openapi-mcp --mock api.yaml                             # Simulate responses from the spec
+
+  User Login (OAuth2 authorization code or device flow):
+    openapi-mcp --oauth-client-id=cli-app auth login api.yaml  # Log in once, tokens are stored
+    openapi-mcp --oauth-client-id=cli-app api.yaml             # The server refreshes them as needed
+
+  Fixed Parameter Values:
+    openapi-mcp --pin='account_id=$ACCOUNT_ID' api.yaml     # Always send account_id, hide it from tools
+    openapi-mcp --pin=tag:billing:currency=EUR --default=limit=20 api.yaml
+    openapi-mcp --http=:8080 --auth-jwks-file=jwks.json --pin=tenant_id=identity:tenant api.yaml
+
 
 Flags:
   --extended           Enable extended (human-friendly) output (default: minimal/agent)
   --api-key            API key for authenticated endpoints
   --base-url           Override the base URL for HTTP calls
+  --server-var         Value of a server URL variable: name=value (repeatable; default: the spec's default)
   --bearer-token       Bearer token for Authorization header
   --basic-auth         Basic auth (user:pass) for Authorization header
+  --credential         Credential for a named security scheme: schemeName=value (repeatable; literal, "$" included).
+                       API keys, bearer tokens, user:pass for basic auth, or access tokens for oauth2 schemes.
+                       Without it, apiKey schemes use API_KEY, bearer and oauth2 schemes BEARER_TOKEN, basic ones BASIC_AUTH
+                       env:<VAR> reads the value from an environment variable at call time
+                       file:<path> reads the value from a file, re-read when it changes (e.g. a mounted secret)
+                       exec:<command> runs a credential helper: JSON {"action":"get","scheme":...} on stdin,
+                       {"value":"...","expires_in":3600} on stdout. Both are cached and refreshed after a 401
+  --credential-ttl     How long file: and exec: credentials are cached (default: 5m; helpers may set expires_in)
+  --signer             Sign requests for a security scheme: schemeName=kind[,key=value...] (repeatable), e.g.
+                       api=aws-sigv4,region=us-east-1,service=execute-api  (credential: keyId:secret[:token] or AWS_* env)
+                       api=hmac,keyId=k1,header=Signature,headers=(request-target) date digest  (credential: secret)
+                       Schemes can also declare x-mcp-auth: {type: aws-sigv4|hmac, ...} in the spec
+  --oauth-client-id    OAuth2 client id: oauth2 schemes with a clientCredentials flow fetch, cache and refresh tokens
+  --oauth-client-secret OAuth2 client secret
+  --oauth-token-url    Override the spec's tokenUrl ({tenant} is replaced with the tenant)
+  --oauth-scopes       Scopes to request (default: the scopes required by each operation)
+  --oauth-tenant       Default tenant for multi-tenant token endpoints; identity:<claim> uses the authenticated
+                       client's subject (identity:subject) or JWT claim
+  --oauth-tenants      Tenants clients may select with the X-MCP-Tenant header (comma-separated; others are rejected)
+  --oauth-scheme       Security scheme to log in with (auth login; needed if the spec has several)
+  --oauth-device       Log in with the device flow instead of a browser redirect (auth login)
+  --oauth-redirect-port Loopback port for the login redirect (auth login; default: random)
+  --credential-file    File storing login tokens (default: <user config dir>/openapi-mcp/credentials.json)
   --http               Serve over HTTP on this address (e.g., :8080). For MCP server: serves tools via HTTP. For validate/lint: creates REST API endpoints.
                        In HTTP mode, authentication can also be provided via headers:
                        X-API-Key, Api-Key (for API keys)
                        Authorization: Bearer <token> (for bearer tokens)
                        Authorization: Basic <credentials> (for basic auth)
+  --auth-token         Require MCP clients to authenticate (HTTP): accept the bearer token of subject=token (repeatable)
+  --auth-users-file    Accept HTTP basic auth for the users of this file: user:passwordHash[:group,...] lines,
+                       created with 'openapi-mcp auth hash-password <user>'
+  --auth-jwks-file     Accept bearer JWTs signed by the keys of this JWKS file (read again when it changes)
+  --auth-issuer        Required issuer (iss) of client JWTs
+  --auth-audience      Accepted audiences (aud) of client JWTs, comma-separated
+  --auth-resource-url  Public URL of this server (e.g. https://mcp.example.com). Publishes OAuth 2.0 protected resource
+                       metadata at /.well-known/oauth-protected-resource/<base path>, points 401 challenges to it,
+                       and requires client JWTs to be issued for the endpoint URL (aud), per the MCP authorization spec
+  --auth-authorization-server Authorization server for MCP clients (repeatable; default: --auth-issuer)
+  --auth-scopes        Scopes MCP clients should request, comma-separated
+  --stdio-workers      Maximum number of requests handled concurrently in stdio mode (default 8; 1: one at a time).
+                       Clients can cancel requests in progress with notifications/cancelled
+  --tls-cert           Serve HTTP (MCP, validate and lint) over TLS with this PEM certificate chain;
+                       send SIGHUP to reload it after a renewal
+  --tls-key            PEM private key of --tls-cert
+  --tls-client-ca      Require clients to present a certificate issued by one of these PEM CAs (mTLS);
+                       the certificate's subject identifies the client
+  --policy             Policy file restricting the tools each client may list and call, by subject, group or
+                       token scope (read again when it changes; see README)
+  --audit-log          Append each tool call's policy decision to this file as a JSON line (default: stderr)
   --http-transport     HTTP transport to use for MCP server: 'streamable' (default) or 'sse'
   --include-desc-regex Only include APIs whose description matches this regex
   --exclude-desc-regex Exclude APIs whose description matches this regex
@@ -218,6 +403,32 @@ Flags:
   --log-file           File path to log all MCP requests and responses for debugging
   --no-log-truncation  Disable truncation of long values in human-readable MCP logs
   --header             Add custom header to API requests (format: 'Key: Value') (repeatable)
+  --overlay            Apply an OpenAPI Overlay 1.0 file to the spec (repeatable, applied in order, to each --mount spec too)
+  --record-har         Record every upstream HTTP exchange (timings included, secrets redacted) to a HAR file
+  --replay-har         Serve upstream responses from a HAR file instead of the network
+  --replay-match       Request parts matched during replay: method, path, query, body (default: method,path,query)
+  --pin                Pin a parameter value and remove it from tool schemas:
+                       [spec:<mount base path>:][operation:<id>:|tag:<tag>:][override:]name=value (repeatable)
+                       $VAR/${VAR} are expanded at call time; identity:<claim> sends the client's subject or JWT claim;
+                       with override:, clients may replace the value with an X-MCP-Param-<name> header
+  --default            Like --pin, but the parameter stays visible and the value is only sent when omitted
+  --coerce-args        Convert mistyped arguments ("5" for an integer, "true" for a boolean, JSON strings for
+                       objects, a single value for an array) before validation; conversions are reported in results
+  --async              Wait for the 202 Accepted operations of this operationId to complete (repeatable; "*" for all),
+                       polling the Operation-Location or Location monitor; x-mcp-async does the same from the spec
+  --async-deadline     How long a tool call waits before returning a handle to resume waiting (default: 1m)
+  --stream-max-events  Streaming responses (SSE, NDJSON) are relayed event by event as progress and log notifications;
+                       stop after this many events (default: 1000)
+  --stream-max-duration Stop relaying a streaming response after this long (default: 1m)
+  --stream-terminator  Stop at the first event whose data matches this regular expression, e.g. '^\[DONE\]$'
+  --paginate           Fetch the following pages of the lists returned by this GET operationId and merge their items
+                       (repeatable; "*" for all), following Link headers, cursors, page numbers or offsets;
+                       x-mcp-pagination does the same from the spec
+  --paginate-max-items Stop fetching pages once a call has this many items (default: 1000)
+  --paginate-max-pages Fetch at most this many pages per call (default: 10)
+  --paginate-max-bytes Stop fetching pages once a call has received this many bytes (default: 1048576)
+  --mock               Simulate responses from the spec's examples and schemas instead of calling the API
+                       (select a documented status with the __mock_status argument or X-Mock-Status header)
   --help, -h           Show help
 
 By default, output is minimal and agent-friendly. Use --extended for banners, help, and human-readable output.
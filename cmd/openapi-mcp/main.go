@@ -178,12 +178,17 @@ func main() {
 		}
 	}
 
+	// --- Auth subcommand ---
+	if args[0] == "auth" {
+		handleAuthCommand(flags, args)
+	}
+
 	// --- Validate subcommand ---
 	if args[0] == "validate" {
 		// Check if HTTP mode is requested
 		if flags.httpAddr != "" {
 			fmt.Fprintf(os.Stderr, "Starting OpenAPI validation HTTP server on %s\n", flags.httpAddr)
-			err := openapi2mcp.ServeHTTPLint(flags.httpAddr, false)
+			err := openapi2mcp.ServeHTTPLint(flags.httpAddr, false, tlsOptionsFromFlags(flags)...)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "HTTP server failed: %v\n", err)
 				os.Exit(1)
@@ -196,7 +201,7 @@ func main() {
 			os.Exit(1)
 		}
 		specPath := args[1]
-		doc, err := openapi2mcp.LoadOpenAPISpec(specPath)
+		doc, err := openapi2mcp.LoadOpenAPISpecWithOverlays(specPath, flags.overlays...)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Validation failed: %v\n", err)
 			os.Exit(1)
@@ -224,7 +229,7 @@ func main() {
 		// Check if HTTP mode is requested
 		if flags.httpAddr != "" {
 			fmt.Fprintf(os.Stderr, "Starting OpenAPI linting HTTP server on %s\n", flags.httpAddr)
-			err := openapi2mcp.ServeHTTPLint(flags.httpAddr, true)
+			err := openapi2mcp.ServeHTTPLint(flags.httpAddr, true, tlsOptionsFromFlags(flags)...)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "HTTP server failed: %v\n", err)
 				os.Exit(1)
@@ -237,7 +242,7 @@ func main() {
 			os.Exit(1)
 		}
 		specPath := args[1]
-		doc, err := openapi2mcp.LoadOpenAPISpec(specPath)
+		doc, err := openapi2mcp.LoadOpenAPISpecWithOverlays(specPath, flags.overlays...)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Linting failed: %v\n", err)
 			os.Exit(1)
@@ -266,7 +271,7 @@ func main() {
 			os.Exit(1)
 		}
 		specPath := args[1]
-		doc, err := openapi2mcp.LoadOpenAPISpec(specPath)
+		doc, err := openapi2mcp.LoadOpenAPISpecWithOverlays(specPath, flags.overlays...)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Could not load OpenAPI spec: %v\n", err)
 			os.Exit(1)
@@ -448,7 +453,7 @@ func main() {
 	}
 
 	specPath := args[len(args)-1]
-	doc, err := openapi2mcp.LoadOpenAPISpec(specPath)
+	doc, err := openapi2mcp.LoadOpenAPISpecWithOverlays(specPath, flags.overlays...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Could not load OpenAPI spec: %v\n", err)
 		os.Exit(1)
@@ -236,7 +236,7 @@ func TestHTTPOpenAPIToolHandler(t *testing.T) {
 		"method":  "tools/call",
 		"params": map[string]any{
 			"name":      "createBar",
-			"arguments": map[string]any{"requestBody": map[string]any{"foo": "bar"}},
+			"arguments": map[string]any{"requestBody": map[string]any{"foo": "bar"}, "__confirmed": true},
 		},
 	}
 	postReqJSON, _ := json.Marshal(postReq)
@@ -307,54 +307,30 @@ func TestHTTPOpenAPIToolHandler(t *testing.T) {
 		if !ok {
 			t.Fatalf("expected CallToolResult, got %T", v.Result)
 		}
+		if toolResult.OutputType != "file" {
+			t.Errorf("expected output type file, got %q", toolResult.OutputType)
+		}
 		found := false
 		for _, c := range toolResult.Content {
-			if tc, ok := c.(mcp.TextContent); ok {
-				switch toolResult.OutputType {
-				case "json":
-					var obj map[string]any
-					if err := json.Unmarshal([]byte(tc.Text), &obj); err == nil {
-						if errObj, ok := obj["error"].(map[string]any); ok {
-							if errObj["http_status"] == float64(404) && strings.Contains(errObj["message"].(string), "404") {
-								found = true
-							}
-						}
-					}
-				case "file":
-					var fileObj map[string]any
-					if err := json.Unmarshal([]byte(tc.Text), &fileObj); err == nil {
-						if _, ok := fileObj["file_base64"]; ok {
-							if _, ok := fileObj["mime_type"]; ok {
-								if _, ok := fileObj["file_name"]; ok {
-									found = true
-								}
-							}
-						}
-					}
-				default:
-					if toolResult.OutputType == "file" {
-						var fileObj map[string]any
-						err := json.Unmarshal([]byte(tc.Text), &fileObj)
-						if err != nil {
-							t.Errorf("expected JSON file object, got: %v", tc.Text)
-							continue
-						}
-						if _, ok := fileObj["file_base64"]; !ok {
-							t.Errorf("expected file_base64 in file response, got: %v", fileObj)
-						}
-						if _, ok := fileObj["mime_type"]; !ok {
-							t.Errorf("expected mime_type in file response, got: %v", fileObj)
-						}
-						if _, ok := fileObj["file_name"]; !ok {
-							t.Errorf("expected file_name in file response, got: %v", fileObj)
-						}
-						found = true
-					}
+			if res, ok := c.(mcp.EmbeddedResource); ok {
+				blob, ok := res.Resource.(mcp.BlobResourceContents)
+				if !ok {
+					t.Fatalf("expected BlobResourceContents, got %T", res.Resource)
+				}
+				if blob.MIMEType != "application/octet-stream" {
+					t.Errorf("expected mime type application/octet-stream, got %q", blob.MIMEType)
+				}
+				if blob.Blob != "AQIDBA==" {
+					t.Errorf("expected base64 blob AQIDBA==, got %q", blob.Blob)
+				}
+				if !strings.HasSuffix(blob.URI, "/mock.bin") {
+					t.Errorf("expected resource URI to end with the file name, got %q", blob.URI)
 				}
+				found = true
 			}
 		}
 		if !found {
-			t.Errorf("expected file response for /file, got: %+v", toolResult.Content)
+			t.Errorf("expected embedded resource for /file, got: %+v", toolResult.Content)
 		}
 	default:
 		t.Fatalf("unexpected result type: %T", v)
@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -20,6 +21,8 @@ import (
 // startServer starts the MCP server in stdio or HTTP mode, based on CLI flags.
 // It registers all OpenAPI operations as MCP tools and starts the server.
 func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *openapi3.T) {
+	toolOpts := toolGenOptionsFromFlags(flags)
+	httpOpts := httpOptionsFromFlags(flags)
 	if flags.httpAddr != "" && len(flags.mounts) > 0 {
 		// Check for duplicate base paths
 		basePathCount := make(map[string]int)
@@ -42,28 +45,40 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 		mux := http.NewServeMux()
 		for _, m := range flags.mounts {
 			fmt.Fprintf(os.Stderr, "Loading OpenAPI spec for mount %s: %s...\n", m.BasePath, m.SpecPath)
-			d, err := openapi3.NewLoader().LoadFromFile(m.SpecPath)
+			d, err := openapi2mcp.LoadOpenAPISpecWithOverlays(m.SpecPath, flags.overlays...)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to load OpenAPI spec for %s: %v\n", m.BasePath, err)
 				os.Exit(1)
 			}
 			ops = openapi2mcp.ExtractOpenAPIOperations(d)
-			srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, flags.logFile, flags.noLogTruncation)
+			// Parameter values scoped with spec:<base path>: only apply to this mount
+			mountToolOpts := *toolOpts
+			mountToolOpts.Spec = m.BasePath
+			// Mounted specs may have tools with the same name, which the policy must tell apart
+			if toolOpts.Policy != nil {
+				mountToolOpts.Policy = toolOpts.Policy.Clone()
+			}
+			srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, &mountToolOpts, flags.logFile, flags.noLogTruncation)
 			if logFileHandle != nil {
 				defer logFileHandle.Close()
 			}
+			mountOpts := httpOpts
+			if meta := protectedResourceFromFlags(flags, m.BasePath, d); meta != nil {
+				mountOpts = append(mountOpts[:len(mountOpts):len(mountOpts)], openapi2mcp.WithProtectedResource(*meta))
+				mux.Handle(openapi2mcp.ProtectedResourceMetadataPath(meta.Resource), openapi2mcp.ProtectedResourceHandler(*meta))
+			}
 			var handler http.Handler
 			if flags.httpTransport == "streamable" {
-				handler = openapi2mcp.HandlerForStreamableHTTP(srv, m.BasePath)
+				handler = openapi2mcp.HandlerForStreamableHTTP(srv, m.BasePath, mountOpts...)
 			} else {
-				handler = openapi2mcp.HandlerForBasePath(srv, m.BasePath)
+				handler = openapi2mcp.HandlerForBasePath(srv, m.BasePath, mountOpts...)
 			}
 			mux.Handle(m.BasePath+"/", handler)
 			mux.Handle(m.BasePath, handler) // allow both /base and /base/
 			fmt.Fprintf(os.Stderr, "Mounted %s at %s\n", m.SpecPath, m.BasePath)
 		}
 		fmt.Fprintf(os.Stderr, "Starting multi-mount MCP HTTP server on %s...\n", flags.httpAddr)
-		if err := http.ListenAndServe(flags.httpAddr, mux); err != nil {
+		if err := openapi2mcp.ListenAndServe(flags.httpAddr, mux, httpOpts...); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to start MCP HTTP server: %v\n", err)
 			os.Exit(1)
 		}
@@ -76,24 +91,27 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 			os.Exit(2)
 		}
 		specPath := flags.args[0]
-		d, err := openapi3.NewLoader().LoadFromFile(specPath)
+		d, err := openapi2mcp.LoadOpenAPISpecWithOverlays(specPath, flags.overlays...)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to load OpenAPI spec: %v\n", err)
 			os.Exit(1)
 		}
 		ops := openapi2mcp.ExtractOpenAPIOperations(d)
-		srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, flags.logFile, flags.noLogTruncation)
+		srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, toolOpts, flags.logFile, flags.noLogTruncation)
 		if logFileHandle != nil {
 			defer logFileHandle.Close()
 		}
+		if meta := protectedResourceFromFlags(flags, "/mcp", d); meta != nil {
+			httpOpts = append(httpOpts, openapi2mcp.WithProtectedResource(*meta))
+		}
 		fmt.Fprintf(os.Stderr, "Starting MCP server (HTTP, %s transport) on %s...\n", flags.httpTransport, flags.httpAddr)
 		if flags.httpTransport == "streamable" {
-			if err := openapi2mcp.ServeStreamableHTTP(srv, flags.httpAddr, "/mcp"); err != nil {
+			if err := openapi2mcp.ServeStreamableHTTP(srv, flags.httpAddr, "/mcp", httpOpts...); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to start MCP HTTP server: %v\n", err)
 				os.Exit(1)
 			}
 		} else {
-			if err := openapi2mcp.ServeHTTP(srv, flags.httpAddr, "/mcp"); err != nil {
+			if err := openapi2mcp.ServeHTTP(srv, flags.httpAddr, "/mcp", httpOpts...); err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to start MCP HTTP server: %v\n", err)
 				os.Exit(1)
 			}
@@ -107,19 +125,19 @@ func startServer(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *opena
 		os.Exit(2)
 	}
 	specPath := flags.args[0]
-	d, err := openapi3.NewLoader().LoadFromFile(specPath)
+	d, err := openapi2mcp.LoadOpenAPISpecWithOverlays(specPath, flags.overlays...)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load OpenAPI spec: %v\n", err)
 		os.Exit(1)
 	}
 	ops = openapi2mcp.ExtractOpenAPIOperations(d)
-	srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, flags.logFile, flags.noLogTruncation)
+	srv, logFileHandle := createServerWithOptions("openapi-mcp", d.Info.Version, d, ops, toolOpts, flags.logFile, flags.noLogTruncation)
 	if logFileHandle != nil {
 		defer logFileHandle.Close()
 	}
 	fmt.Fprintln(os.Stderr, "Registered all OpenAPI operations as MCP tools.")
 	fmt.Fprintln(os.Stderr, "Starting MCP server (stdio)...")
-	if err := openapi2mcp.ServeStdio(srv); err != nil {
+	if err := openapi2mcp.ServeStdio(srv, mcpserver.WithWorkerLimit(flags.stdioWorkers)); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to start MCP server: %v\n", err)
 		os.Exit(1)
 	}
@@ -243,15 +261,28 @@ func formatHumanReadableLog(timestamp, logType, method string, id any, data inte
 			if len(result.Content) > 0 {
 				log.WriteString("📋 Response Content:\n")
 				for i, item := range result.Content {
-					if textContent, ok := item.(mcp.TextContent); ok {
-						log.WriteString(fmt.Sprintf("   [%d] Type: %s\n", i+1, textContent.Type))
+					switch c := item.(type) {
+					case mcp.TextContent:
+						log.WriteString(fmt.Sprintf("   [%d] Type: %s\n", i+1, c.Type))
 						// Truncate very long responses
-						if !noTruncation && len(textContent.Text) > 500 {
+						if !noTruncation && len(c.Text) > 500 {
 							log.WriteString(fmt.Sprintf("   [%d] Text: %s... (%d chars total)\n",
-								i+1, textContent.Text[:500], len(textContent.Text)))
+								i+1, c.Text[:500], len(c.Text)))
+						} else {
+							log.WriteString(fmt.Sprintf("   [%d] Text: %s\n", i+1, c.Text))
+						}
+					case mcp.ImageContent:
+						log.WriteString(fmt.Sprintf("   [%d] Type: image (%s, %d base64 chars)\n", i+1, c.MIMEType, len(c.Data)))
+					case mcp.AudioContent:
+						log.WriteString(fmt.Sprintf("   [%d] Type: audio (%s, %d base64 chars)\n", i+1, c.MIMEType, len(c.Data)))
+					case mcp.EmbeddedResource:
+						if blob, ok := c.Resource.(mcp.BlobResourceContents); ok {
+							log.WriteString(fmt.Sprintf("   [%d] Type: resource %s (%s, %d base64 chars)\n", i+1, blob.URI, blob.MIMEType, len(blob.Blob)))
 						} else {
-							log.WriteString(fmt.Sprintf("   [%d] Text: %s\n", i+1, textContent.Text))
+							log.WriteString(fmt.Sprintf("   [%d] Type: resource\n", i+1))
 						}
+					case mcp.ResourceLink:
+						log.WriteString(fmt.Sprintf("   [%d] Type: resource_link %s (%s, %d bytes)\n", i+1, c.URI, c.MIMEType, c.Size))
 					}
 				}
 			}
@@ -406,9 +437,324 @@ func createLoggingHooks(logFilePath string, noLogTruncation bool) (*mcpserver.Ho
 	return hooks, logFile, nil
 }
 
+// toolGenOptionsFromFlags builds the tool options used when serving, including the upstream
+// HTTP client that records to or replays from a HAR file.
+func toolGenOptionsFromFlags(flags *cliFlags) *openapi2mcp.ToolGenOptions {
+	opts := &openapi2mcp.ToolGenOptions{
+		ConfirmDangerousActions: !flags.noConfirmDangerous,
+		Mock:                    flags.mock,
+		ParameterValues:         parameterValuesFromFlags(flags),
+		CoerceArguments:         flags.coerceArgs,
+		Credentials:             credentialsFromFlags(flags),
+		CredentialTTL:           flags.credentialTTL,
+		Signers:                 signersFromFlags(flags),
+		Async:                   asyncFromFlags(flags),
+		Pagination:              paginationFromFlags(flags),
+		ServerVariables:         serverVariablesFromFlags(flags),
+	}
+	if flags.streamMaxEvents != 0 || flags.streamMaxDuration != 0 || flags.streamTerminator != "" {
+		if _, err := regexp.Compile(flags.streamTerminator); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --stream-terminator: %v\n", err)
+			os.Exit(2)
+		}
+		opts.Stream = map[string]openapi2mcp.StreamOptions{"*": {
+			MaxEvents:   flags.streamMaxEvents,
+			MaxDuration: flags.streamMaxDuration,
+			Terminator:  flags.streamTerminator,
+		}}
+	}
+	if flags.policy != "" {
+		policy, err := openapi2mcp.LoadToolPolicy(flags.policy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load policy: %v\n", err)
+			os.Exit(1)
+		}
+		policy.AuditLog = os.Stderr
+		if flags.auditLog != "" && flags.auditLog != "-" {
+			f, err := os.OpenFile(flags.auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to open audit log: %v\n", err)
+				os.Exit(1)
+			}
+			policy.AuditLog = f
+		}
+		opts.Policy = policy
+	} else if flags.auditLog != "" {
+		fmt.Fprintln(os.Stderr, "[WARN] --audit-log has no effect without --policy")
+	}
+	if flags.mock {
+		fmt.Fprintln(os.Stderr, "Mock mode: responses are simulated from the OpenAPI spec")
+	}
+	if flags.recordHAR != "" && flags.replayHAR != "" {
+		fmt.Fprintln(os.Stderr, "Error: --record-har and --replay-har cannot be used together")
+		os.Exit(2)
+	}
+	if flags.recordHAR != "" {
+		recorder, err := openapi2mcp.NewHARRecorder(flags.recordHAR, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create HAR file: %v\n", err)
+			os.Exit(1)
+		}
+		opts.HTTPClient = &http.Client{Transport: recorder}
+		fmt.Fprintf(os.Stderr, "Recording upstream HTTP traffic to: %s\n", flags.recordHAR)
+	}
+	if flags.replayHAR != "" {
+		match, err := openapi2mcp.ParseHARMatchOptions(flags.replayMatch)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --replay-match: %v\n", err)
+			os.Exit(2)
+		}
+		replayer, err := openapi2mcp.NewHARReplayer(flags.replayHAR, match)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load HAR file: %v\n", err)
+			os.Exit(1)
+		}
+		opts.HTTPClient = &http.Client{Transport: replayer}
+		fmt.Fprintf(os.Stderr, "Replaying upstream HTTP traffic from: %s\n", flags.replayHAR)
+	}
+	return opts
+}
+
+// httpOptionsFromFlags builds the options of the MCP HTTP transports, including the authentication
+// of MCP clients. It exits on invalid values.
+func httpOptionsFromFlags(flags *cliFlags) []openapi2mcp.HTTPOption {
+	cfg := openapi2mcp.HTTPAuthConfig{
+		UsersFile: flags.authUsersFile,
+		JWKSFile:  flags.authJWKSFile,
+		Issuer:    flags.authIssuer,
+	}
+	if flags.authAudience != "" {
+		cfg.Audience = strings.Split(flags.authAudience, ",")
+	}
+	if len(flags.authTokens) > 0 {
+		cfg.BearerTokens = make(map[string]string, len(flags.authTokens))
+		for _, s := range flags.authTokens {
+			subject, token, ok := strings.Cut(s, "=")
+			if !ok || subject == "" {
+				fmt.Fprintf(os.Stderr, "Error: invalid --auth-token %q (expected subject=token)\n", s)
+				os.Exit(2)
+			}
+			cfg.BearerTokens[os.ExpandEnv(token)] = subject
+		}
+	}
+	auth, err := openapi2mcp.NewAuthenticator(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: MCP client authentication: %v\n", err)
+		os.Exit(2)
+	}
+	// Advertising an authorization server only makes sense if its tokens are checked
+	if flags.authResourceURL != "" && flags.authJWKSFile == "" {
+		fmt.Fprintln(os.Stderr, "Error: --auth-resource-url requires --auth-jwks-file, to verify the tokens of the authorization server")
+		os.Exit(2)
+	}
+	opts := tlsOptionsFromFlags(flags)
+	if auth == nil {
+		return opts
+	}
+	if flags.httpAddr == "" {
+		fmt.Fprintln(os.Stderr, "[WARN] --auth-* flags only apply to the HTTP transports (--http)")
+	}
+	if flags.authJWKSFile != "" && flags.authAudience == "" {
+		fmt.Fprintln(os.Stderr, "[WARN] --auth-jwks-file without --auth-audience accepts tokens issued for any audience")
+	}
+	return append(opts, openapi2mcp.WithAuthenticator(auth))
+}
+
+// tlsOptionsFromFlags returns the options serving HTTP over TLS, if --tls-cert is set. The certificates
+// are reloaded on SIGHUP. It exits on invalid values.
+func tlsOptionsFromFlags(flags *cliFlags) []openapi2mcp.HTTPOption {
+	if flags.tlsCert == "" && flags.tlsKey == "" && flags.tlsClientCA == "" {
+		return nil
+	}
+	if flags.tlsCert == "" || flags.tlsKey == "" {
+		fmt.Fprintln(os.Stderr, "Error: --tls-cert and --tls-key must be used together (--tls-client-ca requires both)")
+		os.Exit(2)
+	}
+	if flags.httpAddr == "" {
+		fmt.Fprintln(os.Stderr, "[WARN] --tls-* flags only apply to the HTTP transports (--http)")
+		return nil
+	}
+	certs, err := openapi2mcp.LoadTLSCertificates(openapi2mcp.TLSConfig{
+		CertFile:     flags.tlsCert,
+		KeyFile:      flags.tlsKey,
+		ClientCAFile: flags.tlsClientCA,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	certs.ReloadOnSIGHUP()
+	return []openapi2mcp.HTTPOption{openapi2mcp.WithTLS(certs)}
+}
+
+// protectedResourceFromFlags returns the OAuth 2.0 protected resource metadata of the MCP endpoint
+// served at basePath, or nil if --auth-resource-url is not set.
+func protectedResourceFromFlags(flags *cliFlags, basePath string, doc *openapi3.T) *openapi2mcp.ProtectedResourceMetadata {
+	if flags.authResourceURL == "" {
+		return nil
+	}
+	meta := &openapi2mcp.ProtectedResourceMetadata{
+		Resource:             strings.TrimSuffix(flags.authResourceURL, "/") + basePath,
+		AuthorizationServers: flags.authServers,
+	}
+	if len(meta.AuthorizationServers) == 0 && flags.authIssuer != "" {
+		meta.AuthorizationServers = []string{flags.authIssuer}
+	}
+	if len(meta.AuthorizationServers) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --auth-resource-url requires --auth-authorization-server or --auth-issuer")
+		os.Exit(2)
+	}
+	if flags.authScopes != "" {
+		meta.ScopesSupported = strings.Split(flags.authScopes, ",")
+	}
+	if doc != nil && doc.Info != nil {
+		meta.ResourceName = doc.Info.Title
+	}
+	return meta
+}
+
+// envReference matches values that consist of a single $VAR or ${VAR} reference.
+var envReference = regexp.MustCompile(`^\$(\w+|\{\w+\})$`)
+
+// credentialsFromFlags parses the --credential flags, exiting on invalid values.
+func credentialsFromFlags(flags *cliFlags) map[string]string {
+	if len(flags.credentials) == 0 {
+		return nil
+	}
+	credentials := make(map[string]string, len(flags.credentials))
+	for _, s := range flags.credentials {
+		scheme, value, err := openapi2mcp.ParseCredential(s)
+		if err == nil {
+			_, err = openapi2mcp.ParseCredentialSource(scheme, value, flags.credentialTTL)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --credential: %v\n", err)
+			os.Exit(2)
+		}
+		// Values are not expanded, so a bare $VAR was most likely meant as env:VAR
+		if envReference.MatchString(value) {
+			fmt.Fprintf(os.Stderr, "[WARN] --credential %s=%s is sent literally; use %s=env:%s to read the environment variable\n", scheme, value, scheme, strings.Trim(value, "${}"))
+		}
+		credentials[scheme] = value
+	}
+	return credentials
+}
+
+// serverVariablesFromFlags parses the --server-var flags, exiting on invalid values.
+func serverVariablesFromFlags(flags *cliFlags) map[string]string {
+	if len(flags.serverVars) == 0 {
+		return nil
+	}
+	variables := make(map[string]string, len(flags.serverVars))
+	for _, s := range flags.serverVars {
+		name, value, ok := strings.Cut(s, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			fmt.Fprintf(os.Stderr, "Error: invalid --server-var %q (expected name=value)\n", s)
+			os.Exit(2)
+		}
+		variables[strings.TrimSpace(name)] = value
+	}
+	return variables
+}
+
+// signersFromFlags parses the --signer flags, exiting on invalid values.
+func signersFromFlags(flags *cliFlags) map[string]openapi2mcp.RequestSigner {
+	if len(flags.signers) == 0 {
+		return nil
+	}
+	signers := make(map[string]openapi2mcp.RequestSigner, len(flags.signers))
+	for _, s := range flags.signers {
+		scheme, signer, err := openapi2mcp.ParseSigner(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --signer: %v\n", err)
+			os.Exit(2)
+		}
+		signers[scheme] = signer
+	}
+	return signers
+}
+
+// parameterValuesFromFlags parses the --pin and --default flags, exiting on invalid values.
+// Values scoped to a spec must name the base path of a --mount.
+func parameterValuesFromFlags(flags *cliFlags) []openapi2mcp.ParameterValue {
+	var values []openapi2mcp.ParameterValue
+	for _, group := range []struct {
+		flag   string
+		values []string
+		pinned bool
+	}{{"--default", flags.defaults, false}, {"--pin", flags.pins, true}} {
+		for _, s := range group.values {
+			pv, err := openapi2mcp.ParseParameterValue(s, group.pinned)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid %s: %v\n", group.flag, err)
+				os.Exit(2)
+			}
+			if pv.Spec != "" && !isMountBasePath(flags, pv.Spec) {
+				fmt.Fprintf(os.Stderr, "Error: invalid %s %q: spec:%s: is not the base path of a --mount\n", group.flag, s, pv.Spec)
+				os.Exit(2)
+			}
+			values = append(values, pv)
+		}
+	}
+	return values
+}
+
+// isMountBasePath reports whether base is the base path of a --mount.
+func isMountBasePath(flags *cliFlags, base string) bool {
+	for _, m := range flags.mounts {
+		if m.BasePath == base {
+			return true
+		}
+	}
+	return false
+}
+
+// asyncFromFlags returns the asynchronous operations enabled by --async, waited for --async-deadline.
+func asyncFromFlags(flags *cliFlags) map[string]openapi2mcp.AsyncOperation {
+	if len(flags.async) == 0 {
+		if flags.asyncDeadline != 0 {
+			fmt.Fprintln(os.Stderr, "[WARN] --async-deadline has no effect without --async")
+		}
+		return nil
+	}
+	async := map[string]openapi2mcp.AsyncOperation{}
+	for _, value := range flags.async {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				async[id] = openapi2mcp.AsyncOperation{Deadline: flags.asyncDeadline}
+			}
+		}
+	}
+	return async
+}
+
+// paginationFromFlags returns the paginated operations enabled by --paginate, with the --paginate-max-* limits.
+func paginationFromFlags(flags *cliFlags) map[string]openapi2mcp.Pagination {
+	if len(flags.paginate) == 0 {
+		if flags.paginateMaxItems != 0 || flags.paginateMaxPages != 0 || flags.paginateMaxBytes != 0 {
+			fmt.Fprintln(os.Stderr, "[WARN] --paginate-max-items, --paginate-max-pages and --paginate-max-bytes have no effect without --paginate")
+		}
+		return nil
+	}
+	pagination := map[string]openapi2mcp.Pagination{}
+	for _, value := range flags.paginate {
+		for _, id := range strings.Split(value, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				pagination[id] = openapi2mcp.Pagination{
+					MaxItems: flags.paginateMaxItems,
+					MaxPages: flags.paginateMaxPages,
+					MaxBytes: flags.paginateMaxBytes,
+				}
+			}
+		}
+	}
+	return pagination
+}
+
 // createServerWithOptions creates a new MCP server with the given operations and optional logging
-func createServerWithOptions(name, version string, doc *openapi3.T, ops []openapi2mcp.OpenAPIOperation, logFile string, noLogTruncation bool) (*mcpserver.MCPServer, *os.File) {
-	var opts []mcpserver.ServerOption
+func createServerWithOptions(name, version string, doc *openapi3.T, ops []openapi2mcp.OpenAPIOperation, toolOpts *openapi2mcp.ToolGenOptions, logFile string, noLogTruncation bool) (*mcpserver.MCPServer, *os.File) {
+	// Tools relay the events of streaming responses as log messages
+	opts := []mcpserver.ServerOption{mcpserver.WithLogging()}
 	var logFileHandle *os.File
 
 	if logFile != "" {
@@ -423,6 +769,6 @@ func createServerWithOptions(name, version string, doc *openapi3.T, ops []openap
 	}
 
 	srv := mcpserver.NewMCPServer(name, version, opts...)
-	openapi2mcp.RegisterOpenAPITools(srv, ops, doc, nil)
+	openapi2mcp.RegisterOpenAPITools(srv, ops, doc, toolOpts)
 	return srv, logFileHandle
 }
@@ -20,6 +20,7 @@ func handleDryRunMode(flags *cliFlags, ops []openapi2mcp.OpenAPIOperation, doc *
 		PrettyPrint:             true,
 		Version:                 doc.Info.Version,
 		ConfirmDangerousActions: !flags.noConfirmDangerous,
+		ParameterValues:         parameterValuesFromFlags(flags),
 	}
 	openapi2mcp.RegisterOpenAPITools(nil, ops, doc, opts)
 	if flags.summary {
@@ -40,6 +40,10 @@ type CallToolResult struct {
 	//
 	// If not set, this is assumed to be false (the call was successful).
 	IsError bool `json:"isError,omitempty"`
+	// An optional JSON object with a machine-readable form of the result.
+	//
+	// Content should also carry a textual form for clients that do not read structured content.
+	StructuredContent any `json:"structuredContent,omitempty"`
 	// The result type of the tool call
 	ResultType string `json:"resultType,omitempty"`
 	// The input schema for the tool (JSON Schema)
@@ -855,6 +855,26 @@ type EmbeddedResource struct {
 
 func (EmbeddedResource) isContent() {}
 
+// ResourceLink is a reference to a resource that the client can read with
+// resources/read, returned instead of embedding large contents in a tool call result.
+// It must have Type set to "resource_link".
+type ResourceLink struct {
+	Annotated
+	Type string `json:"type"` // Must be "resource_link"
+	// The URI of the linked resource.
+	URI string `json:"uri"`
+	// A human-readable name for the linked resource.
+	Name string `json:"name"`
+	// A description of what the linked resource represents.
+	Description string `json:"description,omitempty"`
+	// The MIME type of the linked resource, if known.
+	MIMEType string `json:"mimeType,omitempty"`
+	// The size of the linked resource in bytes, if known.
+	Size int64 `json:"size,omitempty"`
+}
+
+func (ResourceLink) isContent() {}
+
 // ModelPreferences represents the server's preferences for model selection,
 // requested of the client during sampling.
 //
@@ -243,6 +243,18 @@ func NewEmbeddedResource(resource ResourceContents) EmbeddedResource {
 	}
 }
 
+// Helper function to create a new ResourceLink
+func NewResourceLink(uri, name, description, mimeType string, size int64) ResourceLink {
+	return ResourceLink{
+		Type:        "resource_link",
+		URI:         uri,
+		Name:        name,
+		Description: description,
+		MIMEType:    mimeType,
+		Size:        size,
+	}
+}
+
 // NewToolResultText creates a new CallToolResult with a text content
 func NewToolResultText(text string, schema map[string]any, arguments map[string]any, examples []any, usage string, nextSteps []string) *CallToolResult {
 	return &CallToolResult{
@@ -426,6 +438,17 @@ func ParseContent(contentMap map[string]any) (Content, error) {
 		}
 
 		return NewEmbeddedResource(resourceContents), nil
+
+	case "resource_link":
+		uri := ExtractString(contentMap, "uri")
+		if uri == "" {
+			return nil, fmt.Errorf("resource_link uri is missing")
+		}
+		var size int64
+		if v, ok := contentMap["size"].(float64); ok {
+			size = int64(v)
+		}
+		return NewResourceLink(uri, ExtractString(contentMap, "name"), ExtractString(contentMap, "description"), ExtractString(contentMap, "mimeType"), size), nil
 	}
 
 	return nil, fmt.Errorf("unsupported content type: %s", contentType)
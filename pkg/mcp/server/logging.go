@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+)
+
+// loggingSeverity orders logging levels from the least to the most severe.
+var loggingSeverity = map[mcp.LoggingLevel]int{
+	mcp.LoggingLevelDebug:     0,
+	mcp.LoggingLevelInfo:      1,
+	mcp.LoggingLevelNotice:    2,
+	mcp.LoggingLevelWarning:   3,
+	mcp.LoggingLevelError:     4,
+	mcp.LoggingLevelCritical:  5,
+	mcp.LoggingLevelAlert:     6,
+	mcp.LoggingLevelEmergency: 7,
+}
+
+// SendLogMessageToClient sends a notifications/message log message to the client of the request being
+// handled. Nothing is sent unless the server has the logging capability (see WithLogging), or if the
+// message is less severe than the level the client set with logging/setLevel. Sessions that don't keep
+// a level, such as the ephemeral sessions of streamable HTTP, receive all messages.
+func (s *MCPServer) SendLogMessageToClient(ctx context.Context, level mcp.LoggingLevel, logger string, data any) error {
+	if s.capabilities.logging == nil || !*s.capabilities.logging {
+		return nil
+	}
+	if session, ok := ClientSessionFromContext(ctx).(SessionWithLogging); ok {
+		if loggingSeverity[level] < loggingSeverity[session.GetLogLevel()] {
+			return nil
+		}
+	}
+	params := map[string]any{
+		"level": level,
+		"data":  data,
+	}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	return s.SendNotificationToClient(ctx, "notifications/message", params)
+}
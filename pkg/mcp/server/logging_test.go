@@ -0,0 +1,39 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestSendLogMessageToClient(t *testing.T) {
+	session := &stdioSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	session.Initialize()
+	received := func() []any {
+		var levels []any
+		for len(session.notifications) > 0 {
+			notification := <-session.notifications
+			levels = append(levels, notification.Params.AdditionalFields["level"])
+		}
+		return levels
+	}
+
+	srv := NewMCPServer("test", "1.0.0")
+	ctx := srv.WithContext(context.Background(), session)
+	srv.SendLogMessageToClient(ctx, mcp.LoggingLevelAlert, "tool", "ignored")
+	if levels := received(); len(levels) != 0 {
+		t.Errorf("expected no log messages without the logging capability, got %v", levels)
+	}
+
+	srv = NewMCPServer("test", "1.0.0", WithLogging())
+	// Sessions start at the error level, until the client sets another
+	for _, level := range []mcp.LoggingLevel{mcp.LoggingLevelInfo, mcp.LoggingLevelError} {
+		srv.SendLogMessageToClient(ctx, level, "tool", "message")
+	}
+	session.SetLogLevel(mcp.LoggingLevelDebug)
+	srv.SendLogMessageToClient(ctx, mcp.LoggingLevelDebug, "tool", "message")
+	if levels := received(); len(levels) != 2 || levels[0] != mcp.LoggingLevelError || levels[1] != mcp.LoggingLevelDebug {
+		t.Errorf("unexpected log messages %v", levels)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"sync"
+)
+
+// progressKey is the context key for the progress reporter of a tool call.
+type progressKey struct{}
+
+// ProgressReporter sends progress notifications for a tool call to the client that made it, over
+// the client's transport. Clients ask for them by setting _meta.progressToken in their request.
+type ProgressReporter struct {
+	server *MCPServer
+	ctx    context.Context
+	token  any
+
+	mu   sync.Mutex
+	sent bool
+	last float64
+}
+
+// ProgressReporterFromContext returns the progress reporter of the tool call being handled, or nil if
+// the client didn't ask for progress. Reporting to a nil reporter does nothing.
+func ProgressReporterFromContext(ctx context.Context) *ProgressReporter {
+	reporter, _ := ctx.Value(progressKey{}).(*ProgressReporter)
+	return reporter
+}
+
+// Report sends a progress notification. total is 0 if unknown, and message may be empty.
+// As progress must increase, values not above the last one reported are ignored.
+func (p *ProgressReporter) Report(progress, total float64, message string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	if p.sent && progress <= p.last {
+		p.mu.Unlock()
+		return
+	}
+	p.sent, p.last = true, progress
+	p.mu.Unlock()
+
+	params := map[string]any{
+		"progressToken": p.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	// Progress is best effort: it is dropped if the client can't receive notifications
+	_ = p.server.SendNotificationToClient(p.ctx, "notifications/progress", params)
+}
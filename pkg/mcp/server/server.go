@@ -990,6 +990,9 @@ func (s *MCPServer) handleToolCall(
 		finalHandler = mw[i](finalHandler)
 	}
 
+	if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+		ctx = context.WithValue(ctx, progressKey{}, &ProgressReporter{server: s, ctx: ctx, token: request.Params.Meta.ProgressToken})
+	}
 	result, err := finalHandler(ctx, request)
 	if err != nil {
 		return nil, &requestError{
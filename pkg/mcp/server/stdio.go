@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"syscall"
 
@@ -28,8 +31,18 @@ type StdioServer struct {
 	server      *MCPServer
 	errLogger   *log.Logger
 	contextFunc StdioContextFunc
+	workers     int
+
+	writeMu  sync.Mutex
+	inflight sync.Map // request ID (raw JSON) -> *context.CancelCauseFunc
 }
 
+// DefaultStdioWorkers is the default number of requests a StdioServer handles concurrently.
+const DefaultStdioWorkers = 8
+
+// errRequestCancelled is the cause of the context of a request cancelled by the client.
+var errRequestCancelled = errors.New("request cancelled by the client")
+
 // StdioOption defines a function type for configuring StdioServer
 type StdioOption func(*StdioServer)
 
@@ -40,6 +53,14 @@ func WithErrorLogger(logger *log.Logger) StdioOption {
 	}
 }
 
+// WithWorkerLimit sets the maximum number of requests handled concurrently (DefaultStdioWorkers if
+// not set). Requests beyond it wait for a request to complete; 1 handles requests one at a time.
+func WithWorkerLimit(n int) StdioOption {
+	return func(s *StdioServer) {
+		s.workers = n
+	}
+}
+
 // WithStdioContextFunc sets a function that will be called to customise the context
 // to the server. Note that the stdio server uses the same context for all requests,
 // so this function will only be called once per server instance.
@@ -138,12 +159,21 @@ func (s *StdioServer) handleNotifications(ctx context.Context, stdout io.Writer)
 }
 
 // processInputStream continuously reads and processes messages from the input stream.
-// It handles EOF gracefully as a normal termination condition.
-// The function returns when either:
+// Requests are handled concurrently, up to the worker limit; notifications are handled in order,
+// as they arrive. It handles EOF gracefully as a normal termination condition.
+// The function returns, once the requests in progress are complete, when either:
 // - The context is cancelled (returns context.Err())
 // - EOF is encountered (returns nil)
 // - An error occurs while reading or processing messages (returns the error)
 func (s *StdioServer) processInputStream(ctx context.Context, reader *bufio.Reader, stdout io.Writer) error {
+	workers := s.workers
+	if workers <= 0 {
+		workers = DefaultStdioWorkers
+	}
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	for {
 		if err := ctx.Err(); err != nil {
 			return err
@@ -158,13 +188,60 @@ func (s *StdioServer) processInputStream(ctx context.Context, reader *bufio.Read
 			return err
 		}
 
-		if err := s.processMessage(ctx, line, stdout); err != nil {
-			if err == io.EOF {
-				return nil
+		var envelope struct {
+			ID     json.RawMessage `json:"id"`
+			Method mcp.MCPMethod   `json:"method"`
+		}
+		_ = json.Unmarshal([]byte(line), &envelope)
+		id := strings.TrimSpace(string(envelope.ID))
+		// Notifications, responses and the initialize request are handled before reading on
+		if id == "" || id == "null" || envelope.Method == "" || envelope.Method == mcp.MethodInitialize {
+			if envelope.Method == "notifications/cancelled" {
+				s.cancelRequest(line)
 			}
-			s.errLogger.Printf("Error handling message: %v", err)
-			return err
+			if err := s.processMessage(ctx, line, stdout); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				s.errLogger.Printf("Error handling message: %v", err)
+				return err
+			}
+			continue
 		}
+
+		reqCtx, cancel := context.WithCancelCause(ctx)
+		entry := &cancel
+		s.inflight.Store(id, entry)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer s.inflight.CompareAndDelete(id, entry)
+			defer cancel(nil)
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-reqCtx.Done():
+				return
+			}
+			if err := s.processMessage(reqCtx, line, stdout); err != nil {
+				s.errLogger.Printf("Error handling message: %v", err)
+			}
+		}()
+	}
+}
+
+// cancelRequest cancels the context of the request named by a notifications/cancelled message.
+func (s *StdioServer) cancelRequest(line string) {
+	var notification struct {
+		Params struct {
+			RequestID json.RawMessage `json:"requestId"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal([]byte(line), &notification); err != nil {
+		return
+	}
+	if cancel, ok := s.inflight.Load(strings.TrimSpace(string(notification.Params.RequestID))); ok {
+		(*cancel.(*context.CancelCauseFunc))(errRequestCancelled)
 	}
 }
 
@@ -255,8 +332,9 @@ func (s *StdioServer) processMessage(
 	// Handle the message using the wrapped server
 	response := s.server.HandleMessage(ctx, rawMessage)
 
-	// Only write response if there is one (not for notifications)
-	if response != nil {
+	// Only write response if there is one (not for notifications), and not for requests the
+	// client cancelled, as it ignores them
+	if response != nil && !errors.Is(context.Cause(ctx), errRequestCancelled) {
 		if err := s.writeResponse(response, writer); err != nil {
 			return fmt.Errorf("failed to write response: %w", err)
 		}
@@ -266,6 +344,7 @@ func (s *StdioServer) processMessage(
 }
 
 // writeResponse marshals and writes a JSON-RPC response message followed by a newline.
+// Concurrent writes are serialized, so that messages are never interleaved.
 // Returns an error if marshaling or writing fails.
 func (s *StdioServer) writeResponse(
 	response mcp.JSONRPCMessage,
@@ -277,7 +356,9 @@ func (s *StdioServer) writeResponse(
 	}
 
 	// Write response followed by newline
-	if _, err := fmt.Fprintf(writer, "%s\n", responseBytes); err != nil {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if _, err := writer.Write(append(responseBytes, '\n')); err != nil {
 		return err
 	}
 
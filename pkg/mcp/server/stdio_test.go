@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+)
+
+func TestStdioServer_ConcurrentRequestsAndCancellation(t *testing.T) {
+	srv := NewMCPServer("test", "1.0.0")
+	started := make(chan struct{})
+	aborted := make(chan error, 1)
+	srv.AddTool(mcp.Tool{Name: "slow", InputSchema: mcp.ToolInputSchema{Type: "object"}}, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		close(started)
+		<-ctx.Done()
+		aborted <- ctx.Err()
+		return mcp.NewToolResultText("too late", nil, nil, nil, "", nil), nil
+	})
+
+	stdinReader, stdin := io.Pipe()
+	stdout, stdoutWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewStdioServer(srv).Listen(context.Background(), stdinReader, stdoutWriter)
+		stdoutWriter.Close()
+	}()
+	responses := bufio.NewScanner(stdout)
+	send := func(message string) {
+		if _, err := io.WriteString(stdin, message+"\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	receive := func() map[string]any {
+		t.Helper()
+		if !responses.Scan() {
+			t.Fatalf("no response: %v", responses.Err())
+		}
+		var response map[string]any
+		if err := json.Unmarshal(responses.Bytes(), &response); err != nil {
+			t.Fatalf("invalid response %q: %v", responses.Text(), err)
+		}
+		return response
+	}
+
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	receive()
+	send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+
+	// A slow tool call doesn't block other requests
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow","arguments":{}}}`)
+	<-started
+	send(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if response := receive(); response["id"] != float64(3) {
+		t.Fatalf("expected the ping response first, got %v", response)
+	}
+
+	// Cancelling the call cancels its context, and no response is sent for it
+	send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2,"reason":"user abort"}}`)
+	select {
+	case err := <-aborted:
+		if err != context.Canceled {
+			t.Errorf("unexpected context error %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the cancelled request's context was not cancelled")
+	}
+	send(`{"jsonrpc":"2.0","id":4,"method":"ping"}`)
+	if response := receive(); response["id"] != float64(4) {
+		t.Errorf("expected no response for the cancelled request, got %v", response)
+	}
+
+	stdin.Close()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestStdioServer_WorkerLimit(t *testing.T) {
+	srv := NewMCPServer("test", "1.0.0")
+	release := make(chan struct{})
+	running := make(chan struct{}, 10)
+	srv.AddTool(mcp.Tool{Name: "block", InputSchema: mcp.ToolInputSchema{Type: "object"}}, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		running <- struct{}{}
+		<-release
+		return mcp.NewToolResultText("done", nil, nil, nil, "", nil), nil
+	})
+
+	stdinReader, stdin := io.Pipe()
+	stdout, stdoutWriter := io.Pipe()
+	s := NewStdioServer(srv)
+	WithWorkerLimit(2)(s)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Listen(context.Background(), stdinReader, stdoutWriter)
+		stdoutWriter.Close()
+	}()
+	go io.Copy(io.Discard, stdout)
+
+	for _, id := range []string{"1", "2", "3"} {
+		io.WriteString(stdin, `{"jsonrpc":"2.0","id":`+id+`,"method":"tools/call","params":{"name":"block","arguments":{}}}`+"\n")
+	}
+	<-running
+	<-running
+	select {
+	case <-running:
+		t.Error("expected at most 2 requests to run at once")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	<-running
+	stdin.Close()
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -243,39 +243,40 @@ func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request
 	}
 
 	// handle potential notifications
-	mu := sync.Mutex{}
 	upgraded := false
 	done := make(chan struct{})
-	defer close(done)
+	stopped := make(chan struct{})
+
+	// writeNotification sends a notification as an SSE event. It is called by the relay goroutine
+	// below while the message is handled, and then by this one.
+	writeNotification := func(nt mcp.JSONRPCNotification) {
+		defer func() {
+			flusher, ok := w.(http.Flusher)
+			if ok {
+				flusher.Flush()
+			}
+		}()
+
+		// if there's notifications, upgrade to SSE response
+		if !upgraded {
+			upgraded = true
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Connection", "keep-alive")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusAccepted)
+		}
+		err := writeSSEEvent(w, nt)
+		if err != nil {
+			s.logger.Errorf("Failed to write SSE event: %v", err)
+		}
+	}
 
 	go func() {
+		defer close(stopped)
 		for {
 			select {
 			case nt := <-session.notificationChannel:
-				func() {
-					mu.Lock()
-					defer mu.Unlock()
-					defer func() {
-						flusher, ok := w.(http.Flusher)
-						if ok {
-							flusher.Flush()
-						}
-					}()
-
-					// if there's notifications, upgrade to SSE response
-					if !upgraded {
-						upgraded = true
-						w.Header().Set("Content-Type", "text/event-stream")
-						w.Header().Set("Connection", "keep-alive")
-						w.Header().Set("Cache-Control", "no-cache")
-						w.WriteHeader(http.StatusAccepted)
-					}
-					err := writeSSEEvent(w, nt)
-					if err != nil {
-						s.logger.Errorf("Failed to write SSE event: %v", err)
-						return
-					}
-				}()
+				writeNotification(nt)
 			case <-done:
 				return
 			case <-ctx.Done():
@@ -286,18 +287,30 @@ func (s *StreamableHTTPServer) handlePost(w http.ResponseWriter, r *http.Request
 
 	// Process message through MCPServer
 	response := s.server.HandleMessage(ctx, rawData)
+	// Stop relaying notifications, once the one being written, if any, is complete
+	close(done)
+	<-stopped
 	if response == nil {
 		// For notifications, just send 202 Accepted with no body
-		w.WriteHeader(http.StatusAccepted)
+		if !upgraded {
+			w.WriteHeader(http.StatusAccepted)
+		}
 		return
 	}
 
 	// Write response
-	mu.Lock()
-	defer mu.Unlock()
 	if ctx.Err() != nil {
 		return
 	}
+	// Notifications sent while handling the request (e.g. progress) precede its response
+	for pending := true; pending; {
+		select {
+		case nt := <-session.notificationChannel:
+			writeNotification(nt)
+		default:
+			pending = false
+		}
+	}
 	if upgraded {
 		if err := writeSSEEvent(w, response); err != nil {
 			s.logger.Errorf("Failed to write final SSE response event: %v", err)
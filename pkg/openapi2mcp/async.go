@@ -0,0 +1,425 @@
+// async.go
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+)
+
+// AsyncOperation configures how a tool waits for an asynchronous operation. When the API answers
+// 202 Accepted with a status monitor URL (an Operation-Location, Azure-AsyncOperation or Location
+// header), the tool polls the monitor with exponential backoff until the operation completes and
+// returns the final resource instead of the empty 202 response. If the deadline passes first, the
+// tool returns a handle with which a later call resumes waiting.
+//
+// The operation is still running while the monitor answers 202, or reports a status such as
+// "running" or "queued" in StatusField. A status such as "failed" or "canceled" is returned as an
+// error. Otherwise the operation completed: the final resource is fetched from ResultField or the
+// monitor's Location header if there is one, and is the monitor's response if there is none.
+type AsyncOperation struct {
+	Interval    time.Duration // delay before the first poll, doubled after each poll (default 1s; Retry-After takes precedence)
+	MaxInterval time.Duration // longest delay between two polls (default 30s)
+	Deadline    time.Duration // how long a tool call waits before returning a resume handle (default 1m)
+	StatusField string        // dot-separated path of the operation status in the monitor's JSON (default "status")
+	ResultField string        // dot-separated path of the final resource URL in the monitor's JSON (default: the Location header)
+}
+
+const (
+	defaultAsyncInterval    = time.Second
+	defaultAsyncMaxInterval = 30 * time.Second
+	defaultAsyncDeadline    = time.Minute
+	// asyncHandleTTL is how long an operation still running when a tool call returned can be resumed.
+	asyncHandleTTL = 24 * time.Hour
+)
+
+// asyncHandleArgument is the reserved tool argument resuming the wait for an operation that was
+// still running when an earlier call returned.
+const asyncHandleArgument = "__async_handle"
+
+var errUnknownAsyncHandle = errors.New("unknown or expired " + asyncHandleArgument)
+
+// asyncOperationFor returns the async configuration of an operation with defaults applied: its
+// x-mcp-async, overridden by the non-zero fields of configured[operationId] or else configured["*"].
+// It returns nil if the operation's 202 responses are returned as is.
+func asyncOperationFor(op OpenAPIOperation, configured map[string]AsyncOperation) *AsyncOperation {
+	override, ok := configured[op.OperationID]
+	if !ok {
+		override, ok = configured["*"]
+	}
+	if !ok && op.Async == nil {
+		return nil
+	}
+	var async AsyncOperation
+	if op.Async != nil {
+		async = *op.Async
+	}
+	if override.Interval > 0 {
+		async.Interval = override.Interval
+	}
+	if override.MaxInterval > 0 {
+		async.MaxInterval = override.MaxInterval
+	}
+	if override.Deadline > 0 {
+		async.Deadline = override.Deadline
+	}
+	if override.StatusField != "" {
+		async.StatusField = override.StatusField
+	}
+	if override.ResultField != "" {
+		async.ResultField = override.ResultField
+	}
+	if async.Interval <= 0 {
+		async.Interval = defaultAsyncInterval
+	}
+	if async.MaxInterval <= 0 {
+		async.MaxInterval = defaultAsyncMaxInterval
+	}
+	if async.MaxInterval < async.Interval {
+		async.MaxInterval = async.Interval
+	}
+	if async.Deadline <= 0 {
+		async.Deadline = defaultAsyncDeadline
+	}
+	if async.StatusField == "" {
+		async.StatusField = "status"
+	}
+	return &async
+}
+
+// asyncStatusState classifies the status reported by a monitor: "running", "failed" or "succeeded".
+// Unknown statuses count as succeeded, so that a final resource with a status of its own
+// (e.g. an order "shipped") is not polled until the deadline.
+func asyncStatusState(status string) string {
+	normalized := strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(status))
+	switch normalized {
+	case "accepted", "notstarted", "queued", "pending", "running", "inprogress", "processing", "started", "waiting", "scheduled", "submitted":
+		return "running"
+	case "failed", "failure", "error", "errored", "cancelled", "canceled", "aborted", "rejected", "timedout":
+		return "failed"
+	}
+	return "succeeded"
+}
+
+// monitorLocation returns the status monitor URL of a 202 Accepted response.
+func monitorLocation(h http.Header) string {
+	for _, name := range []string{"Operation-Location", "Azure-AsyncOperation", "Location"} {
+		if v := h.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// retryAfter parses a Retry-After header: a number of seconds or an HTTP date. It returns 0 if absent.
+func retryAfter(h http.Header) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// lookupField returns the value at a dot-separated path of object fields in a JSON document.
+func lookupField(doc any, path string) (any, bool) {
+	for _, name := range strings.Split(path, ".") {
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if doc, ok = obj[name]; !ok {
+			return nil, false
+		}
+	}
+	return doc, true
+}
+
+// pendingOperation is an asynchronous operation that was still running when a tool call returned.
+type pendingOperation struct {
+	tool    string
+	caller  string   // client that started the operation (see callerFromContext); only it may resume it
+	origin  *url.URL // URL of the request that started the operation; credentials are only sent to its host
+	monitor *url.URL
+	status  string
+	expires time.Time
+}
+
+// asyncHandles remembers the pending operations of the tools of a server, by random handle.
+// Handles do not survive restarts.
+type asyncHandles struct {
+	mu      sync.Mutex
+	pending map[string]pendingOperation
+}
+
+func newAsyncHandles() *asyncHandles {
+	return &asyncHandles{pending: map[string]pendingOperation{}}
+}
+
+// put stores a pending operation under handle, or under a new handle if empty, and returns the handle.
+func (h *asyncHandles) put(handle string, op pendingOperation) string {
+	if handle == "" {
+		handle = randomToken(16)
+	}
+	now := time.Now()
+	op.expires = now.Add(asyncHandleTTL)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for k, p := range h.pending {
+		if now.After(p.expires) {
+			delete(h.pending, k)
+		}
+	}
+	h.pending[handle] = op
+	return handle
+}
+
+// get returns the pending operation of a handle issued by tool to caller.
+func (h *asyncHandles) get(handle, tool, caller string) (pendingOperation, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	op, ok := h.pending[handle]
+	// Another client gets the same answer as for an expired handle
+	if !ok || op.tool != tool || op.caller != caller || time.Now().After(op.expires) {
+		return pendingOperation{}, false
+	}
+	return op, true
+}
+
+func (h *asyncHandles) remove(handle string) {
+	if handle == "" {
+		return
+	}
+	h.mu.Lock()
+	delete(h.pending, handle)
+	h.mu.Unlock()
+}
+
+// asyncWaiter waits for the asynchronous operations started by a tool.
+type asyncWaiter struct {
+	config       *AsyncOperation
+	tool         string
+	tmpl         *operationTemplate
+	client       *http.Client
+	interceptors []RequestInterceptor
+	handles      *asyncHandles
+}
+
+// asyncOutcome is the result of waiting for an asynchronous operation.
+type asyncOutcome struct {
+	resp   *http.Response // final response; nil if the deadline passed first
+	failed bool           // the monitor reported that the operation failed; resp is the monitor's response
+	body   []byte         // body of a failed operation's monitor response
+	status string         // last status reported by the monitor
+	handle string         // if the deadline passed: resumes the wait
+	waited time.Duration
+}
+
+// accepted waits for the operation started by a 202 Accepted response. It returns nil if the
+// response has no status monitor, and then leaves it untouched.
+func (w *asyncWaiter) accepted(ctx context.Context, req *http.Request, resp *http.Response, args map[string]any, progress *callProgress) (*asyncOutcome, error) {
+	location := monitorLocation(resp.Header)
+	if location == "" {
+		return nil, nil
+	}
+	monitor, err := req.URL.Parse(location)
+	if err != nil {
+		return nil, nil
+	}
+	delay := w.config.Interval
+	if retry := retryAfter(resp.Header); retry > 0 {
+		delay = retry
+	}
+	op := pendingOperation{tool: w.tool, caller: callerFromContext(ctx), origin: req.URL, monitor: monitor, status: "accepted"}
+	if body, err := io.ReadAll(resp.Body); err == nil {
+		op.status = w.status(body, op.status)
+	}
+	resp.Body.Close()
+	return w.wait(ctx, "", op, delay, args, progress)
+}
+
+// resume waits for the pending operation of a handle returned by an earlier call.
+func (w *asyncWaiter) resume(ctx context.Context, handle string, args map[string]any, progress *callProgress) (*asyncOutcome, error) {
+	op, ok := w.handles.get(handle, w.tool, callerFromContext(ctx))
+	if !ok {
+		return nil, fmt.Errorf("%w %q: call %s without it to start a new operation", errUnknownAsyncHandle, handle, w.tool)
+	}
+	return w.wait(ctx, handle, op, 0, args, progress)
+}
+
+// wait polls the monitor of a pending operation until it completes or the deadline passes,
+// starting after delay.
+func (w *asyncWaiter) wait(ctx context.Context, handle string, op pendingOperation, delay time.Duration, args map[string]any, progress *callProgress) (*asyncOutcome, error) {
+	start := time.Now()
+	deadline := start.Add(w.config.Deadline)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	interval := w.config.Interval
+	for {
+		if time.Now().Add(delay).After(deadline) {
+			handle = w.handles.put(handle, op)
+			return &asyncOutcome{status: op.status, handle: handle, waited: time.Since(start)}, nil
+		}
+		if delay > 0 {
+			progress.step(fmt.Sprintf("Operation %s, checking again in %s", op.status, delay.Round(100*time.Millisecond)))
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+		resp, err := w.get(ctx, op.origin, op.monitor.String(), args)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		delay, interval = interval, min(interval*2, w.config.MaxInterval)
+		if retry := retryAfter(resp.Header); retry > 0 {
+			delay = retry
+		}
+
+		if resp.StatusCode == http.StatusAccepted {
+			if location := monitorLocation(resp.Header); location != "" {
+				if monitor, err := op.monitor.Parse(location); err == nil {
+					op.monitor = monitor
+				}
+			}
+			op.status = w.status(body, op.status)
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			w.handles.remove(handle)
+			return &asyncOutcome{resp: resp, status: op.status, waited: time.Since(start)}, nil
+		}
+		status := w.status(body, "")
+		if status != "" {
+			op.status = status
+			switch asyncStatusState(status) {
+			case "running":
+				continue
+			case "failed":
+				w.handles.remove(handle)
+				return &asyncOutcome{resp: resp, failed: true, body: body, status: status, waited: time.Since(start)}, nil
+			}
+		}
+		w.handles.remove(handle)
+
+		// The operation completed: fetch the final resource if the monitor points to it
+		result := w.resultLocation(resp.Header, body, status != "")
+		if result != "" {
+			if u, err := op.monitor.Parse(result); err == nil {
+				progress.step("Operation completed, fetching the result")
+				if resp, err = w.get(ctx, op.origin, u.String(), args); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return &asyncOutcome{resp: resp, status: op.status, waited: time.Since(start)}, nil
+	}
+}
+
+// status returns the operation status in a monitor's JSON body, or fallback.
+func (w *asyncWaiter) status(body []byte, fallback string) string {
+	var doc any
+	if json.Unmarshal(body, &doc) != nil {
+		return fallback
+	}
+	if v, ok := lookupField(doc, w.config.StatusField); ok {
+		if s, ok := v.(string); ok && s != "" {
+			return s
+		}
+	}
+	return fallback
+}
+
+// resultLocation returns the URL of the final resource of a completed operation: the ResultField
+// of the monitor's body, or its Location header if the monitor is a status resource.
+func (w *asyncWaiter) resultLocation(h http.Header, body []byte, isStatus bool) string {
+	if w.config.ResultField != "" {
+		var doc any
+		if json.Unmarshal(body, &doc) == nil {
+			if v, ok := lookupField(doc, w.config.ResultField); ok {
+				if s, ok := v.(string); ok && s != "" {
+					return s
+				}
+			}
+		}
+	}
+	if isStatus {
+		return h.Get("Location")
+	}
+	return ""
+}
+
+// get sends an authenticated GET request for a URL returned by the API, retrying once with
+// fresh credentials after a 401.
+func (w *asyncWaiter) get(ctx context.Context, origin *url.URL, ref string, args map[string]any) (*http.Response, error) {
+	return sendFollowUpRequest(ctx, w.client, w.tmpl, w.interceptors, func() (*preparedRequest, error) {
+		return buildFollowUpRequest(ctx, w.tmpl, origin, ref, args)
+	})
+}
+
+// result returns the tool result of an operation that failed or is still running, or nil if
+// it completed and its final response is to be returned like any other.
+func (o *asyncOutcome) result(name string, op OpenAPIOperation, fullURL string, inputSchema, args map[string]any) *mcp.CallToolResult {
+	if o.failed {
+		text := fmt.Sprintf("HTTP %s %s\nError: the asynchronous operation %s (status: %s)", strings.ToUpper(op.Method), fullURL, asyncStatusState(o.status), o.status)
+		if len(o.body) > 0 {
+			text += "\nDetails: " + string(o.body)
+		}
+		text += "\nOperation: " + op.OperationID
+		return mcp.NewToolResultError(text, inputSchema, args, nil, "", nil)
+	}
+	if o.resp != nil {
+		return nil
+	}
+	text := fmt.Sprintf("The operation is still running (status: %s) after waiting %s.\n"+
+		"To keep waiting, call %s again with the same arguments and {\"%s\": \"%s\"} added.",
+		o.status, o.waited.Round(time.Second), name, asyncHandleArgument, o.handle)
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+		StructuredContent: map[string]any{
+			"type":      "async_operation",
+			"operation": op.OperationID,
+			"status":    o.status,
+			"handle":    o.handle,
+		},
+		Schema:       inputSchema,
+		Arguments:    args,
+		Usage:        "call <tool> <json-args>",
+		NextSteps:    []string{"call " + name + " with " + asyncHandleArgument + " to keep waiting"},
+		Partial:      true,
+		ResumeToken:  o.handle,
+		OutputFormat: "structured",
+		OutputType:   "json",
+	}
+}
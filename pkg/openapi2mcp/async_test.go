@@ -0,0 +1,191 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// asyncTestAPI starts a job on POST /jobs and reports its status at /operations/1
+// until it is told the final status, or for succeedAfter polls.
+type asyncTestAPI struct {
+	mu           sync.Mutex
+	status       string
+	succeedAfter int
+	polls        int
+	keys         []string
+}
+
+func (a *asyncTestAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/jobs":
+		w.Header().Set("Operation-Location", "/operations/1")
+		w.WriteHeader(http.StatusAccepted)
+	case "/operations/1":
+		if a.polls++; a.succeedAfter > 0 && a.polls > a.succeedAfter {
+			a.status = "succeeded"
+		}
+		a.keys = append(a.keys, r.Header.Get("X-Key"))
+		if a.status == "succeeded" {
+			w.Header().Set("Location", "/jobs/1")
+		}
+		w.Write([]byte(`{"status": "` + a.status + `", "error": "disk full"}`))
+	case "/jobs/1":
+		w.Write([]byte(`{"id": 1, "result": "converted"}`))
+	}
+}
+
+func (a *asyncTestAPI) setStatus(status string) {
+	a.mu.Lock()
+	a.status = status
+	a.mu.Unlock()
+}
+
+func newAsyncTestServer(t *testing.T, api *asyncTestAPI, async string, opts ToolGenOptions) *server.MCPServer {
+	t.Helper()
+	upstream := httptest.NewServer(api)
+	t.Cleanup(upstream.Close)
+	opts.Credentials = map[string]string{"key": "s3cret"}
+	return newTestServer(t, `
+openapi: 3.0.0
+info: {title: Jobs, version: "1.0"}
+servers: [{url: "`+upstream.URL+`"}]
+components:
+  securitySchemes:
+    key: {type: apiKey, in: header, name: X-Key}
+security: [{key: []}]
+paths:
+  /jobs:
+    post:
+      operationId: startJob
+      x-mcp-async: `+async+`
+      responses: {"202": {description: Accepted}}
+`, &opts)
+}
+
+func TestAsyncOperationCompletes(t *testing.T) {
+	api := &asyncTestAPI{status: "running", succeedAfter: 2}
+	srv := newAsyncTestServer(t, api, "{interval: 0.01}", ToolGenOptions{})
+	result := callTool(t, srv, nil, "startJob", `{}`)
+	if result.IsError || !strings.Contains(resultText(result), `"result": "converted"`) {
+		t.Fatalf("expected the final resource, got %s", resultText(result))
+	}
+	if api.polls != 3 {
+		t.Errorf("expected 3 polls, got %d", api.polls)
+	}
+	for _, key := range api.keys {
+		if key != "s3cret" {
+			t.Errorf("expected the monitor to be polled with credentials, got %q", key)
+		}
+	}
+}
+
+func TestAsyncOperationDeadlineAndResume(t *testing.T) {
+	api := &asyncTestAPI{status: "queued"}
+	srv := newAsyncTestServer(t, api, "{interval: 0.01, deadline: 0.1}", ToolGenOptions{})
+
+	result := callTool(t, srv, nil, "startJob", `{}`)
+	pending, _ := result.StructuredContent.(map[string]any)
+	if result.IsError || !result.Partial || pending["type"] != "async_operation" || pending["status"] != "queued" {
+		t.Fatalf("expected a pending operation, got %s", resultText(result))
+	}
+	handle := pending["handle"].(string)
+	if result.ResumeToken != handle || !strings.Contains(resultText(result), handle) {
+		t.Errorf("expected the handle %q in the result, got %s", handle, resultText(result))
+	}
+
+	// Resuming doesn't start another job
+	api.setStatus("succeeded")
+	handleArg, _ := json.Marshal(map[string]string{asyncHandleArgument: handle})
+	result = callTool(t, srv, nil, "startJob", string(handleArg))
+	if result.IsError || !strings.Contains(resultText(result), `"result": "converted"`) {
+		t.Fatalf("expected the final resource, got %s", resultText(result))
+	}
+	// The handle of a completed operation can't be resumed
+	result = callTool(t, srv, nil, "startJob", string(handleArg))
+	if !result.IsError || !strings.Contains(resultText(result), "unknown or expired") {
+		t.Errorf("expected the handle to be forgotten, got %s", resultText(result))
+	}
+}
+
+func TestAsyncHandleBoundToCaller(t *testing.T) {
+	api := &asyncTestAPI{status: "queued"}
+	srv := newAsyncTestServer(t, api, "{interval: 0.01, deadline: 0.1}", ToolGenOptions{ConfirmDangerousActions: true})
+	alice := &Identity{Subject: "alice", Method: "bearer"}
+	result := callTool(t, srv, alice, "startJob", `{"__confirmed": true}`)
+	pending, _ := result.StructuredContent.(map[string]any)
+	handle, _ := pending["handle"].(string)
+	if handle == "" {
+		t.Fatalf("expected a pending operation, got %s", resultText(result))
+	}
+	api.setStatus("succeeded")
+	handleArg, _ := json.Marshal(map[string]string{asyncHandleArgument: handle})
+
+	// Another client can't resume the operation
+	for _, other := range []*Identity{nil, {Subject: "bob", Method: "bearer"}, {Subject: "alice", Method: "basic"}} {
+		result = callTool(t, srv, other, "startJob", string(handleArg))
+		if !result.IsError || !strings.Contains(resultText(result), "unknown or expired") {
+			t.Errorf("%+v: expected the handle to be rejected, got %s", other, resultText(result))
+		}
+	}
+	// Resuming sends nothing, so it needs no confirmation
+	result = callTool(t, srv, alice, "startJob", string(handleArg))
+	if result.IsError || !strings.Contains(resultText(result), `"result": "converted"`) {
+		t.Errorf("expected the final resource, got %s", resultText(result))
+	}
+}
+
+func TestAsyncOperationFails(t *testing.T) {
+	api := &asyncTestAPI{status: "Failed"}
+	srv := newAsyncTestServer(t, api, "{interval: 0.01}", ToolGenOptions{})
+	result := callTool(t, srv, nil, "startJob", `{}`)
+	text := resultText(result)
+	if !result.IsError || !strings.Contains(text, "operation failed (status: Failed)") || !strings.Contains(text, "disk full") {
+		t.Errorf("expected the failure to be reported, got %s", text)
+	}
+}
+
+func TestFollowUpRequestCredentials(t *testing.T) {
+	doc, _ := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Jobs, version: "1.0"}
+servers: [{url: "https://api.example.com"}]
+components:
+  securitySchemes:
+    key: {type: apiKey, in: query, name: key}
+security: [{key: []}]
+paths:
+  /jobs:
+    post:
+      operationId: startJob
+      responses: {"202": {description: Accepted}}
+`))
+	op := ExtractOpenAPIOperations(doc)[0]
+	tmpl := newOperationTemplate(op, doc, nil, credentialSources(map[string]string{"key": "s3cret"}, nil, 0), nil, nil)
+	origin, _ := http.NewRequest(http.MethodPost, "https://api.example.com/jobs", nil)
+	for ref, want := range map[string]string{
+		"/operations/1":                    "https://api.example.com/operations/1?key=s3cret",
+		"https://status.example.net/ops/1": "https://status.example.net/ops/1",
+	} {
+		prepared, err := buildFollowUpRequest(context.Background(), tmpl, origin.URL, ref, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := prepared.req.URL.String(); got != want {
+			t.Errorf("%s: expected %s, got %s", ref, want, got)
+		}
+	}
+	if _, err := buildFollowUpRequest(context.Background(), tmpl, origin.URL, "file:///etc/passwd", nil); err == nil {
+		t.Error("expected non-HTTP URLs to be rejected")
+	}
+}
@@ -0,0 +1,142 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// benchmarkOperations is the number of operations in the generated benchmark spec.
+const benchmarkOperations = 2000
+
+// largeBenchmarkSpec generates a spec with n operations, each with path, query and header
+// parameters and a JSON request body.
+func largeBenchmarkSpec(b *testing.B, n int) (*openapi3.T, []OpenAPIOperation) {
+	b.Helper()
+	var sb strings.Builder
+	sb.WriteString("openapi: 3.0.0\ninfo: {title: Large, version: '1.0'}\nservers: [{url: 'http://api.example.com'}]\npaths:\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, `  /accounts/{account_id}/items%d:
+    post:
+      operationId: createItem%d
+      parameters:
+        - {name: account_id, in: path, required: true, schema: {type: string}}
+        - {name: "filter[status]", in: query, schema: {type: string, enum: [active, archived]}}
+        - {name: limit, in: query, schema: {type: integer, minimum: 1, maximum: 100}}
+        - {name: X-Request-Id, in: header, schema: {type: string}}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string, minLength: 1}
+                tags: {type: array, items: {type: string}}
+                price: {type: number}
+      responses:
+        "200": {description: OK}
+`, i, i)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(sb.String()))
+	if err != nil {
+		b.Fatalf("loading spec: %v", err)
+	}
+	return doc, ExtractOpenAPIOperations(doc)
+}
+
+// stubTransport answers every request with a small JSON document, without any network access.
+type stubTransport struct{}
+
+func (stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"id":"1"}`)),
+		Request:    req,
+	}, nil
+}
+
+var benchmarkArgs = map[string]any{
+	"account_id":     "acct-1",
+	"filter_status_": "active",
+	"limit":          float64(10),
+	"X-Request-Id":   "req-1",
+	"requestBody":    map[string]any{"name": "widget", "tags": []any{"a", "b"}, "price": 9.5},
+	"__confirmed":    true,
+}
+
+func BenchmarkRegisterLargeSpec(b *testing.B) {
+	doc, ops := largeBenchmarkSpec(b, benchmarkOperations)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		srv := server.NewMCPServer("bench", "1.0.0")
+		RegisterOpenAPITools(srv, ops, doc, &ToolGenOptions{})
+	}
+}
+
+func BenchmarkToolCall(b *testing.B) {
+	doc, ops := largeBenchmarkSpec(b, benchmarkOperations)
+	srv := server.NewMCPServer("bench", "1.0.0")
+	RegisterOpenAPITools(srv, ops, doc, &ToolGenOptions{HTTPClient: &http.Client{Transport: stubTransport{}}})
+	params, _ := json.Marshal(map[string]any{"name": fmt.Sprintf("createItem%d", benchmarkOperations/2), "arguments": benchmarkArgs})
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":` + string(params) + `}`)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		srv.HandleMessage(ctx, msg)
+	}
+}
+
+// BenchmarkValidateArguments compares validation against a schema compiled once at registration
+// with the previous approach of loading the schema on every call.
+func BenchmarkValidateArguments(b *testing.B) {
+	doc, ops := largeBenchmarkSpec(b, 1)
+	_ = doc
+	schemaJSON, _ := json.Marshal(BuildInputSchema(ops[0].Parameters, ops[0].RequestBody))
+
+	b.Run("precompiled", func(b *testing.B) {
+		schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaJSON))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if result, err := schema.Validate(gojsonschema.NewGoLoader(benchmarkArgs)); err != nil || !result.Valid() {
+				b.Fatalf("unexpected validation failure: %v %v", err, result.Errors())
+			}
+		}
+	})
+	b.Run("per-call", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			argsJSON, _ := json.Marshal(benchmarkArgs)
+			if result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schemaJSON), gojsonschema.NewBytesLoader(argsJSON)); err != nil || !result.Valid() {
+				b.Fatalf("unexpected validation failure: %v %v", err, result.Errors())
+			}
+		}
+	})
+}
+
+func BenchmarkBuildOperationRequest(b *testing.B) {
+	doc, ops := largeBenchmarkSpec(b, 1)
+	tmpl := newOperationTemplate(ops[0], doc, nil, nil, nil, nil)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := buildOperationRequest(ctx, tmpl, tmpl.baseURL(), benchmarkArgs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
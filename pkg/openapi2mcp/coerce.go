@@ -0,0 +1,217 @@
+// coerce.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// coerceArguments normalizes tool arguments to the shapes expected by a tool's input schema,
+// fixing the mistakes models commonly make: "5" for an integer, "true" for a boolean, a JSON-encoded
+// string for an object or array, a scalar where an array is expected, or a number for a string.
+// Values that already match, or that cannot be converted unambiguously, are left for validation to report.
+// It returns the (possibly copied) arguments and a description of each coercion applied.
+func coerceArguments(schema map[string]any, args map[string]any) (map[string]any, []string) {
+	var changes []string
+	coerced, _ := coerceObject(schema, args, "", &changes).(map[string]any)
+	return coerced, changes
+}
+
+// coerceValue coerces a value to a schema, recording changes under path.
+func coerceValue(schema map[string]any, value any, path string, changes *[]string) any {
+	if schema == nil || value == nil {
+		return value
+	}
+	typ := schemaType(schema)
+	if typ == "" || matchesType(typ, value) {
+		// Values of other Go types (json.Number, []string...) are left untouched
+		if obj, ok := value.(map[string]any); ok && typ == "object" {
+			return coerceObject(schema, obj, path, changes)
+		}
+		if arr, ok := value.([]any); ok && typ == "array" {
+			return coerceArray(schema, arr, path, changes)
+		}
+		return value
+	}
+
+	coerced, ok := convertValue(typ, value)
+	if !ok {
+		return value
+	}
+	*changes = append(*changes, fmt.Sprintf("%s: %s → %s (%s)", path, describeValue(value), describeValue(coerced), typ))
+	switch typ {
+	case "object":
+		return coerceObject(schema, coerced.(map[string]any), path, changes)
+	case "array":
+		return coerceArray(schema, coerced.([]any), path, changes)
+	}
+	return coerced
+}
+
+// coerceObject coerces the properties of an object, copying it only if something changed.
+func coerceObject(schema map[string]any, obj map[string]any, path string, changes *[]string) any {
+	props, _ := schema["properties"].(map[string]any)
+	var out map[string]any
+	for key, value := range obj {
+		propSchema, _ := props[key].(map[string]any)
+		if propSchema == nil {
+			continue
+		}
+		before := len(*changes)
+		coerced := coerceValue(propSchema, value, joinArgumentPath(path, key), changes)
+		if len(*changes) == before {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]any, len(obj))
+			for k, v := range obj {
+				out[k] = v
+			}
+		}
+		out[key] = coerced
+	}
+	if out == nil {
+		return obj
+	}
+	return out
+}
+
+// coerceArray coerces the items of an array, copying it only if something changed.
+func coerceArray(schema map[string]any, arr []any, path string, changes *[]string) any {
+	items, _ := schema["items"].(map[string]any)
+	if items == nil {
+		return arr
+	}
+	var out []any
+	for i, value := range arr {
+		before := len(*changes)
+		coerced := coerceValue(items, value, fmt.Sprintf("%s[%d]", path, i), changes)
+		if len(*changes) == before {
+			continue
+		}
+		if out == nil {
+			out = append([]any(nil), arr...)
+		}
+		out[i] = coerced
+	}
+	if out == nil {
+		return arr
+	}
+	return out
+}
+
+// convertValue converts a value to a JSON Schema type, reporting whether the conversion is unambiguous.
+func convertValue(typ string, value any) (any, bool) {
+	switch typ {
+	case "integer":
+		switch v := value.(type) {
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return n, true
+			}
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+				return int64(f), true
+			}
+		}
+	case "number":
+		if v, ok := value.(string); ok {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return f, true
+			}
+		}
+	case "boolean":
+		if v, ok := value.(string); ok {
+			switch strings.ToLower(strings.TrimSpace(v)) {
+			case "true":
+				return true, true
+			case "false":
+				return false, true
+			}
+		}
+	case "string":
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case int, int64:
+			return fmt.Sprintf("%d", v), true
+		case bool:
+			return strconv.FormatBool(v), true
+		}
+	case "object":
+		if v, ok := value.(string); ok && strings.HasPrefix(strings.TrimSpace(v), "{") {
+			var obj map[string]any
+			if err := json.Unmarshal([]byte(v), &obj); err == nil {
+				return obj, true
+			}
+		}
+	case "array":
+		if v, ok := value.(string); ok && strings.HasPrefix(strings.TrimSpace(v), "[") {
+			var arr []any
+			if err := json.Unmarshal([]byte(v), &arr); err == nil {
+				return arr, true
+			}
+		}
+		if _, isObject := value.(map[string]any); !isObject {
+			return []any{value}, true
+		}
+	}
+	return nil, false
+}
+
+// schemaType returns the non-null type of a schema, or "" if it has none or several.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		typ := ""
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				if typ != "" {
+					return ""
+				}
+				typ = s
+			}
+		}
+		return typ
+	}
+	return ""
+}
+
+// matchesType reports whether a decoded JSON value already has a JSON Schema type. Values of other
+// Go types, which library callers may pass, match any type so that they are not converted.
+func matchesType(typ string, value any) bool {
+	switch v := value.(type) {
+	case string:
+		return typ == "string"
+	case bool:
+		return typ == "boolean"
+	case float64:
+		return typ == "number" || (typ == "integer" && v == math.Trunc(v))
+	case int, int64:
+		return typ == "integer" || typ == "number"
+	case map[string]any:
+		return typ == "object"
+	case []any:
+		return typ == "array"
+	}
+	return true
+}
+
+func describeValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+func joinArgumentPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
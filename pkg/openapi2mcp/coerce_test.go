@@ -0,0 +1,144 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func TestCoerceArguments(t *testing.T) {
+	var schema map[string]any
+	_ = json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"limit": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"active": {"type": "boolean"},
+			"id": {"type": "string"},
+			"tags": {"type": "array", "items": {"type": "integer"}},
+			"filter": {"type": "object", "properties": {"min": {"type": "integer"}}},
+			"note": {"type": ["string", "null"]}
+		}
+	}`), &schema)
+
+	var args map[string]any
+	_ = json.Unmarshal([]byte(`{
+		"limit": "5",
+		"ratio": "0.5",
+		"active": "TRUE",
+		"id": 42,
+		"tags": "3",
+		"filter": "{\"min\": \"1\"}",
+		"note": null,
+		"other": "7"
+	}`), &args)
+	original, _ := json.Marshal(args)
+
+	got, changes := coerceArguments(schema, args)
+	want := map[string]any{
+		"limit":  int64(5),
+		"ratio":  0.5,
+		"active": true,
+		"id":     "42",
+		"tags":   []any{int64(3)},
+		"filter": map[string]any{"min": int64(1)},
+		"note":   nil,
+		"other":  "7",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected coerced arguments:\n got %#v\nwant %#v", got, want)
+	}
+	if len(changes) != 8 {
+		t.Errorf("expected 8 reported coercions, got %d: %v", len(changes), changes)
+	}
+	if after, _ := json.Marshal(args); string(after) != string(original) {
+		t.Errorf("the original arguments were modified")
+	}
+
+	for _, bad := range []string{`{"limit": "5.5"}`, `{"limit": "five"}`, `{"active": "yes"}`, `{"filter": "min=1"}`} {
+		var in map[string]any
+		_ = json.Unmarshal([]byte(bad), &in)
+		if _, changes := coerceArguments(schema, in); len(changes) != 0 {
+			t.Errorf("%s: ambiguous value should not be coerced: %v", bad, changes)
+		}
+	}
+}
+
+func TestCoerceArgumentsInHandler(t *testing.T) {
+	var gotQuery string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+	os.Setenv("OPENAPI_BASE_URL", ts.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Coerce, version: "1.0"}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      parameters:
+        - {name: limit, in: query, required: true, schema: {type: integer}}
+      responses:
+        "200": {description: OK}
+`))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"listItems","arguments":{"limit":"5"}}}`)
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{})
+	result := srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	if !result.IsError {
+		t.Errorf("without coercion, a string integer should fail validation")
+	}
+
+	srv = server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{CoerceArguments: true})
+	result = srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	if result.IsError || gotQuery != "limit=5" {
+		t.Fatalf("coerced call failed (query %q): %+v", gotQuery, result.Content)
+	}
+	note := result.Content[len(result.Content)-1].(mcp.TextContent).Text
+	if !strings.Contains(note, `limit: "5" → 5 (integer)`) {
+		t.Errorf("coercion not reported in the result: %s", note)
+	}
+}
+
+func TestCoerceArgumentsKeepsOtherTypes(t *testing.T) {
+	var schema map[string]any
+	_ = json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"limit": {"type": "integer"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"labels": {"type": "object", "properties": {"env": {"type": "string"}}}
+		}
+	}`), &schema)
+
+	// Arguments built by library callers rather than decoded from JSON are left as is
+	args := map[string]any{
+		"limit":  json.Number("5"),
+		"tags":   []string{"a", "b"},
+		"labels": map[string]string{"env": "prod"},
+	}
+	got, changes := coerceArguments(schema, args)
+	if !reflect.DeepEqual(got, args) || len(changes) != 0 {
+		t.Errorf("unexpected coercion of %#v: %#v %v", args, got, changes)
+	}
+}
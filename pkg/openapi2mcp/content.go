@@ -0,0 +1,233 @@
+// content.go
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// defaultMaxInlineBinarySize is the largest binary response embedded directly in a tool result
+// when ToolGenOptions.MaxInlineBinarySize is not set.
+const defaultMaxInlineBinarySize = 1 << 20
+
+// temporaryResourceTTL is how long a large binary response stays readable as a temporary resource.
+const temporaryResourceTTL = 15 * time.Minute
+
+// parseContentDispositionFilename extracts the filename from an RFC 6266 Content-Disposition header.
+// The extended "filename*" parameter (RFC 8187) takes precedence over "filename".
+// Returns an empty string if no usable filename is present.
+func parseContentDispositionFilename(cd string) string {
+	if cd == "" {
+		return ""
+	}
+	// mime.ParseMediaType decodes filename* into the "filename" parameter
+	if _, params, err := mime.ParseMediaType(cd); err == nil {
+		return sanitizeFileName(params["filename"])
+	}
+
+	// Lenient fallback for headers that do not strictly follow the grammar
+	// (e.g. unquoted filenames containing spaces)
+	var plain, extended string
+	for _, part := range strings.Split(cd, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "filename":
+			plain = strings.Trim(value, `"`)
+		case "filename*":
+			// charset'language'percent-encoded-value
+			if pieces := strings.SplitN(value, "'", 3); len(pieces) == 3 {
+				if decoded, err := url.PathUnescape(pieces[2]); err == nil {
+					extended = decoded
+				}
+			}
+		}
+	}
+	if extended != "" {
+		return sanitizeFileName(extended)
+	}
+	return sanitizeFileName(plain)
+}
+
+// sanitizeFileName strips any directory components from a server-provided filename.
+func sanitizeFileName(name string) string {
+	name = strings.TrimSpace(strings.ReplaceAll(name, `\`, "/"))
+	if name == "" {
+		return ""
+	}
+	name = path.Base(name)
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// temporaryResource is a large binary response kept for the session whose tool call produced it.
+type temporaryResource struct {
+	uri      string
+	session  string
+	mimeType string
+	data     []byte
+}
+
+// temporaryResourceStore holds the temporary resources of a server within a total size budget:
+// the oldest are dropped to make room for new ones. They are served through a resource template,
+// so they never appear in resources/list.
+type temporaryResourceStore struct {
+	mu        sync.Mutex
+	budget    int
+	size      int
+	resources map[string]*temporaryResource
+	order     []*temporaryResource // oldest first
+}
+
+// temporaryResourceStores records the temporary resource store of each server whose tools produced one.
+var temporaryResourceStores sync.Map
+
+// temporaryResourceTemplate matches the URIs of temporary resources.
+const temporaryResourceTemplate = "response://{id}/{name}"
+
+// temporaryResourceBudget is the total size of the temporary resources a server keeps.
+const temporaryResourceBudget = 64 << 20
+
+// sessionIDFromContext returns the ID of the MCP session of a request, or "" outside a session.
+func sessionIDFromContext(ctx context.Context) string {
+	if session := mcpserver.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+// callerFromContext identifies the client of a request, for the handles that only it may use in later
+// calls: by its identity if it was authenticated, which outlives its sessions, and else by its session.
+func callerFromContext(ctx context.Context) string {
+	if identity := IdentityFromContext(ctx); identity != nil {
+		return "identity:" + identity.Method + ":" + identity.Subject
+	}
+	return "session:" + sessionIDFromContext(ctx)
+}
+
+// temporaryResourcesFor returns the temporary resource store of a server, creating it and registering
+// its resource template on first use.
+func temporaryResourcesFor(server *mcpserver.MCPServer) *temporaryResourceStore {
+	if store, ok := temporaryResourceStores.Load(server); ok {
+		return store.(*temporaryResourceStore)
+	}
+	store, loaded := temporaryResourceStores.LoadOrStore(server, &temporaryResourceStore{
+		budget:    temporaryResourceBudget,
+		resources: map[string]*temporaryResource{},
+	})
+	if !loaded {
+		template := mcp.NewResourceTemplate(temporaryResourceTemplate, "API response",
+			mcp.WithTemplateDescription("Temporary copy of a large API response, readable by the session that received it"))
+		server.AddResourceTemplate(template, store.(*temporaryResourceStore).read)
+	}
+	return store.(*temporaryResourceStore)
+}
+
+// add keeps data readable at uri for temporaryResourceTTL, by the calling session only. It reports
+// false if data doesn't fit in the budget at all; older resources are dropped to make room otherwise.
+func (s *temporaryResourceStore) add(ctx context.Context, uri, mimeType string, data []byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(data) > s.budget {
+		return false
+	}
+	for len(s.order) > 0 && s.size+len(data) > s.budget {
+		s.removeLocked(s.order[0])
+	}
+	resource := &temporaryResource{uri: uri, session: sessionIDFromContext(ctx), mimeType: mimeType, data: data}
+	s.resources[uri] = resource
+	s.order = append(s.order, resource)
+	s.size += len(data)
+	time.AfterFunc(temporaryResourceTTL, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.removeLocked(resource)
+	})
+	return true
+}
+
+// removeLocked drops a resource, if it is still kept.
+func (s *temporaryResourceStore) removeLocked(resource *temporaryResource) {
+	if s.resources[resource.uri] != resource {
+		return
+	}
+	delete(s.resources, resource.uri)
+	for i, r := range s.order {
+		if r == resource {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	s.size -= len(resource.data)
+}
+
+// read serves the temporary resources to the sessions that own them.
+func (s *temporaryResourceStore) read(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	uri := request.Params.URI
+	s.mu.Lock()
+	resource, ok := s.resources[uri]
+	s.mu.Unlock()
+	// Another session gets the same answer as for an expired URI
+	if !ok || resource.session != sessionIDFromContext(ctx) {
+		return nil, fmt.Errorf("%s: %w", uri, mcpserver.ErrResourceNotFound)
+	}
+	return []mcp.ResourceContents{
+		mcp.BlobResourceContents{
+			URI:      uri,
+			MIMEType: resource.mimeType,
+			Blob:     base64.StdEncoding.EncodeToString(resource.data),
+		},
+	}, nil
+}
+
+// binaryResponseContent converts a binary HTTP response body into native MCP content.
+// image/* becomes ImageContent and audio/* becomes AudioContent. Other payloads are embedded
+// as a blob resource, or, when larger than maxInline, kept as a temporary resource of the
+// calling session and returned as a resource link (or still embedded if the server's temporary
+// resources can't hold it).
+// Returns the content and the output type ("image", "audio" or "file").
+func binaryResponseContent(ctx context.Context, server *mcpserver.MCPServer, mimeType, fileName string, data []byte, maxInline int) (mcp.Content, string) {
+	baseType := strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+	if baseType == "" {
+		baseType = "application/octet-stream"
+	}
+	if maxInline <= 0 {
+		maxInline = defaultMaxInlineBinarySize
+	}
+
+	switch {
+	case strings.HasPrefix(baseType, "image/") && len(data) <= maxInline:
+		return mcp.NewImageContent(base64.StdEncoding.EncodeToString(data), baseType), "image"
+	case strings.HasPrefix(baseType, "audio/") && len(data) <= maxInline:
+		return mcp.NewAudioContent(base64.StdEncoding.EncodeToString(data), baseType), "audio"
+	}
+
+	uri := "response://" + uuid.NewString() + "/" + url.PathEscape(fileName)
+	// Responses too large for the temporary resources are embedded like small ones
+	if len(data) <= maxInline || server == nil || !temporaryResourcesFor(server).add(ctx, uri, baseType, data) {
+		return mcp.NewEmbeddedResource(mcp.BlobResourceContents{
+			URI:      uri,
+			MIMEType: baseType,
+			Blob:     base64.StdEncoding.EncodeToString(data),
+		}), "file"
+	}
+
+	description := fmt.Sprintf("Temporary copy of an API response (%d bytes), available for %s", len(data), temporaryResourceTTL)
+	return mcp.NewResourceLink(uri, fileName, description, baseType, int64(len(data))), "file"
+}
@@ -0,0 +1,138 @@
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func TestParseContentDispositionFilename(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"inline", ""},
+		{`attachment; filename="report.pdf"`, "report.pdf"},
+		{"attachment; filename=report.pdf", "report.pdf"},
+		{`attachment; filename="fallback.txt"; filename*=UTF-8''%E2%82%AC%20rates.txt`, "€ rates.txt"},
+		{"attachment; filename*=utf-8''na%C3%AFve.csv", "naïve.csv"},
+		{`attachment; filename="../../etc/passwd"`, "passwd"},
+		{"attachment; filename=my report.pdf", "my report.pdf"},
+	}
+	for _, tt := range tests {
+		if got := parseContentDispositionFilename(tt.header); got != tt.expected {
+			t.Errorf("parseContentDispositionFilename(%q) = %q, want %q", tt.header, got, tt.expected)
+		}
+	}
+}
+
+// testSession is a client session with a fixed ID.
+type testSession string
+
+func (s testSession) Initialize()                                         {}
+func (s testSession) Initialized() bool                                   { return true }
+func (s testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (s testSession) SessionID() string                                   { return string(s) }
+
+func TestBinaryResponseContent(t *testing.T) {
+	srv := server.NewMCPServer("test", "1.0.0")
+	ctx := srv.WithContext(context.Background(), testSession("alice"))
+
+	content, outputType := binaryResponseContent(ctx, srv, "image/png", "a.png", []byte{0x89, 0x50}, 0)
+	if img, ok := content.(mcp.ImageContent); !ok || img.Type != "image" || img.MIMEType != "image/png" || outputType != "image" {
+		t.Errorf("expected image content, got %#v (%s)", content, outputType)
+	}
+
+	content, outputType = binaryResponseContent(ctx, srv, "audio/mpeg; charset=binary", "a.mp3", []byte{1}, 0)
+	if audio, ok := content.(mcp.AudioContent); !ok || audio.MIMEType != "audio/mpeg" || outputType != "audio" {
+		t.Errorf("expected audio content, got %#v (%s)", content, outputType)
+	}
+
+	content, _ = binaryResponseContent(ctx, srv, "application/pdf", "a.pdf", []byte("%PDF"), 0)
+	res, ok := content.(mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("expected embedded resource, got %#v", content)
+	}
+	if blob, ok := res.Resource.(mcp.BlobResourceContents); !ok || blob.Blob != "JVBERg==" {
+		t.Errorf("expected blob resource contents, got %#v", res.Resource)
+	}
+
+	large := bytes.Repeat([]byte{0xff}, 64)
+	content, _ = binaryResponseContent(ctx, srv, "application/zip", "big report.zip", large, 16)
+	link, ok := content.(mcp.ResourceLink)
+	if !ok {
+		t.Fatalf("expected resource link for large payload, got %#v", content)
+	}
+	if link.Type != "resource_link" || link.Size != int64(len(large)) || link.Name != "big report.zip" {
+		t.Errorf("unexpected resource link: %#v", link)
+	}
+
+	// The linked resource must be readable by the session that received it
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"` + link.URI + `"}}`)
+	resp, ok := srv.HandleMessage(ctx, msg).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected resources/read to succeed for %s", link.URI)
+	}
+	readResult, ok := resp.Result.(mcp.ReadResourceResult)
+	if !ok || len(readResult.Contents) != 1 {
+		t.Fatalf("unexpected read result: %#v", resp.Result)
+	}
+
+	// Other sessions can neither read nor list it
+	other := srv.WithContext(context.Background(), testSession("mallory"))
+	if _, ok := srv.HandleMessage(other, msg).(mcp.JSONRPCError); !ok {
+		t.Errorf("expected another session to be denied %s", link.URI)
+	}
+	list := srv.HandleMessage(other, []byte(`{"jsonrpc":"2.0","id":2,"method":"resources/list"}`)).(mcp.JSONRPCResponse)
+	if resources := list.Result.(mcp.ListResourcesResult).Resources; len(resources) != 0 {
+		t.Errorf("expected no listed resources, got %v", resources)
+	}
+}
+
+func TestTemporaryResourceBudget(t *testing.T) {
+	srv := server.NewMCPServer("test", "1.0.0")
+	ctx := srv.WithContext(context.Background(), testSession("alice"))
+	temporaryResourcesFor(srv).budget = 100
+	read := func(srv *server.MCPServer, uri string) bool {
+		msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"` + uri + `"}}`)
+		_, ok := srv.HandleMessage(ctx, msg).(mcp.JSONRPCResponse)
+		return ok
+	}
+
+	var links []mcp.ResourceLink
+	for i := 0; i < 3; i++ {
+		content, _ := binaryResponseContent(ctx, srv, "application/zip", "a.zip", bytes.Repeat([]byte{byte(i)}, 40), 16)
+		link, ok := content.(mcp.ResourceLink)
+		if !ok {
+			t.Fatalf("expected a resource link, got %#v", content)
+		}
+		links = append(links, link)
+	}
+	// The oldest resource was dropped to make room for the third
+	if read(srv, links[0].URI) || !read(srv, links[1].URI) || !read(srv, links[2].URI) {
+		t.Error("expected only the two most recent resources to be kept")
+	}
+	if store := temporaryResourcesFor(srv); store.size != 80 || len(store.order) != 2 {
+		t.Errorf("unexpected store size %d with %d resources", store.size, len(store.order))
+	}
+
+	// A response larger than the budget is embedded instead
+	content, _ := binaryResponseContent(ctx, srv, "application/zip", "huge.zip", bytes.Repeat([]byte{9}, 200), 16)
+	if _, ok := content.(mcp.EmbeddedResource); !ok {
+		t.Errorf("expected an embedded resource, got %#v", content)
+	}
+	if !read(srv, links[2].URI) {
+		t.Error("expected an oversized response not to evict other resources")
+	}
+
+	// Resources belong to the server whose tool produced them
+	other := server.NewMCPServer("other", "1.0.0")
+	temporaryResourcesFor(other)
+	if read(other, links[2].URI) {
+		t.Error("expected another server not to serve the resource")
+	}
+}
@@ -0,0 +1,239 @@
+// credentials.go
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CredentialSource supplies the credential bound to a security scheme. Sources are queried on every
+// call, so they can rotate secrets without a restart.
+//
+// A source may also implement Invalidate(value string), called when the API rejects value with
+// 401 Unauthorized; the call is then retried once with a fresh credential.
+type CredentialSource interface {
+	Credential(ctx context.Context) (string, error)
+}
+
+// DefaultCredentialTTL is how long file and helper credentials are cached (unless a helper sets expires_in).
+const DefaultCredentialTTL = 5 * time.Minute
+
+// ParseCredentialSource parses a credential binding value, as used by --credential:
+//
+//	env:<VAR>       the value of an environment variable, read at call time
+//	file:<path>     the contents of a file (surrounding whitespace trimmed), re-read when it changes
+//	exec:<command>  the output of a credential helper run with sh -c (see CredentialHelper)
+//	<value>         a literal value, sent as is ("$" included)
+//
+// A ttl of 0 selects DefaultCredentialTTL.
+func ParseCredentialSource(scheme, value string, ttl time.Duration) (CredentialSource, error) {
+	if ttl <= 0 {
+		ttl = DefaultCredentialTTL
+	}
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		name := strings.TrimPrefix(value, "env:")
+		if name == "" {
+			return nil, fmt.Errorf("credential for %q: empty environment variable name", scheme)
+		}
+		return envCredential(name), nil
+	case strings.HasPrefix(value, "file:"):
+		path := strings.TrimPrefix(value, "file:")
+		if path == "" {
+			return nil, fmt.Errorf("credential for %q: empty file path", scheme)
+		}
+		return &CredentialFileSource{Path: path, TTL: ttl}, nil
+	case strings.HasPrefix(value, "exec:"):
+		command := strings.TrimPrefix(value, "exec:")
+		if command == "" {
+			return nil, fmt.Errorf("credential for %q: empty helper command", scheme)
+		}
+		return &CredentialHelper{Command: command, Scheme: scheme, TTL: ttl}, nil
+	}
+	return staticCredential(value), nil
+}
+
+// staticCredential is a literal credential. It is never expanded, so that secrets containing "$" are kept intact.
+type staticCredential string
+
+func (s staticCredential) Credential(context.Context) (string, error) {
+	return string(s), nil
+}
+
+// envCredential reads a credential from an environment variable at call time.
+type envCredential string
+
+func (e envCredential) Credential(context.Context) (string, error) {
+	value, ok := os.LookupEnv(string(e))
+	if !ok {
+		return "", fmt.Errorf("credential environment variable %s is not set", string(e))
+	}
+	return value, nil
+}
+
+// CredentialFileSource reads a credential from a file, such as a mounted Kubernetes or Docker secret.
+// The file is re-read when its modification time or size changes, when TTL expires, and after the API
+// rejected the credential.
+type CredentialFileSource struct {
+	Path string
+	TTL  time.Duration
+
+	mu      sync.Mutex
+	value   string
+	modTime time.Time
+	size    int64
+	expiry  time.Time
+}
+
+func (f *CredentialFileSource) Credential(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading credential file: %w", err)
+	}
+	if f.value != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size && time.Now().Before(f.expiry) {
+		return f.value, nil
+	}
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading credential file: %w", err)
+	}
+	f.value = strings.TrimSpace(string(data))
+	f.modTime, f.size = info.ModTime(), info.Size()
+	f.expiry = time.Now().Add(f.TTL)
+	return f.value, nil
+}
+
+// Invalidate forces the file to be re-read.
+func (f *CredentialFileSource) Invalidate(value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.value == value {
+		f.value = ""
+	}
+}
+
+// CredentialHelper obtains a credential from an external command, in the spirit of git's credential
+// helpers. The command is run with sh -c and receives a JSON request on its standard input:
+//
+//	{"action": "get", "scheme": "<security scheme name>"}
+//
+// After the API rejected a credential, the request also has "rejected": "<previous value>", so that the
+// helper can renew it. The helper prints a JSON response on its standard output:
+//
+//	{"value": "<credential>", "expires_in": 3600}
+//
+// expires_in (seconds) is optional and overrides TTL. A non-zero exit status fails the tool call,
+// with the helper's standard error in the message.
+type CredentialHelper struct {
+	Command string
+	Scheme  string
+	TTL     time.Duration
+
+	mu       sync.Mutex
+	value    string
+	expiry   time.Time
+	rejected string
+}
+
+// credentialHelperTimeout bounds the run time of a credential helper.
+const credentialHelperTimeout = 30 * time.Second
+
+func (h *CredentialHelper) Credential(ctx context.Context) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.value != "" && time.Now().Before(h.expiry) {
+		return h.value, nil
+	}
+
+	request := map[string]string{"action": "get", "scheme": h.Scheme}
+	if h.rejected != "" {
+		request["rejected"] = h.rejected
+	}
+	input, _ := json.Marshal(request)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), credentialHelperTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("credential helper for %q failed: %s", h.Scheme, msg)
+	}
+
+	var response struct {
+		Value     string          `json:"value"`
+		ExpiresIn json.RawMessage `json:"expires_in"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return "", fmt.Errorf("credential helper for %q: invalid JSON output: %w", h.Scheme, err)
+	}
+	if response.Value == "" {
+		return "", fmt.Errorf("credential helper for %q returned no value", h.Scheme)
+	}
+	ttl := h.TTL
+	if expiresIn := parseExpiresIn(response.ExpiresIn); expiresIn > 0 {
+		ttl = expiresIn
+	}
+	h.value, h.expiry, h.rejected = response.Value, time.Now().Add(ttl), ""
+	return h.value, nil
+}
+
+// Invalidate drops a rejected credential, so that the helper is asked for a new one.
+func (h *CredentialHelper) Invalidate(value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.value == value {
+		h.value, h.rejected = "", value
+	}
+}
+
+// boundCredential returns the credential of a source. In request previews, sources that could have
+// side effects, such as running a helper, are not queried: only their cached value is used, and
+// errCredentialNotResolved is returned if there is none.
+func boundCredential(ctx context.Context, source CredentialSource) (string, error) {
+	if !isPreview(ctx) {
+		return source.Credential(ctx)
+	}
+	switch s := source.(type) {
+	case staticCredential, envCredential, *CredentialFileSource:
+		return source.Credential(ctx)
+	case *CredentialHelper:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.value != "" && time.Now().Before(s.expiry) {
+			return s.value, nil
+		}
+	}
+	return "", errCredentialNotResolved
+}
+
+// credentialSources parses the credentials bound by name and adds the sources given directly;
+// the latter take precedence. Invalid bindings are reported and skipped.
+func credentialSources(bound map[string]string, sources map[string]CredentialSource, ttl time.Duration) map[string]CredentialSource {
+	result := make(map[string]CredentialSource, len(bound)+len(sources))
+	for scheme, value := range bound {
+		source, err := ParseCredentialSource(scheme, value, ttl)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] %v\n", err)
+			continue
+		}
+		result[scheme] = source
+	}
+	for scheme, source := range sources {
+		result[scheme] = source
+	}
+	return result
+}
@@ -0,0 +1,168 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func TestCredentialFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	source, err := ParseCredentialSource("api", "file:"+path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := source.Credential(context.Background()); err != nil || got != "first" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	// A rotated secret is picked up without waiting for the TTL
+	if err := os.WriteFile(path, []byte("second-value"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := source.Credential(context.Background()); got != "second-value" {
+		t.Errorf("expected the rotated value, got %q", got)
+	}
+	os.Remove(path)
+	if _, err := source.Credential(context.Background()); err == nil {
+		t.Errorf("expected an error for a missing file")
+	}
+}
+
+func TestCredentialLiteralAndEnvSources(t *testing.T) {
+	// Literal values are sent as is, even when they contain a dollar sign
+	source, err := ParseCredentialSource("api", "pa$$word", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := source.Credential(context.Background()); err != nil || got != "pa$$word" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	t.Setenv("OPENAPI_MCP_TEST_KEY", "from-env")
+	source, err = ParseCredentialSource("api", "env:OPENAPI_MCP_TEST_KEY", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := source.Credential(context.Background()); err != nil || got != "from-env" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+
+	source, err = ParseCredentialSource("api", "env:OPENAPI_MCP_TEST_UNSET", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.Credential(context.Background()); err == nil {
+		t.Errorf("expected an error for an unset environment variable")
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	dir := t.TempDir()
+	// The helper returns a new token for every request it gets, and records the requests
+	script := filepath.Join(dir, "helper.sh")
+	os.WriteFile(script, []byte(`#!/bin/sh
+cat >> "$1/requests"; echo >> "$1/requests"
+n=$(wc -l < "$1/requests" | tr -d ' ')
+echo "{\"value\": \"token-$n\", \"expires_in\": 3600}"
+`), 0o700)
+	source, err := ParseCredentialSource("api", "exec:sh "+script+" "+dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	helper := source.(*CredentialHelper)
+	for i := 0; i < 2; i++ {
+		if got, err := helper.Credential(context.Background()); err != nil || got != "token-1" {
+			t.Fatalf("expected the cached token-1, got %q, %v", got, err)
+		}
+	}
+	helper.Invalidate("token-1")
+	if got, _ := helper.Credential(context.Background()); got != "token-2" {
+		t.Errorf("expected a new token after invalidation, got %q", got)
+	}
+	requests, _ := os.ReadFile(filepath.Join(dir, "requests"))
+	lines := strings.Split(strings.TrimSpace(string(requests)), "\n")
+	if len(lines) != 2 || lines[0] != `{"action":"get","scheme":"api"}` || lines[1] != `{"action":"get","rejected":"token-1","scheme":"api"}` {
+		t.Errorf("unexpected helper requests:\n%s", requests)
+	}
+
+	failing := &CredentialHelper{Command: "echo 'vault is sealed' >&2; exit 1", Scheme: "api", TTL: time.Minute}
+	if _, err := failing.Credential(context.Background()); err == nil || !strings.Contains(err.Error(), "vault is sealed") {
+		t.Errorf("expected the helper's error output, got %v", err)
+	}
+}
+
+func TestCredentialRefreshOn401(t *testing.T) {
+	var seen []string
+	valid := "stale"
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-Api-Key")
+		seen = append(seen, key)
+		w.Header().Set("Content-Type", "application/json")
+		if key != valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid key"}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	t.Setenv("OPENAPI_BASE_URL", api.URL)
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Rotation, version: "1.0"}
+components:
+  securitySchemes:
+    key: {type: apiKey, in: header, name: X-Api-Key}
+security: [{key: []}]
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses: {"200": {description: OK}}
+`))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(path, []byte("stale"), 0o600)
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{
+		Credentials: map[string]string{"key": "file:" + path},
+	})
+	call := func() mcp.CallToolResult {
+		t.Helper()
+		msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": map[string]any{"name": "listItems", "arguments": map[string]any{}}})
+		return srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	}
+
+	// Cache the key, then revoke it and rotate the file without changing its size or modification
+	// time: the 401 makes the file be read again
+	if result := call(); result.IsError {
+		t.Fatalf("first call failed: %+v", result.Content)
+	}
+	info, _ := os.Stat(path)
+	valid = "fresh"
+	os.WriteFile(path, []byte("fresh"), 0o600)
+	os.Chtimes(path, info.ModTime(), info.ModTime())
+	seen = nil
+	if result := call(); result.IsError {
+		t.Fatalf("expected the retry to succeed: %+v", result.Content)
+	}
+	if len(seen) != 2 || seen[0] != "stale" || seen[1] != "fresh" {
+		t.Errorf("expected one retry with the rotated key, got %v", seen)
+	}
+}
@@ -0,0 +1,527 @@
+// extensions.go
+package openapi2mcp
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Vendor extensions recognized on operations and parameters (and x-mcp-exclude on tags)
+// to control tool generation from within the spec.
+const (
+	extMCPName        = "x-mcp-name"        // tool (operation) or argument (parameter) name
+	extMCPDescription = "x-mcp-description" // description shown to the model instead of the spec description
+	extMCPHidden      = "x-mcp-hidden"      // if true, the operation or parameter is not exposed
+	extMCPDangerous   = "x-mcp-dangerous"   // overrides method-based detection of operations that require confirmation
+	extMCPTimeout     = "x-mcp-timeout"     // upstream call timeout: a duration ("30s") or a number of seconds
+	extMCPExamples    = "x-mcp-examples"    // example tool arguments (operation) or values (parameter)
+	extMCPAsync       = "x-mcp-async"       // true or an object: wait for 202 Accepted operations to complete (see AsyncOperation)
+	extMCPStream      = "x-mcp-stream"      // limits on relaying streaming responses (see StreamOptions)
+	extMCPPagination  = "x-mcp-pagination"  // true or an object: fetch the following pages of GET list operations (see Pagination)
+	extMCPExclude     = "x-mcp-exclude"     // on a tag: if true, operations with this tag are not exposed
+)
+
+// toolNamePattern is the set of names MCP clients accept for tools and arguments.
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// extString returns a string extension value.
+func extString(ext map[string]any, key string) (string, bool) {
+	s, ok := ext[key].(string)
+	return s, ok && s != ""
+}
+
+// extBool returns a boolean extension value.
+func extBool(ext map[string]any, key string) (value bool, ok bool) {
+	value, ok = ext[key].(bool)
+	return value, ok
+}
+
+// parseMCPTimeout parses an x-mcp-timeout value: a Go duration string or a number of seconds.
+func parseMCPTimeout(v any) (time.Duration, error) {
+	var d time.Duration
+	switch t := v.(type) {
+	case string:
+		var err error
+		if d, err = time.ParseDuration(t); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", t)
+		}
+	case float64:
+		d = time.Duration(t * float64(time.Second))
+	case int:
+		d = time.Duration(t) * time.Second
+	default:
+		return 0, fmt.Errorf("expected a duration string or a number of seconds, got %T", v)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeout must be positive")
+	}
+	return d, nil
+}
+
+// parseAsyncExtension parses an x-mcp-async value: a boolean, or an object with the fields of
+// AsyncOperation (interval, maxInterval, deadline, statusField, resultField). It returns nil if disabled.
+func parseAsyncExtension(v any) (*AsyncOperation, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if !t {
+			return nil, nil
+		}
+		return &AsyncOperation{}, nil
+	case map[string]any:
+		var async AsyncOperation
+		for _, key := range sortedKeys(t) {
+			var err error
+			switch key {
+			case "interval":
+				async.Interval, err = parseMCPTimeout(t[key])
+			case "maxInterval":
+				async.MaxInterval, err = parseMCPTimeout(t[key])
+			case "deadline":
+				async.Deadline, err = parseMCPTimeout(t[key])
+			case "statusField":
+				async.StatusField, err = asyncFieldName(t[key])
+			case "resultField":
+				async.ResultField, err = asyncFieldName(t[key])
+			default:
+				err = fmt.Errorf("unknown field")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+		}
+		return &async, nil
+	}
+	return nil, fmt.Errorf("expected a boolean or an object, got %T", v)
+}
+
+// parseStreamExtension parses an x-mcp-stream object with the fields of StreamOptions
+// (maxEvents, maxDuration, terminator).
+func parseStreamExtension(v any) (*StreamOptions, error) {
+	if v == nil {
+		return nil, nil
+	}
+	fields, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
+	var stream StreamOptions
+	for _, key := range sortedKeys(fields) {
+		var err error
+		switch key {
+		case "maxEvents":
+			if n, isNumber := fields[key].(float64); isNumber && n >= 1 && n == float64(int(n)) {
+				stream.MaxEvents = int(n)
+			} else {
+				err = fmt.Errorf("expected a positive integer, got %v", fields[key])
+			}
+		case "maxDuration":
+			stream.MaxDuration, err = parseMCPTimeout(fields[key])
+		case "terminator":
+			pattern, isString := fields[key].(string)
+			if !isString || pattern == "" {
+				err = fmt.Errorf("expected a regular expression, got %v", fields[key])
+			} else if _, err = regexp.Compile(pattern); err == nil {
+				stream.Terminator = pattern
+			}
+		default:
+			err = fmt.Errorf("unknown field")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	return &stream, nil
+}
+
+// parsePaginationExtension parses an x-mcp-pagination value: a boolean, or an object with the fields
+// of Pagination (itemsField, cursorField, cursorParam, pageParam, offsetParam, maxItems, maxPages,
+// maxBytes). It returns nil if disabled.
+func parsePaginationExtension(v any) (*Pagination, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		if !t {
+			return nil, nil
+		}
+		return &Pagination{}, nil
+	case map[string]any:
+		var pagination Pagination
+		for _, key := range sortedKeys(t) {
+			var err error
+			switch key {
+			case "itemsField":
+				pagination.ItemsField, err = asyncFieldName(t[key])
+			case "cursorField":
+				pagination.CursorField, err = asyncFieldName(t[key])
+			case "cursorParam":
+				pagination.CursorParam, err = asyncFieldName(t[key])
+			case "pageParam":
+				pagination.PageParam, err = asyncFieldName(t[key])
+			case "offsetParam":
+				pagination.OffsetParam, err = asyncFieldName(t[key])
+			case "maxItems", "maxPages", "maxBytes":
+				n, isNumber := t[key].(float64)
+				if !isNumber || n < 1 || n != float64(int64(n)) {
+					err = fmt.Errorf("expected a positive integer, got %v", t[key])
+				} else if key == "maxItems" {
+					pagination.MaxItems = int(n)
+				} else if key == "maxPages" {
+					pagination.MaxPages = int(n)
+				} else {
+					pagination.MaxBytes = int64(n)
+				}
+			default:
+				err = fmt.Errorf("unknown field")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+		}
+		return &pagination, nil
+	}
+	return nil, fmt.Errorf("expected a boolean or an object, got %T", v)
+}
+
+func asyncFieldName(v any) (string, error) {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return "", fmt.Errorf("expected a field name, got %v", v)
+	}
+	return s, nil
+}
+
+// operationExamples returns the x-mcp-examples argument objects of an operation.
+func operationExamples(ext map[string]any) ([]map[string]any, error) {
+	raw, ok := ext[extMCPExamples]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := raw.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array of argument objects, got %T", raw)
+	}
+	examples := make([]map[string]any, 0, len(list))
+	for i, item := range list {
+		args, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("example %d: expected an object of tool arguments, got %T", i+1, item)
+		}
+		examples = append(examples, args)
+	}
+	return examples, nil
+}
+
+// excludedTags returns the tags marked with x-mcp-exclude: true.
+func excludedTags(doc *openapi3.T) map[string]bool {
+	excluded := map[string]bool{}
+	for _, tag := range doc.Tags {
+		if tag == nil {
+			continue
+		}
+		if exclude, _ := extBool(tag.Extensions, extMCPExclude); exclude {
+			excluded[tag.Name] = true
+		}
+	}
+	return excluded
+}
+
+// isOperationHidden reports whether an operation is hidden by x-mcp-hidden or an excluded tag.
+func isOperationHidden(op *openapi3.Operation, excluded map[string]bool) bool {
+	if hidden, _ := extBool(op.Extensions, extMCPHidden); hidden {
+		return true
+	}
+	for _, tag := range op.Tags {
+		if excluded[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// isParameterHidden reports whether a parameter is hidden by x-mcp-hidden.
+func isParameterHidden(p *openapi3.Parameter) bool {
+	hidden, _ := extBool(p.Extensions, extMCPHidden)
+	return hidden
+}
+
+// parameterArgumentName returns the tool argument name of a parameter: its x-mcp-name,
+// or its escaped spec name.
+func parameterArgumentName(p *openapi3.Parameter) string {
+	if name, ok := extString(p.Extensions, extMCPName); ok {
+		return name
+	}
+	return escapeParameterName(p.Name)
+}
+
+// hasQueryParameter reports whether params include a query parameter with this spec name.
+func hasQueryParameter(params openapi3.Parameters, name string) bool {
+	for _, paramRef := range params {
+		if paramRef != nil && paramRef.Value != nil && paramRef.Value.In == "query" && paramRef.Value.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isDangerousOperation reports whether an operation requires confirmation: x-mcp-dangerous if set,
+// otherwise PUT, POST and DELETE.
+func isDangerousOperation(op OpenAPIOperation) bool {
+	if op.Dangerous != nil {
+		return *op.Dangerous
+	}
+	switch strings.ToUpper(op.Method) {
+	case "PUT", "POST", "DELETE":
+		return true
+	}
+	return false
+}
+
+// lintMCPExtensions validates the values of x-mcp-* extensions in a spec.
+func lintMCPExtensions(doc *openapi3.T) []LintIssue {
+	var issues []LintIssue
+	addError := func(issue LintIssue) {
+		issue.Type = "error"
+		issues = append(issues, issue)
+	}
+
+	for _, tag := range doc.Tags {
+		if tag == nil {
+			continue
+		}
+		if v, ok := tag.Extensions[extMCPExclude]; ok {
+			if _, isBool := v.(bool); !isBool {
+				addError(LintIssue{
+					Message:    fmt.Sprintf("Tag '%s' has a non-boolean %s value (%v).", tag.Name, extMCPExclude, v),
+					Suggestion: fmt.Sprintf("Use '%s: true' to exclude the tag's operations, or remove the extension.", extMCPExclude),
+					Field:      extMCPExclude,
+				})
+			}
+		}
+		issues = append(issues, lintUnknownMCPExtensions(tag.Extensions, map[string]bool{extMCPExclude: true}, LintIssue{})...)
+	}
+
+	operationKeys := map[string]bool{extMCPName: true, extMCPDescription: true, extMCPHidden: true, extMCPDangerous: true, extMCPTimeout: true, extMCPExamples: true, extMCPAsync: true, extMCPStream: true, extMCPPagination: true}
+	parameterKeys := map[string]bool{extMCPName: true, extMCPDescription: true, extMCPHidden: true, extMCPExamples: true}
+	toolNames := map[string]string{}
+
+	paths := doc.Paths.Map()
+	pathNames := make([]string, 0, len(paths))
+	for path := range paths {
+		pathNames = append(pathNames, path)
+	}
+	sort.Strings(pathNames)
+	for _, path := range pathNames {
+		pathItem := paths[path]
+		for method, op := range pathItem.Operations() {
+			at := LintIssue{Operation: op.OperationID, Path: path, Method: method}
+			issues = append(issues, lintStringExtension(op.Extensions, extMCPName, at)...)
+			issues = append(issues, lintStringExtension(op.Extensions, extMCPDescription, at)...)
+			issues = append(issues, lintBoolExtension(op.Extensions, extMCPHidden, at)...)
+			issues = append(issues, lintBoolExtension(op.Extensions, extMCPDangerous, at)...)
+			issues = append(issues, lintUnknownMCPExtensions(op.Extensions, operationKeys, at)...)
+
+			if name, ok := extString(op.Extensions, extMCPName); ok {
+				if !toolNamePattern.MatchString(name) {
+					issue := at
+					issue.Message = fmt.Sprintf("%s '%s' on %s %s is not a valid tool name.", extMCPName, name, strings.ToUpper(method), path)
+					issue.Suggestion = "Tool names may only contain letters, digits, '_' and '-', and be at most 64 characters long."
+					issue.Field = extMCPName
+					addError(issue)
+				}
+				if hidden, _ := extBool(op.Extensions, extMCPHidden); !hidden {
+					if other, dup := toolNames[name]; dup {
+						issue := at
+						issue.Message = fmt.Sprintf("%s '%s' on %s %s is already used by %s.", extMCPName, name, strings.ToUpper(method), path, other)
+						issue.Suggestion = "Tool names must be unique; choose a different x-mcp-name."
+						issue.Field = extMCPName
+						addError(issue)
+					}
+					toolNames[name] = strings.ToUpper(method) + " " + path
+				}
+			}
+			if v, ok := op.Extensions[extMCPTimeout]; ok {
+				if _, err := parseMCPTimeout(v); err != nil {
+					issue := at
+					issue.Message = fmt.Sprintf("Invalid %s on %s %s: %v.", extMCPTimeout, strings.ToUpper(method), path, err)
+					issue.Suggestion = fmt.Sprintf("Use a duration such as '%s: 30s' or a number of seconds.", extMCPTimeout)
+					issue.Field = extMCPTimeout
+					addError(issue)
+				}
+			}
+			if _, err := parseAsyncExtension(op.Extensions[extMCPAsync]); err != nil {
+				issue := at
+				issue.Message = fmt.Sprintf("Invalid %s on %s %s: %v.", extMCPAsync, strings.ToUpper(method), path, err)
+				issue.Suggestion = fmt.Sprintf("Use '%s: true', or an object such as '%s: {deadline: 2m, statusField: state}'.", extMCPAsync, extMCPAsync)
+				issue.Field = extMCPAsync
+				addError(issue)
+			}
+			if _, err := parseStreamExtension(op.Extensions[extMCPStream]); err != nil {
+				issue := at
+				issue.Message = fmt.Sprintf("Invalid %s on %s %s: %v.", extMCPStream, strings.ToUpper(method), path, err)
+				issue.Suggestion = fmt.Sprintf("Use an object such as '%s: {maxEvents: 100, maxDuration: 30s, terminator: \"^\\\\[DONE\\\\]$\"}'.", extMCPStream)
+				issue.Field = extMCPStream
+				addError(issue)
+			}
+			if _, err := operationExamples(op.Extensions); err != nil {
+				issue := at
+				issue.Message = fmt.Sprintf("Invalid %s on %s %s: %v.", extMCPExamples, strings.ToUpper(method), path, err)
+				issue.Suggestion = fmt.Sprintf("Use a list of tool argument objects, e.g.\n    %s:\n      - {id: 42}", extMCPExamples)
+				issue.Field = extMCPExamples
+				addError(issue)
+			}
+
+			params := append(openapi3.Parameters{}, pathItem.Parameters...)
+			params = append(params, op.Parameters...)
+			pagination, err := parsePaginationExtension(op.Extensions[extMCPPagination])
+			if err != nil {
+				issue := at
+				issue.Message = fmt.Sprintf("Invalid %s on %s %s: %v.", extMCPPagination, strings.ToUpper(method), path, err)
+				issue.Suggestion = fmt.Sprintf("Use '%s: true', or an object such as '%s: {cursorField: meta.next, cursorParam: after, maxPages: 5}'.", extMCPPagination, extMCPPagination)
+				issue.Field = extMCPPagination
+				addError(issue)
+			} else if pagination != nil && method != http.MethodGet {
+				issue := at
+				issue.Type = "warning"
+				issue.Message = fmt.Sprintf("%s on %s %s is ignored: only GET operations are paginated.", extMCPPagination, strings.ToUpper(method), path)
+				issue.Suggestion = fmt.Sprintf("Remove %s from this operation.", extMCPPagination)
+				issue.Field = extMCPPagination
+				issues = append(issues, issue)
+			} else if pagination != nil {
+				for _, param := range [][2]string{{"cursorParam", pagination.CursorParam}, {"pageParam", pagination.PageParam}, {"offsetParam", pagination.OffsetParam}} {
+					if field, name := param[0], param[1]; name != "" && !hasQueryParameter(params, name) {
+						issue := at
+						issue.Message = fmt.Sprintf("Invalid %s on %s %s: %s '%s' is not a query parameter of the operation.", extMCPPagination, strings.ToUpper(method), path, field, name)
+						issue.Suggestion = fmt.Sprintf("Set %s to the name of a query parameter, or remove it.", field)
+						issue.Field = extMCPPagination
+						addError(issue)
+					}
+				}
+			}
+			argNames := map[string]string{}
+			for _, paramRef := range params {
+				if paramRef == nil || paramRef.Value == nil {
+					continue
+				}
+				p := paramRef.Value
+				pat := at
+				pat.Parameter = p.Name
+				issues = append(issues, lintStringExtension(p.Extensions, extMCPName, pat)...)
+				issues = append(issues, lintStringExtension(p.Extensions, extMCPDescription, pat)...)
+				issues = append(issues, lintBoolExtension(p.Extensions, extMCPHidden, pat)...)
+				issues = append(issues, lintUnknownMCPExtensions(p.Extensions, parameterKeys, pat)...)
+				if v, ok := p.Extensions[extMCPExamples]; ok {
+					if _, isList := v.([]any); !isList {
+						issue := pat
+						issue.Message = fmt.Sprintf("Parameter '%s' has a non-array %s value.", p.Name, extMCPExamples)
+						issue.Suggestion = fmt.Sprintf("Use a list of example values, e.g. '%s: [asc, desc]'.", extMCPExamples)
+						issue.Field = extMCPExamples
+						addError(issue)
+					}
+				}
+				if isParameterHidden(p) {
+					if p.Required && (p.Schema == nil || p.Schema.Value == nil || p.Schema.Value.Default == nil) {
+						issue := pat
+						issue.Message = fmt.Sprintf("Required parameter '%s' is hidden with %s but has no default, so it can never be sent.", p.Name, extMCPHidden)
+						issue.Suggestion = "Add a schema 'default' for the parameter, or don't hide it."
+						issue.Field = extMCPHidden
+						addError(issue)
+					}
+					continue
+				}
+				argName := parameterArgumentName(p)
+				if name, ok := extString(p.Extensions, extMCPName); ok && !toolNamePattern.MatchString(name) {
+					issue := pat
+					issue.Message = fmt.Sprintf("%s '%s' on parameter '%s' is not a valid argument name.", extMCPName, name, p.Name)
+					issue.Suggestion = "Argument names may only contain letters, digits, '_' and '-', and be at most 64 characters long."
+					issue.Field = extMCPName
+					addError(issue)
+				}
+				if other, dup := argNames[argName]; dup || argName == "requestBody" {
+					if !dup {
+						other = "the request body"
+					}
+					issue := pat
+					issue.Message = fmt.Sprintf("Argument name '%s' of parameter '%s' collides with %s.", argName, p.Name, other)
+					issue.Suggestion = fmt.Sprintf("Give one of the parameters a distinct %s.", extMCPName)
+					issue.Field = extMCPName
+					addError(issue)
+				}
+				argNames[argName] = "parameter '" + p.Name + "'"
+			}
+		}
+	}
+	return issues
+}
+
+func lintStringExtension(ext map[string]any, key string, at LintIssue) []LintIssue {
+	v, ok := ext[key]
+	if !ok {
+		return nil
+	}
+	if s, isString := v.(string); isString && strings.TrimSpace(s) != "" {
+		return nil
+	}
+	at.Type = "error"
+	at.Message = fmt.Sprintf("%s must be a non-empty string, got %v.", key, v)
+	at.Suggestion = fmt.Sprintf("Set %s to a string or remove it.", key)
+	at.Field = key
+	return []LintIssue{at}
+}
+
+func lintBoolExtension(ext map[string]any, key string, at LintIssue) []LintIssue {
+	v, ok := ext[key]
+	if !ok {
+		return nil
+	}
+	if _, isBool := v.(bool); isBool {
+		return nil
+	}
+	at.Type = "error"
+	at.Message = fmt.Sprintf("%s must be a boolean, got %v.", key, v)
+	at.Suggestion = fmt.Sprintf("Use '%s: true' or '%s: false'.", key, key)
+	at.Field = key
+	return []LintIssue{at}
+}
+
+// lintUnknownMCPExtensions warns about x-mcp-* keys that are not recognized at this location, usually typos.
+func lintUnknownMCPExtensions(ext map[string]any, known map[string]bool, at LintIssue) []LintIssue {
+	var issues []LintIssue
+	for _, key := range sortedKeys(ext) {
+		if strings.HasPrefix(key, "x-mcp-") && !known[key] {
+			issue := at
+			issue.Type = "warning"
+			issue.Message = fmt.Sprintf("Unknown extension '%s' is ignored here.", key)
+			issue.Suggestion = "Check the spelling; supported extensions are x-mcp-name, x-mcp-description, x-mcp-hidden, x-mcp-dangerous, x-mcp-timeout, x-mcp-async, x-mcp-stream, x-mcp-pagination, x-mcp-examples (operations and parameters) and x-mcp-exclude (tags)."
+			issue.Field = key
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// printLintIssues prints issues in the self-test format and returns the number of errors and warnings.
+func printLintIssues(issues []LintIssue) (errors, warnings int) {
+	for _, issue := range issues {
+		if issue.Type == "error" {
+			fmt.Fprintf(os.Stderr, "[ERROR] %s\n", issue.Message)
+			errors++
+		} else {
+			fmt.Fprintf(os.Stderr, "[WARN] %s\n", issue.Message)
+			warnings++
+		}
+		if issue.Suggestion != "" {
+			fmt.Fprintf(os.Stderr, "  Suggestion: %s\n", issue.Suggestion)
+		}
+	}
+	return errors, warnings
+}
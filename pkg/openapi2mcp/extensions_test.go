@@ -0,0 +1,222 @@
+package openapi2mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+const extensionsTestSpec = `
+openapi: 3.0.0
+info: {title: Ext, version: "1.0"}
+tags:
+  - name: internal
+    x-mcp-exclude: true
+paths:
+  /items:
+    post:
+      operationId: createItemV2
+      x-mcp-name: create_item
+      x-mcp-description: Create an item. Safe to retry.
+      x-mcp-dangerous: false
+      x-mcp-examples:
+        - {q: hello}
+      parameters:
+        - name: query_string
+          in: query
+          x-mcp-name: q
+          x-mcp-description: Search text
+          x-mcp-examples: [hello, world]
+          schema: {type: string}
+        - name: X-Internal
+          in: header
+          x-mcp-hidden: true
+          schema: {type: string}
+        - name: tenant
+          in: query
+          required: true
+          x-mcp-hidden: true
+          schema: {type: string, default: acme}
+      responses:
+        "200": {description: OK}
+  /purge:
+    get:
+      operationId: purge
+      x-mcp-dangerous: true
+      responses:
+        "200":
+          description: The purged objects, as an archive
+          content:
+            application/octet-stream: {schema: {type: string, format: binary}}
+  /slow:
+    get:
+      operationId: slow
+      x-mcp-timeout: 50ms
+      responses:
+        "200": {description: OK}
+  /secret:
+    get:
+      operationId: secret
+      x-mcp-hidden: true
+      responses:
+        "200": {description: OK}
+  /stats:
+    get:
+      operationId: stats
+      tags: [internal]
+      responses:
+        "200": {description: OK}
+`
+
+func TestMCPExtensions(t *testing.T) {
+	var gotQuery, gotInternal string
+	var purges int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/purge" {
+			purges++
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte{0xff})
+			return
+		}
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		gotQuery = r.URL.RawQuery
+		gotInternal = r.Header.Get("X-Internal")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	os.Setenv("OPENAPI_BASE_URL", ts.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(extensionsTestSpec))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	ops := ExtractOpenAPIOperations(doc)
+	names := map[string]OpenAPIOperation{}
+	for _, op := range ops {
+		names[op.OperationID] = op
+	}
+	if len(ops) != 3 || names["create_item"].Path != "/items" || names["slow"].Timeout != 50*time.Millisecond {
+		t.Fatalf("unexpected operations: %+v", names)
+	}
+
+	schema := BuildInputSchema(names["create_item"].Parameters, nil)
+	props := schema["properties"].(map[string]any)
+	if _, hidden := props["X-Internal"]; hidden {
+		t.Errorf("hidden parameter is exposed in the schema")
+	}
+	q, ok := props["q"].(map[string]any)
+	if !ok || q["description"] != "Search text" || len(q["examples"].([]any)) != 2 {
+		t.Errorf("renamed parameter not in schema as expected: %v", props)
+	}
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ops, doc, &ToolGenOptions{ConfirmDangerousActions: true})
+	for _, tool := range srv.ListTools() {
+		if tool.Name == "create_item" {
+			if !strings.HasPrefix(tool.Description, "Create an item. Safe to retry.") || !strings.Contains(tool.Description, `EXAMPLE: call create_item {"q":"hello"}`) {
+				t.Errorf("unexpected description: %s", tool.Description)
+			}
+			if strings.Contains(tool.Description, "SAFETY") {
+				t.Errorf("x-mcp-dangerous: false should drop the safety note")
+			}
+		}
+	}
+
+	// Not dangerous: the POST is sent without confirmation, with the original parameter name
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_item","arguments":{"q":"hello"}}}`)
+	resp := srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse)
+	if text := resp.Result.(mcp.CallToolResult).Content[0].(mcp.TextContent).Text; strings.Contains(text, "CONFIRMATION REQUIRED") {
+		t.Errorf("x-mcp-dangerous: false should skip confirmation: %s", text)
+	}
+	if gotQuery != "query_string=hello&tenant=acme" {
+		t.Errorf("expected the renamed argument to be sent as query_string and the hidden one with its default, got %q", gotQuery)
+	}
+
+	// Values passed for hidden parameters are not sent: only their defaults are
+	msg = []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_item","arguments":{"q":"hello","X-Internal":"admin","tenant":"other"}}}`)
+	srv.HandleMessage(context.Background(), msg)
+	if gotQuery != "query_string=hello&tenant=acme" || gotInternal != "" {
+		t.Errorf("hidden parameters were taken from the arguments: query=%q X-Internal=%q", gotQuery, gotInternal)
+	}
+
+	// Dangerous: nothing is sent until the call is confirmed, whatever the response type
+	msg = []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"purge","arguments":{}}}`)
+	resp = srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse)
+	if text := resp.Result.(mcp.CallToolResult).Content[0].(mcp.TextContent).Text; !strings.Contains(text, "CONFIRMATION REQUIRED") || purges != 0 {
+		t.Errorf("x-mcp-dangerous: true should ask for confirmation before sending (%d requests sent): %s", purges, text)
+	}
+	msg = []byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"purge","arguments":{"__confirmed":true}}}`)
+	srv.HandleMessage(context.Background(), msg)
+	if purges != 1 {
+		t.Errorf("expected the confirmed call to be sent once, got %d requests", purges)
+	}
+
+	start := time.Now()
+	msg = []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow","arguments":{}}}`)
+	if _, isErr := srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCError); !isErr {
+		t.Errorf("expected the call to fail on x-mcp-timeout")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("x-mcp-timeout was not applied, call took %v", elapsed)
+	}
+}
+
+func TestLintMCPExtensions(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Ext, version: "1.0"}
+tags:
+  - {name: internal, x-mcp-exclude: "yes"}
+paths:
+  /a:
+    get:
+      operationId: a
+      x-mcp-name: "bad name"
+      x-mcp-timeout: soon
+      x-mcp-async: {deadline: 5m, statusFeld: state}
+      x-mcp-stream: {terminator: "[DONE"}
+      x-mcp-pagination: {maxPages: 0, pageParam: page}
+      x-mcp-hidden: 1
+      x-mcp-dangerus: true
+      parameters:
+        - {name: id, in: query, required: true, x-mcp-hidden: true, schema: {type: string}}
+      responses:
+        "200": {description: OK}
+`))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	var messages []string
+	errors := 0
+	for _, issue := range lintMCPExtensions(doc) {
+		messages = append(messages, issue.Message)
+		if issue.Type == "error" {
+			errors++
+		}
+	}
+	all := strings.Join(messages, "\n")
+	for _, want := range []string{"x-mcp-exclude", "not a valid tool name", "Invalid x-mcp-timeout", "Invalid x-mcp-async on GET /a: statusFeld", "Invalid x-mcp-stream on GET /a: terminator", "Invalid x-mcp-pagination on GET /a: maxPages", "x-mcp-hidden must be a boolean", "x-mcp-dangerus", "can never be sent"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("expected a lint issue mentioning %q, got:\n%s", want, all)
+		}
+	}
+	if errors != 8 {
+		t.Errorf("expected 8 errors, got %d:\n%s", errors, all)
+	}
+}
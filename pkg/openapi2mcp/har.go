@@ -0,0 +1,721 @@
+// har.go
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HAR is an HTTP Archive (HAR 1.2) document.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root log object of a HAR document.
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator HARCreator  `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that created a HAR document.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is a single recorded HTTP exchange.
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is the request part of a HAR entry.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	Cookies     []HARNameValue `json:"cookies"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse is the response part of a HAR entry.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, query parameter or cookie.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is a recorded request body.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is a recorded response body. Non-text bodies are base64-encoded.
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings breaks down the time spent on an exchange, in milliseconds.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// requestSecretsKey is the context key carrying the credential header and query names of an upstream request.
+type requestSecretsKey struct{}
+
+// requestSecrets names the headers and query parameters of a request that carry credentials.
+type requestSecrets struct {
+	headers []string
+	query   []string
+}
+
+// withRequestSecrets attaches the credential names of a prepared request to its context.
+func withRequestSecrets(ctx context.Context, headers, query []string) context.Context {
+	return context.WithValue(ctx, requestSecretsKey{}, requestSecrets{headers: headers, query: query})
+}
+
+// secretsFromRequest returns the credential names attached to a request by buildOperationRequest.
+func secretsFromRequest(req *http.Request) requestSecrets {
+	secrets, _ := req.Context().Value(requestSecretsKey{}).(requestSecrets)
+	return secrets
+}
+
+// HARRecorder is an http.RoundTripper that forwards requests to an underlying transport and
+// appends every exchange, with credentials redacted, to a HAR file.
+// Response bodies are recorded as the caller reads them, so that streaming responses are not delayed.
+// Completed entries are appended to the file and dropped from memory; the entries of responses still
+// being read and the closing brackets are rewritten after them, so that the file is complete even if
+// the process is killed.
+type HARRecorder struct {
+	Transport http.RoundTripper // underlying transport (http.DefaultTransport if nil)
+	path      string
+	mu        sync.Mutex
+	file      *os.File
+	footer    []byte      // closes the entries array and the document
+	written   int64       // end of the completed entries in the file
+	entries   int         // number of completed entries in the file
+	pending   []*HAREntry // entries of the responses being read
+	buffered  int         // size of the response bodies being recorded
+}
+
+// NewHARRecorder returns a recorder writing to the given path.
+// The file is created (or truncated) immediately so that permission problems are reported at startup.
+func NewHARRecorder(path string, transport http.RoundTripper) (*HARRecorder, error) {
+	empty, err := json.MarshalIndent(HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "openapi-mcp", Version: "1.0"},
+		Entries: []*HAREntry{},
+	}}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	// Entries are written between the brackets of the empty entries array, its last field
+	i := bytes.LastIndex(empty, []byte("[]")) + 1
+	r := &HARRecorder{Transport: transport, path: path, file: file, footer: empty[i:]}
+	if _, err := file.Write(empty[:i]); err != nil {
+		file.Close()
+		return nil, err
+	}
+	r.written = int64(i)
+	if err := r.flush(nil); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *HARRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	started := time.Now()
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	wait := time.Since(started)
+
+	// The entry is recorded as soon as the headers arrive and completed when the body has been read,
+	// so that streaming responses reach the caller as they are received
+	entry := &HAREntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Time:            milliseconds(wait),
+		Request:         harRequest(req, reqBody),
+		Response:        harResponse(resp, nil),
+		Timings:         HARTimings{Wait: milliseconds(wait)},
+	}
+	r.mu.Lock()
+	r.pending = append(r.pending, entry)
+	r.flushOrWarn(nil)
+	r.mu.Unlock()
+
+	resp.Body = &harBody{ReadCloser: resp.Body, reserve: r.reserve, finish: func(body []byte, truncated bool) {
+		total := time.Since(started)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.buffered -= len(body)
+		entry.Time = milliseconds(total)
+		entry.Timings.Receive = milliseconds(total - wait)
+		entry.Response = harResponse(resp, body)
+		if truncated {
+			entry.Response.Content.Comment = fmt.Sprintf("truncated to the first %d bytes", len(body))
+		}
+		for i, e := range r.pending {
+			if e == entry {
+				r.pending = append(r.pending[:i], r.pending[i+1:]...)
+				break
+			}
+		}
+		r.flushOrWarn(entry)
+	}}
+	return resp, nil
+}
+
+const (
+	// maxHARBodySize is the largest response body stored in a HAR entry; endless streams are cut there.
+	maxHARBodySize = 16 << 20
+	// maxHARBufferedSize caps the response bodies held in memory until their entries are written.
+	maxHARBufferedSize = 64 << 20
+)
+
+// reserve returns how many of n more bytes of a response body can be held in memory.
+func (r *HARRecorder) reserve(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n = min(n, maxHARBufferedSize-r.buffered)
+	r.buffered += n
+	return n
+}
+
+// harBody copies a response body into its HAR entry as the caller reads it. The entry is completed
+// at the end of the body, on a read error or when the body is closed early.
+type harBody struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	truncated bool
+	once      sync.Once
+	reserve   func(n int) int
+	finish    func(body []byte, truncated bool)
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.truncated {
+		kept := b.reserve(min(n, maxHARBodySize-b.buf.Len()))
+		b.buf.Write(p[:kept])
+		b.truncated = kept < n
+	}
+	if err != nil {
+		b.done()
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+func (b *harBody) done() {
+	b.once.Do(func() { b.finish(b.buf.Bytes(), b.truncated) })
+}
+
+// flushOrWarn updates the file, warning on failure. The caller must hold r.mu.
+func (r *HARRecorder) flushOrWarn(completed *HAREntry) {
+	if err := r.flush(completed); err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] Failed to write HAR file %s: %v\n", r.path, err)
+	}
+}
+
+// flush appends a completed entry, if any, to the file, then rewrites the pending entries and the
+// closing brackets after the completed ones. The caller must hold r.mu or own r exclusively.
+func (r *HARRecorder) flush(completed *HAREntry) error {
+	if completed != nil {
+		data, err := r.marshalEntry(completed, r.entries)
+		if err != nil {
+			return err
+		}
+		if _, err := r.file.WriteAt(data, r.written); err != nil {
+			return err
+		}
+		r.written += int64(len(data))
+		r.entries++
+	}
+	var tail bytes.Buffer
+	for i, entry := range r.pending {
+		data, err := r.marshalEntry(entry, r.entries+i)
+		if err != nil {
+			return err
+		}
+		tail.Write(data)
+	}
+	if r.entries+len(r.pending) > 0 {
+		tail.WriteString("\n    ")
+	}
+	tail.Write(r.footer)
+	if _, err := r.file.WriteAt(tail.Bytes(), r.written); err != nil {
+		return err
+	}
+	return r.file.Truncate(r.written + int64(tail.Len()))
+}
+
+// marshalEntry encodes the entry at index i of the entries array, indented like the rest of the file.
+func (r *HARRecorder) marshalEntry(entry *HAREntry, i int) ([]byte, error) {
+	data, err := json.MarshalIndent(entry, "      ", "  ")
+	if err != nil {
+		return nil, err
+	}
+	prefix := ",\n      "
+	if i == 0 {
+		prefix = "\n      "
+	}
+	return append([]byte(prefix), data...), nil
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// harRequest converts a request to its HAR form, redacting credentials.
+func harRequest(req *http.Request, body []byte) HARRequest {
+	secrets := secretsFromRequest(req)
+	query := req.URL.Query()
+	for _, name := range secrets.query {
+		if query.Has(name) {
+			query.Set(name, redactedValue)
+		}
+	}
+	u := *req.URL
+	u.RawQuery = query.Encode()
+
+	h := HARRequest{
+		Method:      req.Method,
+		URL:         u.String(),
+		HTTPVersion: "HTTP/1.1",
+		Headers:     harHeaders(req.Header, secrets.headers),
+		QueryString: harNameValues(query),
+		Cookies:     []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if len(body) > 0 {
+		mimeType := req.Header.Get("Content-Type")
+		h.PostData = &HARPostData{MimeType: mimeType, Text: string(redactedBody(mimeType, body, secrets))}
+	}
+	return h
+}
+
+// redactedBody replaces the credentials of a JSON or form request body: the fields named after
+// the request's credential headers and query parameters, and those named like secrets
+// (passwords, tokens, client secrets...). Other bodies are returned as is.
+func redactedBody(mimeType string, body []byte, secrets requestSecrets) []byte {
+	names := append(append([]string{}, secrets.headers...), secrets.query...)
+	secret := func(name string) bool {
+		for _, n := range names {
+			if strings.EqualFold(name, n) {
+				return true
+			}
+		}
+		return isSensitiveField(name)
+	}
+	mt := strings.ToLower(mimeType)
+	switch {
+	case strings.Contains(mt, "json"):
+		var doc any
+		if json.Unmarshal(body, &doc) != nil {
+			return body
+		}
+		redacted, err := json.Marshal(redactJSON(doc, secret))
+		if err != nil {
+			return body
+		}
+		return redacted
+	case strings.HasPrefix(mt, "application/x-www-form-urlencoded"):
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for name := range form {
+			if secret(name) {
+				form[name] = []string{redactedValue}
+			}
+		}
+		return []byte(form.Encode())
+	}
+	return body
+}
+
+// redactJSON replaces the values of the secret fields of a decoded JSON document, at any depth.
+func redactJSON(v any, secret func(string) bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for name, value := range v {
+			if secret(name) {
+				v[name] = redactedValue
+			} else {
+				v[name] = redactJSON(value, secret)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactJSON(value, secret)
+		}
+	}
+	return v
+}
+
+// isSensitiveField reports whether a body field is named like a credential.
+func isSensitiveField(name string) bool {
+	n := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+	for _, word := range []string{"password", "passwd", "secret", "token", "apikey", "credential", "privatekey"} {
+		if strings.Contains(n, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// harResponse converts a response to its HAR form.
+func harResponse(resp *http.Response, body []byte) HARResponse {
+	mimeType := resp.Header.Get("Content-Type")
+	content := HARContent{Size: len(body), MimeType: mimeType}
+	if isTextualMIMEType(mimeType) {
+		content.Text = string(body)
+	} else if len(body) > 0 {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+	statusText := strings.TrimSpace(strings.TrimPrefix(resp.Status, fmt.Sprint(resp.StatusCode)))
+	if statusText == "" {
+		statusText = http.StatusText(resp.StatusCode)
+	}
+	return HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  statusText,
+		HTTPVersion: "HTTP/1.1",
+		Headers:     harHeaders(resp.Header, nil),
+		Cookies:     []HARNameValue{},
+		Content:     content,
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+}
+
+// harHeaders flattens headers, redacting sensitive ones and the given credential headers.
+func harHeaders(header http.Header, secrets []string) []HARNameValue {
+	values := []HARNameValue{}
+	for _, name := range sortedKeys(header) {
+		redact := isSensitiveHeader(name) || strings.EqualFold(name, "Set-Cookie")
+		for _, secret := range secrets {
+			if strings.EqualFold(name, secret) {
+				redact = true
+			}
+		}
+		for _, value := range header[name] {
+			if redact {
+				value = redactedValue
+			}
+			values = append(values, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return values
+}
+
+func harNameValues(values url.Values) []HARNameValue {
+	list := []HARNameValue{}
+	for _, name := range sortedKeys(values) {
+		for _, value := range values[name] {
+			list = append(list, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return list
+}
+
+func sortedKeys[M ~map[string]V, V any](m M) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isTextualMIMEType reports whether a body of this type can be stored as plain text in a HAR file.
+func isTextualMIMEType(mimeType string) bool {
+	mt := strings.ToLower(mimeType)
+	return mt == "" || strings.HasPrefix(mt, "text/") || strings.Contains(mt, "json") ||
+		strings.Contains(mt, "xml") || strings.HasPrefix(mt, "application/x-www-form-urlencoded")
+}
+
+// HARMatchOptions selects which parts of a request must match a recorded entry during replay.
+// Query values recorded as redacted credentials match any value.
+type HARMatchOptions struct {
+	Method bool
+	Path   bool
+	Query  bool
+	Body   bool
+}
+
+// DefaultHARMatchOptions matches on method, path and query.
+var DefaultHARMatchOptions = HARMatchOptions{Method: true, Path: true, Query: true}
+
+// ParseHARMatchOptions parses a comma-separated list of match criteria (method, path, query, body).
+func ParseHARMatchOptions(s string) (HARMatchOptions, error) {
+	var m HARMatchOptions
+	for _, part := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(part)) {
+		case "method":
+			m.Method = true
+		case "path":
+			m.Path = true
+		case "query":
+			m.Query = true
+		case "body":
+			m.Body = true
+		case "":
+		default:
+			return m, fmt.Errorf("unknown HAR match criterion %q (expected method, path, query or body)", part)
+		}
+	}
+	return m, nil
+}
+
+// HARReplayer is an http.RoundTripper that answers requests from a HAR file without using the network.
+// Matching entries are served in recording order; once they are exhausted the last one is repeated.
+type HARReplayer struct {
+	Match   HARMatchOptions
+	mu      sync.Mutex
+	entries []*HAREntry
+	used    map[int]bool
+}
+
+// NewHARReplayer loads a HAR file for replay.
+func NewHARReplayer(path string, match HARMatchOptions) (*HARReplayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("invalid HAR file %s: %w", path, err)
+	}
+	return &HARReplayer{Match: match, entries: har.Log.Entries, used: map[int]bool{}}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *HARReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i, entry := range r.entries {
+		if !r.matches(entry, req, body) {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return replayResponse(entry, req)
+		}
+		last = i
+	}
+	if last >= 0 {
+		return replayResponse(r.entries[last], req)
+	}
+	return nil, fmt.Errorf("no recorded HAR entry matches %s %s", req.Method, req.URL.Path)
+}
+
+func (r *HARReplayer) matches(entry *HAREntry, req *http.Request, body []byte) bool {
+	if r.Match.Method && !strings.EqualFold(entry.Request.Method, req.Method) {
+		return false
+	}
+	recorded, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return false
+	}
+	if r.Match.Path && recorded.Path != req.URL.Path {
+		return false
+	}
+	if r.Match.Query && !queryMatches(recorded.Query(), req.URL.Query()) {
+		return false
+	}
+	if r.Match.Body {
+		var recordedBody string
+		if entry.Request.PostData != nil {
+			recordedBody = entry.Request.PostData.Text
+		}
+		if !bodiesMatch([]byte(recordedBody), body) {
+			return false
+		}
+	}
+	return true
+}
+
+// queryMatches compares query parameters, treating redacted recorded values as wildcards.
+func queryMatches(recorded, actual url.Values) bool {
+	if len(recorded) != len(actual) {
+		return false
+	}
+	for name, values := range recorded {
+		got, ok := actual[name]
+		if !ok {
+			return false
+		}
+		if len(values) == 1 && values[0] == redactedValue {
+			continue
+		}
+		if strings.Join(values, "\x00") != strings.Join(got, "\x00") {
+			return false
+		}
+	}
+	return true
+}
+
+// bodiesMatch compares request bodies, semantically when both are JSON or forms,
+// treating redacted recorded values as wildcards.
+func bodiesMatch(recorded, actual []byte) bool {
+	var a, b any
+	if json.Unmarshal(recorded, &a) == nil && json.Unmarshal(actual, &b) == nil {
+		return jsonMatches(a, b)
+	}
+	if bytes.Contains(recorded, []byte(url.QueryEscape(redactedValue))) {
+		ra, errA := url.ParseQuery(string(recorded))
+		rb, errB := url.ParseQuery(string(actual))
+		if errA == nil && errB == nil {
+			return queryMatches(ra, rb)
+		}
+	}
+	return bytes.Equal(bytes.TrimSpace(recorded), bytes.TrimSpace(actual))
+}
+
+// jsonMatches compares decoded JSON documents, treating redacted recorded values as wildcards.
+func jsonMatches(recorded, actual any) bool {
+	if recorded == redactedValue {
+		return true
+	}
+	switch r := recorded.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok || len(a) != len(r) {
+			return false
+		}
+		for name, value := range r {
+			if got, ok := a[name]; !ok || !jsonMatches(value, got) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		a, ok := actual.([]any)
+		if !ok || len(a) != len(r) {
+			return false
+		}
+		for i := range r {
+			if !jsonMatches(r[i], a[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	ra, _ := json.Marshal(recorded)
+	rb, _ := json.Marshal(actual)
+	return bytes.Equal(ra, rb)
+}
+
+// replayResponse builds an *http.Response from a recorded entry.
+func replayResponse(entry *HAREntry, req *http.Request) (*http.Response, error) {
+	var body []byte
+	if entry.Response.Content.Encoding == "base64" {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(entry.Response.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 content in HAR entry for %s: %w", entry.Request.URL, err)
+		}
+	} else {
+		body = []byte(entry.Response.Content.Text)
+	}
+	header := http.Header{}
+	for _, h := range entry.Response.Headers {
+		header.Add(h.Name, h.Value)
+	}
+	header.Del("Content-Encoding")
+	header.Set("Content-Length", fmt.Sprint(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Response.Status, entry.Response.StatusText),
+		StatusCode:    entry.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
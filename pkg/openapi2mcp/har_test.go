@@ -0,0 +1,262 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func harTestDoc() *openapi3.T {
+	paths := openapi3.NewPaths()
+	paths.Set("/items", &openapi3.PathItem{
+		Get: &openapi3.Operation{
+			OperationID: "listItems",
+			Parameters: openapi3.Parameters{
+				&openapi3.ParameterRef{Value: &openapi3.Parameter{Name: "q", In: "query", Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: typesPtr("string")}}}},
+			},
+			Security: &openapi3.SecurityRequirements{{"key": []string{}}},
+		},
+	})
+	return &openapi3.T{
+		Info:  &openapi3.Info{Title: "Test API", Version: "1.0.0"},
+		Paths: paths,
+		Components: &openapi3.Components{
+			SecuritySchemes: openapi3.SecuritySchemes{
+				"key": &openapi3.SecuritySchemeRef{Value: &openapi3.SecurityScheme{Type: "apiKey", In: "query", Name: "api_key"}},
+			},
+		},
+	}
+}
+
+func callHARTool(t *testing.T, client *http.Client, query string) string {
+	t.Helper()
+	doc := harTestDoc()
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{HTTPClient: client})
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"listItems","arguments":{"q":"` + query + `"}}}`)
+	resp, ok := srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a JSON-RPC response")
+	}
+	result := resp.Result.(mcp.CallToolResult)
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+func TestHARRecordAndReplay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":["` + r.URL.Query().Get("q") + `"]}`))
+	}))
+	os.Setenv("OPENAPI_BASE_URL", ts.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+	os.Setenv("API_KEY", "super-secret")
+	defer os.Unsetenv("API_KEY")
+
+	harPath := filepath.Join(t.TempDir(), "session.har")
+	recorder, err := NewHARRecorder(harPath, nil)
+	if err != nil {
+		t.Fatalf("NewHARRecorder: %v", err)
+	}
+	live := callHARTool(t, &http.Client{Transport: recorder}, "apple")
+	ts.Close()
+	if !strings.Contains(live, "apple") {
+		t.Fatalf("unexpected live result: %s", live)
+	}
+
+	data, err := os.ReadFile(harPath)
+	if err != nil {
+		t.Fatalf("reading HAR file: %v", err)
+	}
+	if strings.Contains(string(data), "super-secret") {
+		t.Fatalf("HAR file leaked the API key: %s", data)
+	}
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		t.Fatalf("invalid HAR file: %v", err)
+	}
+	if len(har.Log.Entries) != 1 {
+		t.Fatalf("expected 1 HAR entry, got %d", len(har.Log.Entries))
+	}
+	entry := har.Log.Entries[0]
+	if entry.Request.Method != "GET" || entry.Response.Status != 200 || entry.Time <= 0 {
+		t.Errorf("unexpected HAR entry: %+v", entry)
+	}
+
+	// The upstream server is gone: replay must answer from the recording, ignoring the redacted key
+	replayer, err := NewHARReplayer(harPath, DefaultHARMatchOptions)
+	if err != nil {
+		t.Fatalf("NewHARReplayer: %v", err)
+	}
+	if replayed := callHARTool(t, &http.Client{Transport: replayer}, "apple"); replayed != live {
+		t.Errorf("replayed result differs:\n got: %s\nwant: %s", replayed, live)
+	}
+	if _, err := (&http.Client{Transport: replayer}).Get(ts.URL + "/items?q=pear&api_key=x"); err == nil {
+		t.Errorf("expected no match for a different query")
+	}
+}
+
+func TestHARRecorderStreamsResponses(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: first\n\n"))
+		w.(http.Flusher).Flush()
+		<-release
+		w.Write([]byte("data: last\n\n"))
+	}))
+	defer ts.Close()
+	harPath := filepath.Join(t.TempDir(), "stream.har")
+	recorder, err := NewHARRecorder(harPath, nil)
+	if err != nil {
+		t.Fatalf("NewHARRecorder: %v", err)
+	}
+
+	resp, err := (&http.Client{Transport: recorder}).Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first event must be readable while the stream is still open
+	first := make([]byte, len("data: first\n\n"))
+	if _, err := io.ReadFull(resp.Body, first); err != nil || string(first) != "data: first\n\n" {
+		t.Fatalf("unexpected first event %q: %v", first, err)
+	}
+	close(release)
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	data, _ := os.ReadFile(harPath)
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil || len(har.Log.Entries) != 1 {
+		t.Fatalf("invalid HAR file %s: %v", data, err)
+	}
+	if content := har.Log.Entries[0].Response.Content; content.Text != "data: first\n\ndata: last\n\n" {
+		t.Errorf("unexpected recorded content %q", content.Text)
+	}
+}
+
+func TestHARRecorderRedactsRequestBodies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	harPath := filepath.Join(t.TempDir(), "bodies.har")
+	recorder, err := NewHARRecorder(harPath, nil)
+	if err != nil {
+		t.Fatalf("NewHARRecorder: %v", err)
+	}
+	client := &http.Client{Transport: recorder}
+	bodies := map[string]string{
+		"application/json":                  `{"user":"ann","password":"hunter2","nested":[{"client_secret":"s3cret"}]}`,
+		"application/x-www-form-urlencoded": "grant_type=client_credentials&client_secret=s3cret&refresh_token=r3fresh",
+	}
+	for mimeType, body := range bodies {
+		resp, err := client.Post(ts.URL, mimeType, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	data, _ := os.ReadFile(harPath)
+	for _, secret := range []string{"hunter2", "s3cret", "r3fresh"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("HAR file leaked %s: %s", secret, data)
+		}
+	}
+	if !strings.Contains(string(data), "client_credentials") || !strings.Contains(string(data), `\"user\":\"ann\"`) {
+		t.Errorf("expected the other fields to be kept: %s", data)
+	}
+
+	// Redacted values match anything during replay
+	replayer, err := NewHARReplayer(harPath, HARMatchOptions{Method: true, Body: true})
+	if err != nil {
+		t.Fatalf("NewHARReplayer: %v", err)
+	}
+	for mimeType, body := range bodies {
+		if _, err := (&http.Client{Transport: replayer}).Post(ts.URL, mimeType, strings.NewReader(body)); err != nil {
+			t.Errorf("%s: expected the recorded body to match: %v", mimeType, err)
+		}
+	}
+}
+
+func TestParseHARMatchOptions(t *testing.T) {
+	m, err := ParseHARMatchOptions("method, body")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !m.Method || !m.Body || m.Path || m.Query {
+		t.Errorf("unexpected match options: %+v", m)
+	}
+	if _, err := ParseHARMatchOptions("method,headers"); err == nil {
+		t.Errorf("expected an error for an unknown criterion")
+	}
+}
+
+func TestHARRecorderAppendsEntries(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+		if r.URL.Path == "/slow" {
+			w.(http.Flusher).Flush()
+			<-release
+		}
+	}))
+	defer ts.Close()
+	harPath := filepath.Join(t.TempDir(), "entries.har")
+	recorder, err := NewHARRecorder(harPath, nil)
+	if err != nil {
+		t.Fatalf("NewHARRecorder: %v", err)
+	}
+	client := &http.Client{Transport: recorder}
+	contents := func() []string {
+		t.Helper()
+		data, _ := os.ReadFile(harPath)
+		var har HAR
+		if err := json.Unmarshal(data, &har); err != nil {
+			t.Fatalf("invalid HAR file %s: %v", data, err)
+		}
+		var texts []string
+		for _, entry := range har.Log.Entries {
+			texts = append(texts, entry.Request.URL[len(ts.URL):]+"="+entry.Response.Content.Text)
+		}
+		return texts
+	}
+	get := func(path string) *http.Response {
+		resp, err := client.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// The file stays valid while a response is read, and completed entries are written before it
+	slow := get("/slow")
+	if got := fmt.Sprint(contents()); got != "[/slow=]" {
+		t.Errorf("unexpected entries while the response is read: %s", got)
+	}
+	for _, path := range []string{"/a", "/b"} {
+		resp := get(path)
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if got := fmt.Sprint(contents()); got != "[/a=/a /b=/b /slow=]" {
+		t.Errorf("unexpected entries after completed requests: %s", got)
+	}
+	close(release)
+	io.ReadAll(slow.Body)
+	slow.Body.Close()
+	if got := fmt.Sprint(contents()); got != "[/a=/a /b=/b /slow=/slow]" {
+		t.Errorf("unexpected entries after the last response: %s", got)
+	}
+}
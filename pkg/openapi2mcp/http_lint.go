@@ -125,8 +125,8 @@ func (s *HTTPLintServer) HandleHealth(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// ServeHTTPLint starts an HTTP server for linting OpenAPI specs
-func ServeHTTPLint(addr string, detailedSuggestions bool) error {
+// ServeHTTPLint starts an HTTP server for linting OpenAPI specs (over HTTPS with WithTLS)
+func ServeHTTPLint(addr string, detailedSuggestions bool, opts ...HTTPOption) error {
 	server := NewHTTPLintServer(detailedSuggestions)
 
 	mux := http.NewServeMux()
@@ -183,5 +183,5 @@ func ServeHTTPLint(addr string, detailedSuggestions bool) error {
 
 	log.Printf("Starting OpenAPI validation/linting HTTP server on %s (validate & lint endpoints available)", addr)
 
-	return http.ListenAndServe(addr, mux)
+	return ListenAndServe(addr, mux, opts...)
 }
@@ -0,0 +1,556 @@
+// httpauth.go
+package openapi2mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identity is an authenticated MCP client. Tool handlers and hooks get it with IdentityFromContext.
+type Identity struct {
+	Subject string   `json:"subject"`
+	Method  string   `json:"method"` // "bearer", "basic" or "jwt"
+	Groups  []string `json:"groups,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"`
+	// Claims are the verified claims of a JWT
+	Claims map[string]any `json:"claims,omitempty"`
+}
+
+// identityKey is the context key for the authenticated client identity.
+type identityKey struct{}
+
+// ContextWithIdentity returns a context carrying a client identity.
+func ContextWithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the authenticated client identity, or nil if the client was not
+// authenticated (stdio, or HTTP without an Authenticator).
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// Authenticator verifies the credentials an MCP client presents to the HTTP transports.
+type Authenticator interface {
+	// Authenticate returns the client's identity. It returns ErrNoCredentials if the request carries
+	// no credentials it handles, and another error if they are invalid.
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// ErrNoCredentials is returned by an Authenticator for requests without credentials it handles.
+var ErrNoCredentials = errors.New("no credentials")
+
+// HTTPAuthConfig configures the authentication of MCP clients. Any configured method is accepted.
+type HTTPAuthConfig struct {
+	// BearerTokens maps static bearer tokens to the subject they authenticate
+	BearerTokens map[string]string
+	// UsersFile has "user:passwordHash[:group,...]" lines for HTTP basic authentication (see HashPassword)
+	UsersFile string
+	// JWKSFile, Issuer and Audience configure the verification of bearer JWTs (see JWTVerifier)
+	JWKSFile string
+	Issuer   string
+	Audience []string
+	// Realm is sent in WWW-Authenticate challenges (default "openapi-mcp")
+	Realm string
+}
+
+// NewAuthenticator returns an Authenticator accepting the methods configured in cfg, or nil if none is.
+// The users and JWKS files are checked at once, and read again when they change.
+func NewAuthenticator(cfg HTTPAuthConfig) (Authenticator, error) {
+	auth := &httpAuthenticator{realm: cfg.Realm}
+	if auth.realm == "" {
+		auth.realm = "openapi-mcp"
+	}
+	for token, subject := range cfg.BearerTokens {
+		if token == "" {
+			return nil, fmt.Errorf("empty bearer token for %q", subject)
+		}
+		auth.tokens = append(auth.tokens, staticToken{token: []byte(token), subject: subject})
+	}
+	if cfg.UsersFile != "" {
+		auth.users = &usersFile{path: cfg.UsersFile}
+		if _, err := auth.users.load(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.JWKSFile != "" {
+		auth.jwt = &JWTVerifier{JWKSFile: cfg.JWKSFile, Issuer: cfg.Issuer, Audience: cfg.Audience}
+		if _, err := auth.jwt.loadKeys(); err != nil {
+			return nil, err
+		}
+	}
+	if len(auth.tokens) == 0 && auth.users == nil && auth.jwt == nil {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// httpAuthenticator authenticates clients with static bearer tokens, a users file or JWTs.
+type httpAuthenticator struct {
+	realm  string
+	tokens []staticToken
+	users  *usersFile
+	jwt    *JWTVerifier
+}
+
+type staticToken struct {
+	token   []byte
+	subject string
+}
+
+func (a *httpAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	authorization := r.Header.Get("Authorization")
+	scheme, credentials, _ := strings.Cut(authorization, " ")
+	credentials = strings.TrimSpace(credentials)
+	switch {
+	case strings.EqualFold(scheme, "Bearer") && credentials != "":
+		// Compare with every token, so that timing does not reveal which one matched
+		var match *staticToken
+		for i := range a.tokens {
+			if subtle.ConstantTimeCompare(a.tokens[i].token, []byte(credentials)) == 1 {
+				match = &a.tokens[i]
+			}
+		}
+		if match != nil {
+			return &Identity{Subject: match.subject, Method: "bearer"}, nil
+		}
+		if a.jwt != nil && strings.Count(credentials, ".") == 2 {
+			// Tokens must be issued for the protected resource the request is addressed to
+			audiences := a.jwt.Audience
+			if meta := resourceFromContext(r.Context()); meta != nil && meta.Resource != "" {
+				audiences = append(append([]string(nil), audiences...), meta.Resource)
+			}
+			claims, err := a.jwt.verify(credentials, audiences)
+			if err != nil {
+				return nil, err
+			}
+			return identityFromClaims(claims), nil
+		}
+		return nil, errors.New("invalid bearer token")
+	case strings.EqualFold(scheme, "Basic") && a.users != nil:
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			return nil, errors.New("malformed basic credentials")
+		}
+		return a.users.authenticate(user, password)
+	}
+	return nil, ErrNoCredentials
+}
+
+// challenges returns the WWW-Authenticate challenges for the configured methods. Bearer challenges
+// point to the metadata of the protected resource, if any.
+func (a *httpAuthenticator) challenges(err error, meta *ProtectedResourceMetadata) []string {
+	var challenges []string
+	if len(a.tokens) > 0 || a.jwt != nil {
+		challenge := fmt.Sprintf("Bearer realm=%q", a.realm) + meta.challengeParams()
+		if err != nil && !errors.Is(err, ErrNoCredentials) {
+			challenge += `, error="invalid_token"`
+		}
+		challenges = append(challenges, challenge)
+	}
+	if a.users != nil {
+		challenges = append(challenges, fmt.Sprintf("Basic realm=%q, charset=\"UTF-8\"", a.realm))
+	}
+	return challenges
+}
+
+// identityFromClaims builds the identity of a JWT: its subject (or client_id, for tokens obtained with
+// client credentials), its scopes (scope or scp) and groups.
+func identityFromClaims(claims map[string]any) *Identity {
+	identity := &Identity{Method: "jwt", Claims: claims}
+	identity.Subject, _ = claims["sub"].(string)
+	if identity.Subject == "" {
+		identity.Subject, _ = claims["client_id"].(string)
+	}
+	identity.Scopes = claimStrings(claims["scope"])
+	if len(identity.Scopes) == 0 {
+		identity.Scopes = claimStrings(claims["scp"])
+	}
+	identity.Groups = claimStrings(claims["groups"])
+	return identity
+}
+
+// RequireAuthentication wraps an MCP HTTP handler so that only clients authenticated by auth reach it.
+// Other requests get 401 Unauthorized with WWW-Authenticate challenges. The identity is added to the
+// request context, and the credentials are removed from the request, so that they are never forwarded
+// to the upstream API. Requests without credentials that already carry an identity, e.g. from a client
+// certificate, are let through.
+//
+// MCP sessions are bound to the identity that created them: requests for an SSE sessionId or an
+// Mcp-Session-Id created by another client, or unknown to the handler, get 404 Not Found.
+func RequireAuthentication(auth Authenticator, next http.Handler) http.Handler {
+	sessions := &sessionOwners{owners: make(map[string]*sessionOwner)}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, err := auth.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) && IdentityFromContext(r.Context()) != nil {
+			sessions.serve(w, r, IdentityFromContext(r.Context()), next)
+			return
+		}
+		if err != nil || identity == nil {
+			if err == nil {
+				err = ErrNoCredentials
+			}
+			writeUnauthorized(w, r, auth, err)
+			return
+		}
+		r = r.WithContext(ContextWithIdentity(r.Context(), identity))
+		r.Header = r.Header.Clone()
+		r.Header.Del("Authorization")
+		sessions.serve(w, r, identity, next)
+	})
+}
+
+// --- Session owners ---
+
+// sessionIdleTimeout is how long the owner of a streamable HTTP session is remembered after its last
+// request. SSE sessions are forgotten when their stream is closed.
+const sessionIdleTimeout = 24 * time.Hour
+
+// sessionOwners records the identity that created each MCP session.
+type sessionOwners struct {
+	mu     sync.Mutex
+	owners map[string]*sessionOwner
+}
+
+type sessionOwner struct {
+	owner    string
+	lastSeen time.Time
+	stream   bool
+}
+
+// ownerKey identifies a client across requests, and across the renewal of its credentials.
+func ownerKey(identity *Identity) string {
+	return identity.Method + ":" + identity.Subject
+}
+
+// requestSessionID returns the MCP session a request belongs to, if any.
+func requestSessionID(r *http.Request) string {
+	if id := r.URL.Query().Get("sessionId"); id != "" {
+		return id
+	}
+	return r.Header.Get("Mcp-Session-Id")
+}
+
+// serve passes a request to next if it creates a session, which is then recorded as belonging to
+// identity, or if it belongs to a session of identity.
+func (s *sessionOwners) serve(w http.ResponseWriter, r *http.Request, identity *Identity, next http.Handler) {
+	owner := ownerKey(identity)
+	sessionID := requestSessionID(r)
+	if sessionID == "" {
+		recorder := &sessionRecorder{ResponseWriter: w, record: func(id string, stream bool) {
+			s.record(id, owner, stream)
+		}}
+		next.ServeHTTP(recorder, r)
+		if recorder.streamID != "" {
+			s.forget(recorder.streamID)
+		}
+		return
+	}
+	if !s.owns(sessionID, owner) {
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	recorder := &sessionRecorder{ResponseWriter: w}
+	next.ServeHTTP(recorder, r)
+	if r.Method == http.MethodDelete && recorder.status < http.StatusMultipleChoices {
+		s.forget(sessionID)
+	}
+}
+
+func (s *sessionOwners) record(sessionID, owner string, stream bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, o := range s.owners {
+		if !o.stream && now.Sub(o.lastSeen) > sessionIdleTimeout {
+			delete(s.owners, id)
+		}
+	}
+	s.owners[sessionID] = &sessionOwner{owner: owner, lastSeen: now, stream: stream}
+}
+
+func (s *sessionOwners) owns(sessionID, owner string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.owners[sessionID]
+	if !ok || o.owner != owner {
+		return false
+	}
+	o.lastSeen = time.Now()
+	return true
+}
+
+func (s *sessionOwners) forget(sessionID string) {
+	s.mu.Lock()
+	delete(s.owners, sessionID)
+	s.mu.Unlock()
+}
+
+// sessionRecorder watches a response for the ID of a session being created: the Mcp-Session-Id header
+// of the streamable HTTP transport, or the endpoint event of the SSE transport. It is recorded before
+// the client can see it.
+type sessionRecorder struct {
+	http.ResponseWriter
+	record      func(sessionID string, stream bool)
+	wroteHeader bool
+	status      int
+	streamID    string
+}
+
+func (w *sessionRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.status = status
+		if id := w.Header().Get("Mcp-Session-Id"); id != "" && w.record != nil {
+			w.record(id, false)
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sessionRecorder) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.streamID == "" && w.record != nil {
+		if id := endpointSessionID(p); id != "" {
+			w.streamID = id
+			w.record(id, true)
+		}
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *sessionRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *sessionRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// endpointSessionID returns the session ID of an SSE endpoint event, if p is one.
+func endpointSessionID(p []byte) string {
+	event, ok := strings.CutPrefix(string(p), "event: endpoint\ndata: ")
+	if !ok {
+		return ""
+	}
+	endpoint, _, _ := strings.Cut(event, "\n")
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("sessionId")
+}
+
+// writeUnauthorized answers 401 Unauthorized, with the authenticator's challenges. Other
+// authenticators get a bearer challenge pointing to the protected resource metadata, if any.
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, auth Authenticator, err error) {
+	meta := resourceFromContext(r.Context())
+	if a, ok := auth.(interface {
+		challenges(error, *ProtectedResourceMetadata) []string
+	}); ok {
+		for _, challenge := range a.challenges(err, meta) {
+			w.Header().Add("WWW-Authenticate", challenge)
+		}
+	} else if meta != nil {
+		w.Header().Add("WWW-Authenticate", "Bearer"+strings.TrimPrefix(meta.challengeParams(), ","))
+	}
+	description := "authentication required"
+	if !errors.Is(err, ErrNoCredentials) {
+		description = "invalid credentials: " + err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": "unauthorized", "error_description": description})
+}
+
+// --- Users file and password hashes ---
+
+// usersFile holds the users allowed to authenticate with HTTP basic authentication.
+type usersFile struct {
+	path string
+
+	mu      sync.Mutex
+	users   map[string]fileUser
+	modTime time.Time
+	// verified caches a digest of the last password verified for each user, since every MCP message
+	// is authenticated and password hashes are deliberately slow to compute
+	verified map[string][sha256.Size]byte
+}
+
+type fileUser struct {
+	hash   string
+	groups []string
+}
+
+// load returns the users of the file, reading it again if it changed.
+func (f *usersFile) load() (map[string]fileUser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("reading users file: %w", err)
+	}
+	if f.users != nil && info.ModTime().Equal(f.modTime) {
+		return f.users, nil
+	}
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("reading users file: %w", err)
+	}
+	defer file.Close()
+	users := make(map[string]fileUser)
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) < 2 || fields[0] == "" {
+			return nil, fmt.Errorf("users file %s, line %d: expected user:passwordHash[:groups]", f.path, lineNo)
+		}
+		if _, _, _, _, err := parsePasswordHash(fields[1]); err != nil {
+			return nil, fmt.Errorf("users file %s, line %d: %w", f.path, lineNo, err)
+		}
+		user := fileUser{hash: fields[1]}
+		if len(fields) == 3 && fields[2] != "" {
+			user.groups = strings.Split(fields[2], ",")
+		}
+		users[fields[0]] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading users file: %w", err)
+	}
+	f.users, f.modTime = users, info.ModTime()
+	f.verified = make(map[string][sha256.Size]byte)
+	return users, nil
+}
+
+func (f *usersFile) authenticate(name, password string) (*Identity, error) {
+	users, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	user, ok := users[name]
+	if !ok {
+		return nil, errors.New("invalid user name or password")
+	}
+	digest := sha256.Sum256([]byte(user.hash + "\x00" + password))
+	f.mu.Lock()
+	cached, ok := f.verified[name]
+	f.mu.Unlock()
+	if !ok || subtle.ConstantTimeCompare(cached[:], digest[:]) != 1 {
+		if !verifyPassword(user.hash, password) {
+			return nil, errors.New("invalid user name or password")
+		}
+		f.mu.Lock()
+		f.verified[name] = digest
+		f.mu.Unlock()
+	}
+	return &Identity{Subject: name, Method: "basic", Groups: user.groups}, nil
+}
+
+// passwordHashIterations is the PBKDF2 iteration count of new password hashes.
+const passwordHashIterations = 600000
+
+// HashPassword returns a PBKDF2-SHA256 hash of a password for the users file, in the
+// "$pbkdf2-sha256$<iterations>$<salt>$<hash>" format also used by passlib.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2Key(sha256.New, []byte(password), salt, passwordHashIterations, sha256.Size)
+	return fmt.Sprintf("$pbkdf2-sha256$%d$%s$%s", passwordHashIterations, ab64Encode(salt), ab64Encode(key)), nil
+}
+
+// parsePasswordHash parses a "$pbkdf2-sha256$..." or "$pbkdf2-sha512$..." password hash.
+func parsePasswordHash(s string) (func() hash.Hash, int, []byte, []byte, error) {
+	parts := strings.Split(s, "$")
+	if len(parts) != 5 || parts[0] != "" {
+		if strings.HasPrefix(s, "$2") {
+			return nil, 0, nil, nil, errors.New("bcrypt hashes are not supported; use 'openapi-mcp auth hash-password'")
+		}
+		return nil, 0, nil, nil, errors.New("unsupported password hash; use 'openapi-mcp auth hash-password'")
+	}
+	var newHash func() hash.Hash
+	switch parts[1] {
+	case "pbkdf2-sha256":
+		newHash = sha256.New
+	case "pbkdf2-sha512":
+		newHash = sha512.New
+	default:
+		return nil, 0, nil, nil, fmt.Errorf("unsupported password hash %q; use 'openapi-mcp auth hash-password'", parts[1])
+	}
+	iterations, err := strconv.Atoi(parts[2])
+	salt, err1 := ab64Decode(parts[3])
+	key, err2 := ab64Decode(parts[4])
+	if err != nil || err1 != nil || err2 != nil || iterations < 1 || len(key) == 0 {
+		return nil, 0, nil, nil, errors.New("malformed password hash")
+	}
+	return newHash, iterations, salt, key, nil
+}
+
+// verifyPassword checks a password against a hash, in constant time.
+func verifyPassword(passwordHash, password string) bool {
+	newHash, iterations, salt, key, err := parsePasswordHash(passwordHash)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(pbkdf2Key(newHash, []byte(password), salt, iterations, len(key)), key)
+}
+
+// pbkdf2Key derives a key with PBKDF2 (RFC 8018 section 5.2).
+func pbkdf2Key(newHash func() hash.Hash, password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(newHash, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(binary.BigEndian.AppendUint32(nil, block))
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// ab64Encode and ab64Decode implement passlib's adapted base64: no padding, and "." instead of "+".
+func ab64Encode(b []byte) string {
+	return strings.ReplaceAll(base64.RawStdEncoding.EncodeToString(b), "+", ".")
+}
+
+func ab64Decode(s string) ([]byte, error) {
+	return base64.RawStdEncoding.DecodeString(strings.ReplaceAll(strings.TrimRight(s, "="), ".", "+"))
+}
@@ -0,0 +1,322 @@
+package openapi2mcp
+
+import (
+	"bufio"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func TestPBKDF2(t *testing.T) {
+	// Test vectors for PBKDF2-HMAC-SHA256 (password "password", salt "salt")
+	for iterations, want := range map[int]string{
+		1: "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b",
+		2: "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43",
+	} {
+		if got := hex.EncodeToString(pbkdf2Key(sha256.New, []byte("password"), []byte("salt"), iterations, 32)); got != want {
+			t.Errorf("%d iterations: got %s, want %s", iterations, got, want)
+		}
+	}
+	hash, err := HashPassword("s3cret")
+	if err != nil || !strings.HasPrefix(hash, "$pbkdf2-sha256$600000$") {
+		t.Fatalf("unexpected hash %q, %v", hash, err)
+	}
+	if !verifyPassword(hash, "s3cret") || verifyPassword(hash, "wrong") {
+		t.Errorf("password verification failed")
+	}
+}
+
+// signJWT signs claims with an ECDSA P-256 or RSA key.
+func signJWT(t *testing.T, key crypto.Signer, kid string, claims map[string]any) string {
+	t.Helper()
+	alg := "ES256"
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		alg = "RS256"
+	}
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJWKS(t *testing.T, path string, ecKey *ecdsa.PrivateKey, rsaKey *rsa.PrivateKey) {
+	t.Helper()
+	b64 := base64.RawURLEncoding.EncodeToString
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "EC", "kid": "ec1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "RSA", "kid": "rsa1", "alg": "RS256", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}})
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJWTVerifier(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, ecKey, rsaKey)
+	v := &JWTVerifier{JWKSFile: path, Issuer: "https://idp.example.com", Audience: []string{"openapi-mcp"}}
+	exp := float64(time.Now().Add(time.Hour).Unix())
+	valid := map[string]any{"iss": "https://idp.example.com", "aud": []string{"other", "openapi-mcp"}, "sub": "alice", "exp": exp, "scope": "read write"}
+
+	for _, key := range []crypto.Signer{ecKey, rsaKey} {
+		kid := map[bool]string{true: "ec1", false: "rsa1"}[key == crypto.Signer(ecKey)]
+		claims, err := v.Verify(signJWT(t, key, kid, valid))
+		if err != nil || claims["sub"] != "alice" {
+			t.Errorf("%s: expected a valid token: %v", kid, err)
+		}
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	with := func(k string, v any) map[string]any {
+		claims := map[string]any{}
+		for key, value := range valid {
+			claims[key] = value
+		}
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+		return claims
+	}
+	for name, token := range map[string]string{
+		"wrong key":       signJWT(t, otherKey, "ec1", valid),
+		"wrong kid":       signJWT(t, ecKey, "rsa1", valid),
+		"expired":         signJWT(t, ecKey, "ec1", with("exp", float64(time.Now().Add(-time.Hour).Unix()))),
+		"no expiry":       signJWT(t, ecKey, "ec1", with("exp", nil)),
+		"not yet valid":   signJWT(t, ecKey, "ec1", with("nbf", float64(time.Now().Add(time.Hour).Unix()))),
+		"wrong issuer":    signJWT(t, ecKey, "ec1", with("iss", "https://evil.example.com")),
+		"wrong audience":  signJWT(t, ecKey, "ec1", with("aud", "other")),
+		"alg none":        base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"x"}`)) + ".",
+		"malformed token": "abc.def",
+	} {
+		if _, err := v.Verify(token); err == nil {
+			t.Errorf("%s: expected the token to be rejected", name)
+		}
+	}
+}
+
+func TestRequireAuthentication(t *testing.T) {
+	dir := t.TempDir()
+	hash, _ := HashPassword("pw")
+	usersPath := filepath.Join(dir, "users")
+	os.WriteFile(usersPath, []byte("# support staff\nbob:"+hash+":support,tickets\n"), 0o600)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksPath := filepath.Join(dir, "jwks.json")
+	writeJWKS(t, jwksPath, ecKey, rsaKey)
+
+	auth, err := NewAuthenticator(HTTPAuthConfig{
+		BearerTokens: map[string]string{"static-token": "ci"},
+		UsersFile:    usersPath,
+		JWKSFile:     jwksPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var identity *Identity
+	var forwarded string
+	handler := RequireAuthentication(auth, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = IdentityFromContext(r.Context())
+		forwarded = r.Header.Get("Authorization")
+	}))
+	request := func(authorization string) *httptest.ResponseRecorder {
+		identity = nil
+		r := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if request("Bearer static-token"); identity == nil || identity.Subject != "ci" || identity.Method != "bearer" || forwarded != "" {
+		t.Errorf("unexpected identity %+v (forwarded %q)", identity, forwarded)
+	}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("bob:pw"))
+	if request(basic); identity == nil || identity.Subject != "bob" || strings.Join(identity.Groups, ",") != "support,tickets" {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+	token := signJWT(t, ecKey, "ec1", map[string]any{"sub": "carol", "exp": float64(time.Now().Add(time.Minute).Unix()), "scp": []string{"tickets:read"}, "groups": []string{"support"}})
+	if request("Bearer " + token); identity == nil || identity.Subject != "carol" || identity.Method != "jwt" || identity.Scopes[0] != "tickets:read" || identity.Groups[0] != "support" {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+
+	w := request("")
+	if w.Code != http.StatusUnauthorized || identity != nil {
+		t.Fatalf("expected 401 without credentials, got %d", w.Code)
+	}
+	if challenges := w.Header().Values("WWW-Authenticate"); len(challenges) != 2 || challenges[0] != `Bearer realm="openapi-mcp"` || !strings.HasPrefix(challenges[1], "Basic ") {
+		t.Errorf("unexpected challenges %v", challenges)
+	}
+	for _, bad := range []string{"Bearer wrong", "Basic " + base64.StdEncoding.EncodeToString([]byte("bob:wrong")), "Bearer " + token + "x"} {
+		if w := request(bad); w.Code != http.StatusUnauthorized || identity != nil {
+			t.Errorf("%q: expected 401, got %d", bad, w.Code)
+		}
+	}
+	if w := request("Bearer wrong"); !strings.Contains(w.Header().Get("WWW-Authenticate"), `error="invalid_token"`) {
+		t.Errorf("expected an invalid_token challenge, got %q", w.Header().Get("WWW-Authenticate"))
+	}
+
+	if auth, err := NewAuthenticator(HTTPAuthConfig{}); auth != nil || err != nil {
+		t.Errorf("expected no authenticator without configuration")
+	}
+	os.WriteFile(usersPath, []byte("eve:$2y$10$abcdefghijklmnopqrstuu5Yq8G7f1F5b1nq2vH0m3m9i9wO0x0a\n"), 0o600)
+	if _, err := NewAuthenticator(HTTPAuthConfig{UsersFile: usersPath}); err == nil || !strings.Contains(err.Error(), "bcrypt") {
+		t.Errorf("expected bcrypt hashes to be reported, got %v", err)
+	}
+}
+
+func TestIdentityInToolHandlers(t *testing.T) {
+	auth, _ := NewAuthenticator(HTTPAuthConfig{BearerTokens: map[string]string{"t0k3n": "ci"}})
+	srv := server.NewMCPServer("test", "1.0.0")
+	srv.AddTool(mcp.Tool{Name: "whoami", InputSchema: mcp.ToolInputSchema{Type: "object"}}, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		subject := "anonymous"
+		if identity := IdentityFromContext(ctx); identity != nil {
+			subject = identity.Subject
+		}
+		return mcp.NewToolResultText(subject+" "+os.Getenv("BEARER_TOKEN"), nil, nil, nil, "", nil), nil
+	})
+	t.Setenv("BEARER_TOKEN", "")
+	ts := httptest.NewServer(HandlerForStreamableHTTP(srv, "/mcp", WithAuthenticator(auth)))
+	defer ts.Close()
+
+	post := func(token, session, body string) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp, string(data)
+	}
+	resp, _ := post("t0k3n", "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	session := resp.Header.Get("Mcp-Session-Id")
+	call := func(token string) (*http.Response, string) {
+		return post(token, session, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"whoami","arguments":{}}}`)
+	}
+	if resp, _ := call(""); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+	// The identity reaches the tool handler, and the client's token is not used as an upstream credential
+	if resp, body := call("t0k3n"); resp.StatusCode != http.StatusOK || !strings.Contains(body, `"ci "`) {
+		t.Errorf("unexpected response %d: %s", resp.StatusCode, body)
+	}
+}
+
+func TestSessionsBoundToIdentity(t *testing.T) {
+	auth, _ := NewAuthenticator(HTTPAuthConfig{BearerTokens: map[string]string{"alice-token": "alice", "bob-token": "bob"}})
+	srv := server.NewMCPServer("test", "1.0.0")
+	do := func(url, method, token, session, body string) *http.Response {
+		req, _ := http.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	const ping = `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+
+	streamable := httptest.NewServer(HandlerForStreamableHTTP(srv, "/mcp", WithAuthenticator(auth)))
+	defer streamable.Close()
+	resp := do(streamable.URL+"/mcp", http.MethodPost, "alice-token", "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	session := resp.Header.Get("Mcp-Session-Id")
+	if session == "" {
+		t.Fatalf("expected a session ID, got status %d", resp.StatusCode)
+	}
+	if resp := do(streamable.URL+"/mcp", http.MethodPost, "bob-token", session, ping); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected another client's session to be rejected, got %d", resp.StatusCode)
+	}
+	if resp := do(streamable.URL+"/mcp", http.MethodPost, "alice-token", session, ping); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the owner's request to succeed, got %d", resp.StatusCode)
+	}
+	if resp := do(streamable.URL+"/mcp", http.MethodPost, "alice-token", "mcp-session-"+uuid.New().String(), ping); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected an unknown session to be rejected, got %d", resp.StatusCode)
+	}
+	do(streamable.URL+"/mcp", http.MethodDelete, "alice-token", session, "")
+	if resp := do(streamable.URL+"/mcp", http.MethodPost, "alice-token", session, ping); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a deleted session to be rejected, got %d", resp.StatusCode)
+	}
+
+	sse := httptest.NewServer(HandlerForBasePath(srv, "/mcp", WithAuthenticator(auth)))
+	defer sse.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, sse.URL+"/mcp/sse", nil)
+	req.Header.Set("Authorization", "Bearer alice-token")
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	reader := bufio.NewReader(stream.Body)
+	var endpoint string
+	for endpoint == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: "); ok {
+			endpoint = data
+		}
+	}
+	if resp := do(sse.URL+endpoint, http.MethodPost, "bob-token", "", ping); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected another client's SSE session to be rejected, got %d", resp.StatusCode)
+	}
+	if resp := do(sse.URL+endpoint, http.MethodPost, "alice-token", "", ping); resp.StatusCode != http.StatusAccepted {
+		t.Errorf("expected the owner's message to be accepted, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,111 @@
+// interceptor.go
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+)
+
+// RequestInterceptor can modify an upstream HTTP request before it is sent, e.g. to sign it or rewrite its body.
+// It runs after parameters, authentication and custom headers have been applied, so it sees the final request.
+// The context carries the MCP client session (see server.ClientSessionFromContext) and client headers.
+// Returning an error aborts the tool call.
+type RequestInterceptor interface {
+	InterceptRequest(ctx context.Context, op OpenAPIOperation, req *http.Request) error
+}
+
+// RequestInterceptorFunc adapts a function to a RequestInterceptor.
+type RequestInterceptorFunc func(ctx context.Context, op OpenAPIOperation, req *http.Request) error
+
+// InterceptRequest calls f(ctx, op, req).
+func (f RequestInterceptorFunc) InterceptRequest(ctx context.Context, op OpenAPIOperation, req *http.Request) error {
+	return f(ctx, op, req)
+}
+
+// ResponseInterceptor can transform an upstream HTTP response before the tool result is built from it.
+// It may change the response status and headers in place and returns the (possibly rewritten) body.
+// Returning an error aborts the tool call.
+type ResponseInterceptor interface {
+	InterceptResponse(ctx context.Context, op OpenAPIOperation, resp *http.Response, body []byte) ([]byte, error)
+}
+
+// ResponseInterceptorFunc adapts a function to a ResponseInterceptor.
+type ResponseInterceptorFunc func(ctx context.Context, op OpenAPIOperation, resp *http.Response, body []byte) ([]byte, error)
+
+// InterceptResponse calls f(ctx, op, resp, body).
+func (f ResponseInterceptorFunc) InterceptResponse(ctx context.Context, op OpenAPIOperation, resp *http.Response, body []byte) ([]byte, error) {
+	return f(ctx, op, resp, body)
+}
+
+// ResultInterceptor can transform the result of a tool call, including validation errors,
+// confirmation prompts and request previews.
+type ResultInterceptor interface {
+	InterceptResult(ctx context.Context, op OpenAPIOperation, result *mcp.CallToolResult) (*mcp.CallToolResult, error)
+}
+
+// ResultInterceptorFunc adapts a function to a ResultInterceptor.
+type ResultInterceptorFunc func(ctx context.Context, op OpenAPIOperation, result *mcp.CallToolResult) (*mcp.CallToolResult, error)
+
+// InterceptResult calls f(ctx, op, result).
+func (f ResultInterceptorFunc) InterceptResult(ctx context.Context, op OpenAPIOperation, result *mcp.CallToolResult) (*mcp.CallToolResult, error) {
+	return f(ctx, op, result)
+}
+
+// applyRequestInterceptors runs request interceptors in order. The request body is re-read afterwards
+// so that previews, logs and retries see what the interceptors produced.
+func applyRequestInterceptors(ctx context.Context, interceptors []RequestInterceptor, op OpenAPIOperation, prepared *preparedRequest) error {
+	if len(interceptors) == 0 {
+		return nil
+	}
+	for _, interceptor := range interceptors {
+		if err := interceptor.InterceptRequest(ctx, op, prepared.req); err != nil {
+			return err
+		}
+	}
+	req := prepared.req
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	prepared.body = body
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	return nil
+}
+
+// applyResponseInterceptors runs response interceptors in reverse order, so that the first
+// interceptor sees the request first and the response last.
+func applyResponseInterceptors(ctx context.Context, interceptors []ResponseInterceptor, op OpenAPIOperation, resp *http.Response, body []byte) ([]byte, error) {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		var err error
+		body, err = interceptors[i].InterceptResponse(ctx, op, resp, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
+
+// applyResultInterceptors runs result interceptors in reverse order, after response interceptors.
+func applyResultInterceptors(ctx context.Context, interceptors []ResultInterceptor, op OpenAPIOperation, result *mcp.CallToolResult) (*mcp.CallToolResult, error) {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		var err error
+		result, err = interceptors[i].InterceptResult(ctx, op, result)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func TestInterceptorChain(t *testing.T) {
+	var gotSignature, gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get("X-Signature")
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"secret":"s3cr3t"}`))
+	}))
+	defer ts.Close()
+	os.Setenv("OPENAPI_BASE_URL", ts.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+
+	paths := openapi3.NewPaths()
+	paths.Set("/echo", &openapi3.PathItem{
+		Get: &openapi3.Operation{OperationID: "echo"},
+	})
+	doc := &openapi3.T{Info: &openapi3.Info{Title: "Test API", Version: "1.0.0"}, Paths: paths}
+
+	var order []string
+	request := func(name string) RequestInterceptor {
+		return RequestInterceptorFunc(func(ctx context.Context, op OpenAPIOperation, req *http.Request) error {
+			order = append(order, "request:"+name)
+			if op.OperationID != "echo" {
+				t.Errorf("unexpected operation %q", op.OperationID)
+			}
+			req.Header.Set("X-Signature", req.Header.Get("X-Signature")+name)
+			req.Body = io.NopCloser(bytes.NewReader([]byte("body-" + name)))
+			return nil
+		})
+	}
+	response := func(name string) ResponseInterceptor {
+		return ResponseInterceptorFunc(func(ctx context.Context, op OpenAPIOperation, resp *http.Response, body []byte) ([]byte, error) {
+			order = append(order, "response:"+name)
+			return bytes.ReplaceAll(body, []byte("s3cr3t"), []byte("***")), nil
+		})
+	}
+	result := func(name string) ResultInterceptor {
+		return ResultInterceptorFunc(func(ctx context.Context, op OpenAPIOperation, result *mcp.CallToolResult) (*mcp.CallToolResult, error) {
+			order = append(order, "result:"+name)
+			result.NextSteps = append(result.NextSteps, name)
+			return result, nil
+		})
+	}
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{
+		RequestInterceptors:  []RequestInterceptor{request("a"), request("b")},
+		ResponseInterceptors: []ResponseInterceptor{response("a"), response("b")},
+		ResultInterceptors:   []ResultInterceptor{result("a"), result("b")},
+	})
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{}}}`)
+	resp, ok := srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a JSON-RPC response")
+	}
+	res := resp.Result.(mcp.CallToolResult)
+
+	want := "request:a,request:b,response:b,response:a,result:b,result:a"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("unexpected interceptor order:\n got: %s\nwant: %s", got, want)
+	}
+	if gotSignature != "ab" || gotBody != "body-b" {
+		t.Errorf("upstream did not receive the intercepted request: signature=%q body=%q", gotSignature, gotBody)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; strings.Contains(text, "s3cr3t") {
+		t.Errorf("response interceptor did not rewrite the body: %s", text)
+	}
+	if n := len(res.NextSteps); n < 2 || res.NextSteps[n-2] != "b" || res.NextSteps[n-1] != "a" {
+		t.Errorf("unexpected result interceptor effects: %v", res.NextSteps)
+	}
+}
+
+func TestInterceptorErrorsAreToolErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	os.Setenv("OPENAPI_BASE_URL", ts.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+
+	spec := `{"openapi":"3.0.0","info":{"title":"Test API","version":"1.0.0"},"paths":{"/echo":{"get":{"operationId":"echo","responses":{"200":{"description":"ok"}}}}}}`
+	failRequest := RequestInterceptorFunc(func(ctx context.Context, op OpenAPIOperation, req *http.Request) error {
+		return errors.New("request rejected")
+	})
+	failResponse := ResponseInterceptorFunc(func(ctx context.Context, op OpenAPIOperation, resp *http.Response, body []byte) ([]byte, error) {
+		return nil, errors.New("response rejected")
+	})
+
+	for _, tc := range []struct {
+		name, args, want string
+		opts             *ToolGenOptions
+	}{
+		{"request", `{}`, "request rejected", &ToolGenOptions{RequestInterceptors: []RequestInterceptor{failRequest}}},
+		{"preview", `{"__preview":true}`, "request rejected", &ToolGenOptions{RequestInterceptors: []RequestInterceptor{failRequest}}},
+		{"response", `{}`, "response rejected", &ToolGenOptions{ResponseInterceptors: []ResponseInterceptor{failResponse}}},
+	} {
+		result := callTool(t, newTestServer(t, spec, tc.opts), nil, "echo", tc.args)
+		if !result.IsError || !strings.Contains(resultText(result), tc.want) {
+			t.Errorf("%s: expected a tool error mentioning %q, got %+v", tc.name, tc.want, result)
+		}
+	}
+}
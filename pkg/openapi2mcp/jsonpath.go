@@ -0,0 +1,490 @@
+// jsonpath.go
+package openapi2mcp
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath expression over generic JSON/YAML trees (map[string]any, []any and scalars).
+// It supports the subset used by OpenAPI Overlays: $, .name, ['name'], [n], [*], .*, ..name (recursive descent)
+// and filters such as [?(@.name == 'id')], [?@.deprecated], combined with && and ||.
+type jsonPath struct {
+	segments []jsonPathSegment
+}
+
+type jsonPathSegment struct {
+	recursive bool
+	wildcard  bool
+	names     []string
+	indices   []int
+	filter    *jsonPathFilter
+}
+
+// jsonPathFilter is a disjunction of conjunctions of comparisons.
+type jsonPathFilter struct {
+	or [][]jsonPathComparison
+}
+
+type jsonPathComparison struct {
+	path  []string // relative to @
+	op    string   // "", "==" or "!="
+	value any
+}
+
+// compileJSONPath parses a JSONPath expression.
+func compileJSONPath(expr string) (*jsonPath, error) {
+	expr = strings.TrimSpace(expr)
+	if !strings.HasPrefix(expr, "$") {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", expr)
+	}
+	p := &jsonPath{}
+	rest := expr[1:]
+	for len(rest) > 0 {
+		var seg jsonPathSegment
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				break
+			}
+			name, remaining := scanJSONPathName(rest)
+			rest = remaining
+			if name == "*" {
+				seg.wildcard = true
+			} else if name == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty name after ..", expr)
+			} else {
+				seg.names = []string{name}
+			}
+			p.segments = append(p.segments, seg)
+			continue
+		case strings.HasPrefix(rest, "."):
+			name, remaining := scanJSONPathName(rest[1:])
+			rest = remaining
+			if name == "*" {
+				seg.wildcard = true
+			} else if name == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty name", expr)
+			} else {
+				seg.names = []string{name}
+			}
+			p.segments = append(p.segments, seg)
+			continue
+		}
+		if !strings.HasPrefix(rest, "[") {
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", expr, rest)
+		}
+		end := matchingBracket(rest)
+		if end < 0 {
+			return nil, fmt.Errorf("invalid JSONPath %q: unterminated [", expr)
+		}
+		inner := strings.TrimSpace(rest[1:end])
+		rest = rest[end+1:]
+		if err := parseJSONPathBracket(inner, &seg); err != nil {
+			return nil, fmt.Errorf("invalid JSONPath %q: %w", expr, err)
+		}
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+// scanJSONPathName reads a dot-notation member name, up to the next '.' or '['.
+func scanJSONPathName(s string) (string, string) {
+	i := strings.IndexAny(s, ".[")
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// matchingBracket returns the index of the ']' closing the '[' at s[0], skipping quoted strings and nested brackets.
+func matchingBracket(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseJSONPathBracket(inner string, seg *jsonPathSegment) error {
+	if inner == "*" {
+		seg.wildcard = true
+		return nil
+	}
+	if strings.HasPrefix(inner, "?") {
+		filter, err := parseJSONPathFilter(strings.TrimSpace(inner[1:]))
+		if err != nil {
+			return err
+		}
+		seg.filter = filter
+		return nil
+	}
+	for _, part := range splitOutsideQuotes(inner, ",") {
+		part = strings.TrimSpace(part)
+		if s, ok := unquoteJSONPathString(part); ok {
+			seg.names = append(seg.names, s)
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("unsupported selector [%s]", inner)
+		}
+		seg.indices = append(seg.indices, n)
+	}
+	return nil
+}
+
+func parseJSONPathFilter(expr string) (*jsonPathFilter, error) {
+	if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	filter := &jsonPathFilter{}
+	for _, disjunct := range splitOutsideQuotes(expr, "||") {
+		var and []jsonPathComparison
+		for _, term := range splitOutsideQuotes(disjunct, "&&") {
+			cmp, err := parseJSONPathComparison(strings.TrimSpace(term))
+			if err != nil {
+				return nil, err
+			}
+			and = append(and, cmp)
+		}
+		filter.or = append(filter.or, and)
+	}
+	return filter, nil
+}
+
+func parseJSONPathComparison(term string) (jsonPathComparison, error) {
+	var cmp jsonPathComparison
+	lhs := term
+	for _, op := range []string{"==", "!="} {
+		if parts := splitOutsideQuotes(term, op); len(parts) == 2 {
+			cmp.op = op
+			lhs = strings.TrimSpace(parts[0])
+			value, err := parseJSONPathLiteral(strings.TrimSpace(parts[1]))
+			if err != nil {
+				return cmp, err
+			}
+			cmp.value = value
+			break
+		}
+	}
+	if !strings.HasPrefix(lhs, "@") {
+		return cmp, fmt.Errorf("unsupported filter expression %q", term)
+	}
+	rel, err := compileJSONPath("$" + lhs[1:])
+	if err != nil {
+		return cmp, err
+	}
+	for _, seg := range rel.segments {
+		if seg.recursive || seg.wildcard || seg.filter != nil || len(seg.indices) > 0 || len(seg.names) != 1 {
+			return cmp, fmt.Errorf("unsupported filter operand %q", lhs)
+		}
+		cmp.path = append(cmp.path, seg.names[0])
+	}
+	return cmp, nil
+}
+
+func parseJSONPathLiteral(s string) (any, error) {
+	if str, ok := unquoteJSONPathString(s); ok {
+		return str, nil
+	}
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported filter value %q", s)
+}
+
+func unquoteJSONPathString(s string) (string, bool) {
+	if len(s) < 2 || (s[0] != '\'' && s[0] != '"') || s[len(s)-1] != s[0] {
+		return "", false
+	}
+	body := s[1 : len(s)-1]
+	body = strings.ReplaceAll(body, `\`+string(s[0]), string(s[0]))
+	return strings.ReplaceAll(body, `\\`, `\`), true
+}
+
+// splitOutsideQuotes splits s on sep, ignoring separators inside quoted strings.
+func splitOutsideQuotes(s, sep string) []string {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if quote != 0 {
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		if c == '\'' || c == '"' {
+			quote = c
+			continue
+		}
+		if strings.HasPrefix(s[i:], sep) {
+			parts = append(parts, s[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// find returns the locations of all nodes matching the path. A location is a list of
+// map keys (string) and array indices (int) from the root.
+func (p *jsonPath) find(root any) [][]any {
+	current := [][]any{{}}
+	for _, seg := range p.segments {
+		var next [][]any
+		seen := map[string]bool{}
+		for _, loc := range current {
+			candidates := [][]any{loc}
+			if seg.recursive {
+				candidates = descendants(root, loc)
+			}
+			for _, c := range candidates {
+				for _, child := range seg.selectChildren(getAt(root, c), c) {
+					key := fmt.Sprint(child)
+					if !seen[key] {
+						seen[key] = true
+						next = append(next, child)
+					}
+				}
+			}
+		}
+		current = next
+	}
+	return current
+}
+
+// selectChildren applies a segment's selector to the children of node located at loc.
+func (seg jsonPathSegment) selectChildren(node any, loc []any) [][]any {
+	var out [][]any
+	add := func(k any) {
+		child := append(append([]any{}, loc...), k)
+		out = append(out, child)
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		switch {
+		case seg.wildcard || seg.filter != nil:
+			for _, k := range sortedKeys(n) {
+				if seg.filter == nil || seg.filter.matches(n[k]) {
+					add(k)
+				}
+			}
+		default:
+			for _, name := range seg.names {
+				if _, ok := n[name]; ok {
+					add(name)
+				}
+			}
+		}
+	case []any:
+		switch {
+		case seg.wildcard || seg.filter != nil:
+			for i, v := range n {
+				if seg.filter == nil || seg.filter.matches(v) {
+					add(i)
+				}
+			}
+		default:
+			for _, i := range seg.indices {
+				if i < 0 {
+					i += len(n)
+				}
+				if i >= 0 && i < len(n) {
+					add(i)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (f *jsonPathFilter) matches(node any) bool {
+	for _, and := range f.or {
+		ok := true
+		for _, cmp := range and {
+			if !cmp.matches(node) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (c jsonPathComparison) matches(node any) bool {
+	v, exists := node, true
+	for _, name := range c.path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			exists = false
+			break
+		}
+		if v, ok = m[name]; !ok {
+			exists = false
+			break
+		}
+	}
+	switch c.op {
+	case "==":
+		return exists && jsonValuesEqual(v, c.value)
+	case "!=":
+		return !exists || !jsonValuesEqual(v, c.value)
+	}
+	return exists
+}
+
+func jsonValuesEqual(a, b any) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	return a == b
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// descendants returns loc and the locations of all nodes below it, in document order.
+func descendants(root any, loc []any) [][]any {
+	out := [][]any{loc}
+	switch n := getAt(root, loc).(type) {
+	case map[string]any:
+		for _, k := range sortedKeys(n) {
+			out = append(out, descendants(root, append(append([]any{}, loc...), k))...)
+		}
+	case []any:
+		for i := range n {
+			out = append(out, descendants(root, append(append([]any{}, loc...), i))...)
+		}
+	}
+	return out
+}
+
+// getAt returns the node at a location, or nil if it does not exist.
+func getAt(root any, loc []any) any {
+	node := root
+	for _, k := range loc {
+		switch key := k.(type) {
+		case string:
+			m, ok := node.(map[string]any)
+			if !ok {
+				return nil
+			}
+			node = m[key]
+		case int:
+			a, ok := node.([]any)
+			if !ok || key >= len(a) {
+				return nil
+			}
+			node = a[key]
+		}
+	}
+	return node
+}
+
+// setAt replaces the node at a non-root location.
+func setAt(root any, loc []any, value any) {
+	parent := getAt(root, loc[:len(loc)-1])
+	switch key := loc[len(loc)-1].(type) {
+	case string:
+		if m, ok := parent.(map[string]any); ok {
+			m[key] = value
+		}
+	case int:
+		if a, ok := parent.([]any); ok && key < len(a) {
+			a[key] = value
+		}
+	}
+}
+
+// removeAt deletes the node at a non-root location.
+func removeAt(root any, loc []any) {
+	parentLoc := loc[:len(loc)-1]
+	parent := getAt(root, parentLoc)
+	switch key := loc[len(loc)-1].(type) {
+	case string:
+		if m, ok := parent.(map[string]any); ok {
+			delete(m, key)
+		}
+	case int:
+		if a, ok := parent.([]any); ok && key < len(a) {
+			shrunk := append(append([]any{}, a[:key]...), a[key+1:]...)
+			if len(parentLoc) == 0 {
+				return
+			}
+			setAt(root, parentLoc, shrunk)
+		}
+	}
+}
+
+// sortLocationsForRemoval orders locations so that removing them one by one never shifts
+// the array indices of locations not yet removed: deeper nodes and higher indices first.
+func sortLocationsForRemoval(locs [][]any) {
+	sort.SliceStable(locs, func(i, j int) bool {
+		a, b := locs[i], locs[j]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if c := compareLocationKey(a[k], b[k]); c != 0 {
+				return c > 0
+			}
+		}
+		return len(a) > len(b)
+	})
+}
+
+func compareLocationKey(a, b any) int {
+	ai, aInt := a.(int)
+	bi, bInt := b.(int)
+	if aInt && bInt {
+		return ai - bi
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
@@ -0,0 +1,323 @@
+// jwt.go
+package openapi2mcp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTVerifier verifies JSON Web Tokens (RFC 7519) signed with the keys of a local JWKS file (RFC 7517).
+// The file is read again when it changes, so signing keys can be rotated without a restart.
+//
+// Supported algorithms are RS256/384/512, PS256/384/512, ES256/384/512, EdDSA (Ed25519) and, with
+// symmetric "oct" keys, HS256/384/512. Tokens must have an expiry (exp).
+type JWTVerifier struct {
+	JWKSFile string
+	Issuer   string   // required iss claim, if set
+	Audience []string // the aud claim must contain one of these, if set
+	Leeway   time.Duration
+
+	mu      sync.Mutex
+	keys    []jwk
+	modTime time.Time
+	now     func() time.Time
+}
+
+// jwk is a JSON Web Key, with its public (or symmetric) key decoded.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+
+	key any
+}
+
+// defaultJWTLeeway is the clock skew tolerated when checking exp, nbf and iat.
+const defaultJWTLeeway = time.Minute
+
+// Verify checks a token's signature and claims, and returns its claims.
+func (v *JWTVerifier) Verify(token string) (map[string]any, error) {
+	return v.verify(token, v.Audience)
+}
+
+// verify is Verify, with the accepted audiences given explicitly.
+func (v *JWTVerifier) verify(token string, audiences []string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("malformed token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed token signature")
+	}
+	keys, err := v.loadKeys()
+	if err != nil {
+		return nil, err
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	verified := false
+	for _, key := range keys {
+		if header.Kid != "" && key.Kid != header.Kid || key.Alg != "" && key.Alg != header.Alg {
+			continue
+		}
+		if verifyJWTSignature(header.Alg, key.key, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, fmt.Errorf("invalid signature (alg %q, kid %q)", header.Alg, header.Kid)
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if err := v.checkClaims(claims, audiences); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// checkClaims validates the registered time, issuer and audience claims.
+func (v *JWTVerifier) checkClaims(claims map[string]any, accepted []string) error {
+	now := time.Now()
+	if v.now != nil {
+		now = v.now()
+	}
+	leeway := v.Leeway
+	if leeway == 0 {
+		leeway = defaultJWTLeeway
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(leeway)) {
+		return errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token not valid yet")
+	}
+	if iat, ok := claims["iat"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(iat), 0)) {
+		return errors.New("token issued in the future")
+	}
+	if v.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != v.Issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+	if len(accepted) > 0 {
+		audiences := claimStrings(claims["aud"])
+		for _, want := range accepted {
+			for _, aud := range audiences {
+				if aud == want {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("token audience %v does not include %s", audiences, strings.Join(accepted, " or "))
+	}
+	return nil
+}
+
+// loadKeys returns the keys of the JWKS file, reading it again if it changed.
+func (v *JWTVerifier) loadKeys() ([]jwk, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	info, err := os.Stat(v.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWKS file: %w", err)
+	}
+	if v.keys != nil && info.ModTime().Equal(v.modTime) {
+		return v.keys, nil
+	}
+	keys, err := readJWKS(v.JWKSFile)
+	if err != nil {
+		return nil, err
+	}
+	v.keys, v.modTime = keys, info.ModTime()
+	return keys, nil
+}
+
+// readJWKS reads a JSON Web Key Set. Encryption keys and keys of unsupported types are skipped.
+func readJWKS(path string) ([]jwk, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWKS file: %w", err)
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing JWKS file %s: %w", path, err)
+	}
+	keys := make([]jwk, 0, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use == "enc" {
+			continue
+		}
+		if key.key, err = key.decode(); err != nil {
+			return nil, fmt.Errorf("JWKS file %s: key %q: %w", path, key.Kid, err)
+		}
+		if key.key != nil {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s has no signature verification keys", path)
+	}
+	return keys, nil
+}
+
+// decode returns the key as *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or []byte (HMAC),
+// or nil for unsupported key types.
+func (k *jwk) decode() (any, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err1 := b64.DecodeString(k.N)
+		e, err2 := b64.DecodeString(k.E)
+		if err1 != nil || err2 != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("invalid RSA key")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err1 := b64.DecodeString(k.X)
+		y, err2 := b64.DecodeString(k.Y)
+		if err1 != nil || err2 != nil {
+			return nil, errors.New("invalid EC key")
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("invalid EC key")
+		}
+		return key, nil
+	case "OKP":
+		x, err := b64.DecodeString(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("unsupported or invalid OKP key")
+		}
+		return ed25519.PublicKey(x), nil
+	case "oct":
+		secret, err := b64.DecodeString(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("invalid symmetric key")
+		}
+		return secret, nil
+	}
+	return nil, nil
+}
+
+// verifyJWTSignature checks a JWS signature. The algorithm must match the key type; "none" is never accepted.
+func verifyJWTSignature(alg string, key any, signed, signature []byte) bool {
+	var digest crypto.Hash
+	switch alg[min(2, len(alg)):] {
+	case "256":
+		digest = crypto.SHA256
+	case "384":
+		digest = crypto.SHA384
+	case "512":
+		digest = crypto.SHA512
+	}
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if digest == 0 || !strings.HasPrefix(alg, "RS") && !strings.HasPrefix(alg, "PS") {
+			return false
+		}
+		h := digest.New()
+		h.Write(signed)
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(key, digest, h.Sum(nil), signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+		return rsa.VerifyPKCS1v15(key, digest, h.Sum(nil), signature) == nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		want := map[int]string{32: "ES256", 48: "ES384", 66: "ES512"}[size]
+		if alg != want || len(signature) != 2*size {
+			return false
+		}
+		h := digest.New()
+		h.Write(signed)
+		r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, h.Sum(nil), r, s)
+	case ed25519.PublicKey:
+		return alg == "EdDSA" && ed25519.Verify(key, signed, signature)
+	case []byte:
+		if digest == 0 || !strings.HasPrefix(alg, "HS") {
+			return false
+		}
+		newHash := map[crypto.Hash]func() hash.Hash{crypto.SHA256: sha256.New, crypto.SHA384: sha512.New384, crypto.SHA512: sha512.New}[digest]
+		mac := hmac.New(newHash, key)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+	return false
+}
+
+// decodeJWTPart decodes a base64url-encoded JSON part of a token.
+func decodeJWTPart(part string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// claimStrings returns a claim that may be a string, a space-separated list or an array, as strings.
+func claimStrings(claim any) []string {
+	switch claim := claim.(type) {
+	case string:
+		return strings.Fields(claim)
+	case []any:
+		values := make([]string, 0, len(claim))
+		for _, v := range claim {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+// mock.go
+package openapi2mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// mockStatusArgument is the reserved tool argument selecting which documented status code to simulate in mock mode.
+const mockStatusArgument = "__mock_status"
+
+// mockStatusHeader is the client request header selecting which documented status code to simulate in mock mode.
+const mockStatusHeader = "X-Mock-Status"
+
+// errUndocumentedMockStatus is returned for a requested status the operation does not document.
+var errUndocumentedMockStatus = errors.New("undocumented " + mockStatusArgument)
+
+// maxMockDepth bounds schema recursion when synthesizing mock data.
+const maxMockDepth = 8
+
+// requestedMockStatus returns the status code requested via the reserved argument or header, if any.
+func requestedMockStatus(args map[string]any, req *http.Request) string {
+	switch v := args[mockStatusArgument].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.Itoa(int(v))
+	case int:
+		return strconv.Itoa(v)
+	}
+	return strings.TrimSpace(req.Header.Get(mockStatusHeader))
+}
+
+// mockResponse builds a simulated upstream response for an operation without using the network.
+// The body comes from the documented response example or examples, or is synthesized from the response schema.
+// If status is empty, the lowest documented 2xx response is used.
+func mockResponse(op OpenAPIOperation, req *http.Request, status string) (*http.Response, error) {
+	code, ref, err := selectMockResponse(op, status)
+	if err != nil {
+		return nil, err
+	}
+
+	header := http.Header{}
+	header.Set("X-Mock-Response", "true")
+	var body []byte
+	if ref != nil && ref.Value != nil && len(ref.Value.Content) > 0 {
+		contentType, mt := selectMockMediaType(ref.Value.Content)
+		header.Set("Content-Type", contentType)
+		body, err = mockBody(contentType, mt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// selectMockResponse picks the documented response to simulate and the status code to return.
+// A requested status that is not documented falls back to the "default" response, if any.
+func selectMockResponse(op OpenAPIOperation, status string) (int, *openapi3.ResponseRef, error) {
+	var documented []string
+	if op.Responses != nil {
+		for code := range op.Responses.Map() {
+			documented = append(documented, code)
+		}
+	}
+	sort.Strings(documented)
+
+	if status != "" {
+		listed := strings.Join(documented, ", ")
+		if listed == "" {
+			listed = "none"
+		}
+		code, err := strconv.Atoi(status)
+		if err != nil || code < 100 || code > 599 {
+			return 0, nil, fmt.Errorf("%w: %q is not an HTTP status code (documented: %s)", errUndocumentedMockStatus, status, listed)
+		}
+		if op.Responses != nil {
+			if ref := op.Responses.Value(status); ref != nil {
+				return code, ref, nil
+			}
+			// Range responses such as "4XX"
+			if ref := op.Responses.Value(status[:1] + "XX"); ref != nil {
+				return code, ref, nil
+			}
+			if ref := op.Responses.Default(); ref != nil {
+				return code, ref, nil
+			}
+		}
+		return 0, nil, fmt.Errorf("%w: status %s is not documented for operation %s (documented: %s)", errUndocumentedMockStatus, status, op.OperationID, listed)
+	}
+
+	for _, c := range documented {
+		if code, err := strconv.Atoi(c); err == nil && code >= 200 && code < 300 {
+			return code, op.Responses.Value(c), nil
+		}
+	}
+	if op.Responses != nil {
+		if ref := op.Responses.Value("2XX"); ref != nil {
+			return http.StatusOK, ref, nil
+		}
+		if ref := op.Responses.Default(); ref != nil {
+			return http.StatusOK, ref, nil
+		}
+	}
+	return http.StatusOK, nil, nil
+}
+
+// selectMockMediaType prefers JSON content, then falls back to the first media type in name order.
+func selectMockMediaType(content openapi3.Content) (string, *openapi3.MediaType) {
+	for _, ct := range []string{"application/json", "application/vnd.api+json"} {
+		if mt := getContentByType(content, ct); mt != nil {
+			return ct, mt
+		}
+	}
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Strings(types)
+	return types[0], content[types[0]]
+}
+
+// mockBody renders the example or synthesized value for a media type.
+func mockBody(contentType string, mt *openapi3.MediaType) ([]byte, error) {
+	if mt == nil {
+		return nil, nil
+	}
+	value, ok := mediaTypeExample(mt)
+	if !ok && mt.Schema != nil && mt.Schema.Value != nil {
+		value, ok = exampleFromSchema(mt.Schema.Value, 0), true
+	}
+	if !ok || value == nil {
+		return nil, nil
+	}
+	if s, isString := value.(string); isString && !strings.Contains(contentType, "json") {
+		return []byte(s), nil
+	}
+	return json.Marshal(value)
+}
+
+// mediaTypeExample returns the media type's example, or its first named example in name order.
+func mediaTypeExample(mt *openapi3.MediaType) (any, bool) {
+	if mt.Example != nil {
+		return mt.Example, true
+	}
+	names := make([]string, 0, len(mt.Examples))
+	for name := range mt.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ex := mt.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			return ex.Value.Value, true
+		}
+	}
+	return nil, false
+}
+
+// exampleFromSchema synthesizes a value matching a schema, preferring its example, default and enum values.
+// String formats are rendered by generateExampleValue so that mock data and tool descriptions agree.
+func exampleFromSchema(s *openapi3.Schema, depth int) any {
+	if s == nil || depth > maxMockDepth {
+		return nil
+	}
+	if s.Example != nil {
+		return s.Example
+	}
+	if s.Default != nil {
+		return s.Default
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+	for _, alternatives := range []openapi3.SchemaRefs{s.OneOf, s.AnyOf} {
+		for _, ref := range alternatives {
+			if ref != nil && ref.Value != nil {
+				return exampleFromSchema(ref.Value, depth+1)
+			}
+		}
+	}
+	typeStr := ""
+	if s.Type != nil && len(*s.Type) > 0 {
+		typeStr = (*s.Type)[0]
+	} else if len(s.Properties) > 0 {
+		typeStr = "object"
+	} else if s.Items != nil {
+		typeStr = "array"
+	} else if len(s.AllOf) > 0 {
+		typeStr = "object"
+	}
+
+	switch typeStr {
+	case "object":
+		obj := map[string]any{}
+		for _, ref := range s.AllOf {
+			if ref != nil && ref.Value != nil {
+				if part, ok := exampleFromSchema(ref.Value, depth+1).(map[string]any); ok {
+					for k, v := range part {
+						obj[k] = v
+					}
+				}
+			}
+		}
+		for name, ref := range s.Properties {
+			if ref != nil && ref.Value != nil && !ref.Value.WriteOnly {
+				obj[name] = exampleFromSchema(ref.Value, depth+1)
+			}
+		}
+		return obj
+	case "array":
+		if s.Items == nil || s.Items.Value == nil {
+			return []any{}
+		}
+		count := 1
+		if s.MinItems > 1 {
+			count = int(s.MinItems)
+		}
+		items := make([]any, count)
+		for i := range items {
+			items[i] = exampleFromSchema(s.Items.Value, depth+1)
+		}
+		return items
+	case "integer":
+		if s.Min != nil {
+			return int64(*s.Min)
+		}
+		return generateExampleValue(map[string]any{"type": "integer"})
+	case "number":
+		if s.Min != nil {
+			return *s.Min
+		}
+		return generateExampleValue(map[string]any{"type": "number"})
+	case "string":
+		return generateExampleValue(map[string]any{"type": "string", "format": s.Format})
+	case "boolean":
+		return generateExampleValue(map[string]any{"type": "boolean"})
+	}
+	return nil
+}
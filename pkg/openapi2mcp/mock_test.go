@@ -0,0 +1,92 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+)
+
+const mockTestSpec = `
+openapi: 3.0.0
+info: {title: Pets, version: "1.0"}
+servers: [{url: "http://127.0.0.1:1"}]
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200":
+          description: A pet
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id: {type: integer, minimum: 1}
+                  name: {type: string}
+                  status: {type: string, enum: [available, sold]}
+                  owner: {type: string, format: email}
+                  tags: {type: array, items: {type: string, format: uuid}}
+        "404":
+          description: Not found
+          content:
+            application/json:
+              examples:
+                missing: {value: {error: "pet not found"}}
+`
+
+func callMockTool(t *testing.T, args string) mcp.CallToolResult {
+	t.Helper()
+	return callTool(t, newTestServer(t, mockTestSpec, &ToolGenOptions{Mock: true}), nil, "getPet", args)
+}
+
+func TestMockModeSynthesizesFromSchema(t *testing.T) {
+	result := callMockTool(t, `{"id":1}`)
+	if result.IsError {
+		t.Fatalf("unexpected error result: %+v", result)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	var body map[string]any
+	if err := json.Unmarshal([]byte(text[strings.Index(text, "{"):]), &body); err != nil {
+		t.Fatalf("mock body is not JSON: %v\n%s", err, text)
+	}
+	if body["status"] != "available" {
+		t.Errorf("expected the first enum value, got %v", body["status"])
+	}
+	if body["owner"] != "user@example.com" {
+		t.Errorf("expected an email-formatted value, got %v", body["owner"])
+	}
+	if body["id"] != float64(1) {
+		t.Errorf("expected the minimum as the integer value, got %v", body["id"])
+	}
+	if tags, _ := body["tags"].([]any); len(tags) != 1 || tags[0] != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("expected an array of UUIDs, got %v", body["tags"])
+	}
+}
+
+func TestMockModeSelectsStatus(t *testing.T) {
+	result := callMockTool(t, `{"id":1,"__mock_status":"404"}`)
+	if !result.IsError {
+		t.Fatalf("expected an error result for a simulated 404")
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "pet not found") {
+		t.Errorf("expected the documented 404 example, got: %s", text)
+	}
+
+	if _, _, err := selectMockResponse(OpenAPIOperation{OperationID: "getPet", Responses: &openapi3.Responses{}}, "418"); err == nil {
+		t.Errorf("expected an error for an undocumented status")
+	}
+
+	// An undocumented status is a tool error the model can correct, listing the documented ones
+	for _, status := range []string{`"418"`, `"teapot"`} {
+		result = callMockTool(t, `{"id":1,"__mock_status":`+status+`}`)
+		if text := result.Content[0].(mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "documented: 200, 404") {
+			t.Errorf("%s: expected a tool error listing the documented statuses, got: %s", status, text)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+// oauth2.go
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	mcpserver "github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// OAuth2Config configures how tokens are obtained for oauth2 security schemes: the client credentials
+// grant for schemes that declare a clientCredentials flow, and the credentials stored by
+// `openapi-mcp auth login` for the others. Unset fields fall back to the OAUTH_CLIENT_ID,
+// OAUTH_CLIENT_SECRET, OAUTH_TOKEN_URL, OAUTH_SCOPES, OAUTH_TENANT, OAUTH_TENANTS and OAUTH_CREDENTIAL_FILE
+// environment variables. When no token can be obtained, oauth2 schemes use BEARER_TOKEN as before.
+type OAuth2Config struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string   // overrides the scheme's tokenUrl; "{tenant}" is replaced with the tenant
+	Scopes       []string // overrides the scopes required by each operation
+	// Tenant is the default tenant, or "identity:<claim>" for the subject ("identity:subject") or JWT claim
+	// of the authenticated client (see IdentityFromContext)
+	Tenant string
+	// Tenants are the tenants clients may select with the X-MCP-Tenant header; other values are rejected,
+	// and so is the header when Tenants is empty or Tenant comes from the identity
+	Tenants []string
+	// HTTPClient is used for token requests (http.DefaultClient if nil). It is deliberately separate from
+	// ToolGenOptions.HTTPClient so that tokens never end up in HAR recordings.
+	HTTPClient *http.Client
+	// RefreshBefore is how long before expiry a cached token is replaced (default 30s)
+	RefreshBefore time.Duration
+	// CredentialFile holds the tokens stored by `openapi-mcp auth login`, used for schemes without a
+	// clientCredentials flow (OAUTH_CREDENTIAL_FILE, default DefaultCredentialFilePath())
+	CredentialFile string
+}
+
+// oauth2TenantHeader selects the tenant for a session. It is never forwarded to the upstream API.
+const oauth2TenantHeader = "X-Mcp-Tenant"
+
+const defaultOAuth2RefreshBefore = 30 * time.Second
+
+// oauth2Client obtains and caches client credentials tokens, per security scheme, scope set and tenant.
+type oauth2Client struct {
+	config OAuth2Config
+
+	mu     sync.Mutex
+	tokens map[string]*oauth2Token
+}
+
+// oauth2Token is a cache entry. Its mutex is held while a token is fetched, so that concurrent calls
+// needing the same token wait for a single request to the token endpoint.
+type oauth2Token struct {
+	mu          sync.Mutex
+	accessToken string
+	expiry      time.Time // zero if the token endpoint did not return expires_in
+	rejected    string    // last token the API answered 401 to, never reused
+}
+
+func newOAuth2Client(config *OAuth2Config) *oauth2Client {
+	c := &oauth2Client{tokens: map[string]*oauth2Token{}}
+	if config != nil {
+		c.config = *config
+	}
+	return c
+}
+
+// resolvedConfig returns the configuration with environment variable fallbacks applied.
+func (c *oauth2Client) resolvedConfig() OAuth2Config {
+	cfg := c.config
+	if cfg.ClientID == "" {
+		cfg.ClientID = os.Getenv("OAUTH_CLIENT_ID")
+	}
+	if cfg.ClientSecret == "" {
+		cfg.ClientSecret = os.Getenv("OAUTH_CLIENT_SECRET")
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = os.Getenv("OAUTH_TOKEN_URL")
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = strings.FieldsFunc(os.Getenv("OAUTH_SCOPES"), func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	if cfg.Tenant == "" {
+		cfg.Tenant = os.Getenv("OAUTH_TENANT")
+	}
+	if len(cfg.Tenants) == 0 {
+		cfg.Tenants = strings.FieldsFunc(os.Getenv("OAUTH_TENANTS"), func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	if cfg.RefreshBefore == 0 {
+		cfg.RefreshBefore = defaultOAuth2RefreshBefore
+	}
+	if cfg.CredentialFile == "" {
+		cfg.CredentialFile = os.Getenv("OAUTH_CREDENTIAL_FILE")
+	}
+	if cfg.CredentialFile == "" {
+		cfg.CredentialFile = DefaultCredentialFilePath()
+	}
+	return cfg
+}
+
+// token returns an access token for a security scheme and the scopes an operation requires,
+// along with its cache key. Schemes with a clientCredentials flow use the configured client;
+// other flows use the credential stored by `openapi-mcp auth login`, refreshed as needed.
+// It returns an empty token when neither is available, so that the caller can fall back to BEARER_TOKEN.
+func (c *oauth2Client) token(ctx context.Context, schemeName string, flows *openapi3.OAuthFlows, requiredScopes []string) (string, string, error) {
+	if c == nil || flows == nil {
+		return "", "", nil
+	}
+	cfg := c.resolvedConfig()
+	if flows.ClientCredentials != nil && cfg.ClientID != "" {
+		return c.clientCredentialsToken(ctx, cfg, schemeName, flows.ClientCredentials, requiredScopes)
+	}
+	if tokenURL := userFlowTokenURL(flows); tokenURL != "" && cfg.CredentialFile != "" {
+		return c.userToken(ctx, cfg, schemeName, tokenURL)
+	}
+	return "", "", nil
+}
+
+// entry returns the cache entry for a key, creating it if needed.
+func (c *oauth2Client) entry(key string) *oauth2Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.tokens[key]
+	if !ok {
+		entry = &oauth2Token{}
+		c.tokens[key] = entry
+	}
+	return entry
+}
+
+// fresh reports whether a token is usable for at least refreshBefore.
+func fresh(accessToken string, expiry time.Time, refreshBefore time.Duration) bool {
+	return accessToken != "" && (expiry.IsZero() || time.Now().Add(refreshBefore).Before(expiry))
+}
+
+// tenant returns the tenant tokens are requested for: the one selected with the X-MCP-Tenant header
+// if it is allowed, else the configured or identity's tenant. The server's client credentials must not
+// mint tokens for a tenant merely because a client asked for it.
+func (cfg OAuth2Config) tenant(ctx context.Context) (string, error) {
+	claim, fromIdentity := strings.CutPrefix(cfg.Tenant, identityValuePrefix)
+	tenant := cfg.Tenant
+	if fromIdentity {
+		var ok bool
+		if tenant, ok = identityValue(IdentityFromContext(ctx), claim); !ok {
+			return "", fmt.Errorf("the oauth2 tenant is taken from the %q claim of the authenticated client, which this client does not have", claim)
+		}
+	}
+	clientHeaders, _ := ctx.Value(mcpserver.ClientHeadersKey{}).(map[string]string)
+	for key, value := range clientHeaders {
+		if !strings.EqualFold(key, oauth2TenantHeader) || value == "" || value == tenant {
+			continue
+		}
+		if fromIdentity || !slices.Contains(cfg.Tenants, value) {
+			return "", fmt.Errorf("tenant %q selected with the %s header is not allowed", value, oauth2TenantHeader)
+		}
+		tenant = value
+	}
+	return tenant, nil
+}
+
+func (c *oauth2Client) clientCredentialsToken(ctx context.Context, cfg OAuth2Config, schemeName string, flow *openapi3.OAuthFlow, requiredScopes []string) (string, string, error) {
+	tenant, err := cfg.tenant(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	tokenURL := cfg.TokenURL
+	if tokenURL == "" {
+		tokenURL = flow.TokenURL
+	}
+	if tokenURL == "" {
+		return "", "", fmt.Errorf("oauth2 scheme %q has no tokenUrl", schemeName)
+	}
+	tokenURL = strings.ReplaceAll(tokenURL, "{tenant}", url.PathEscape(tenant))
+	scopes := cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = requiredScopes
+	}
+	scopes = append([]string(nil), scopes...)
+	sort.Strings(scopes)
+
+	key := schemeName + "\x00" + strings.Join(scopes, " ") + "\x00" + tenant
+	entry := c.entry(key)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if fresh(entry.accessToken, entry.expiry, cfg.RefreshBefore) {
+		return entry.accessToken, key, nil
+	}
+	if isPreview(ctx) {
+		return "", "", errCredentialNotResolved
+	}
+	accessToken, expiresIn, err := fetchClientCredentialsToken(ctx, cfg, tokenURL, scopes)
+	if err != nil {
+		return "", "", fmt.Errorf("oauth2 scheme %q: %w", schemeName, err)
+	}
+	entry.accessToken = accessToken
+	entry.expiry = time.Time{}
+	if expiresIn > 0 {
+		entry.expiry = time.Now().Add(expiresIn)
+	}
+	return accessToken, key, nil
+}
+
+// userToken returns the access token stored for a token endpoint by `auth login`, using the
+// refresh token to replace it when it is about to expire or was rejected by the API.
+// Refreshed credentials are written back to the credential file.
+func (c *oauth2Client) userToken(ctx context.Context, cfg OAuth2Config, schemeName, tokenURL string) (string, string, error) {
+	key := "login\x00" + tokenURL
+	entry := c.entry(key)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if fresh(entry.accessToken, entry.expiry, cfg.RefreshBefore) {
+		return entry.accessToken, key, nil
+	}
+
+	// The file is re-read, as another process (or a new login) may have refreshed the token
+	store := OpenCredentialFile(cfg.CredentialFile)
+	cred, err := store.Get(tokenURL)
+	if err != nil {
+		return "", "", err
+	}
+	if cred == nil {
+		return "", "", nil
+	}
+	if cred.AccessToken == entry.rejected || !fresh(cred.AccessToken, cred.Expiry, cfg.RefreshBefore) {
+		if cred.RefreshToken == "" {
+			return "", "", fmt.Errorf("oauth2 scheme %q: the stored token has expired; run 'openapi-mcp auth login' again", schemeName)
+		}
+		if isPreview(ctx) {
+			return "", "", errCredentialNotResolved
+		}
+		clientSecret := ""
+		if cred.ClientID == cfg.ClientID {
+			clientSecret = cfg.ClientSecret
+		}
+		form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {cred.RefreshToken}}
+		resp, err := requestOAuth2Token(ctx, cfg.HTTPClient, cred.refreshURL(), form, cred.ClientID, clientSecret)
+		if err != nil {
+			return "", "", fmt.Errorf("oauth2 scheme %q: refreshing the stored token: %w (run 'openapi-mcp auth login' again if it was revoked)", schemeName, err)
+		}
+		cred.update(resp)
+		if err := store.Put(cred); err != nil {
+			return "", "", err
+		}
+	}
+	entry.accessToken, entry.expiry, entry.rejected = cred.AccessToken, cred.Expiry, ""
+	return cred.AccessToken, key, nil
+}
+
+// invalidate drops a cached token after the API rejected it, unless it was already replaced.
+func (c *oauth2Client) invalidate(key, accessToken string) {
+	c.mu.Lock()
+	entry := c.tokens[key]
+	c.mu.Unlock()
+	if entry == nil {
+		return
+	}
+	entry.mu.Lock()
+	if entry.accessToken == accessToken {
+		entry.accessToken = ""
+		entry.rejected = accessToken
+	}
+	entry.mu.Unlock()
+}
+
+// fetchClientCredentialsToken requests a token from the token endpoint (RFC 6749 section 4.4).
+func fetchClientCredentialsToken(ctx context.Context, cfg OAuth2Config, tokenURL string, scopes []string) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+	resp, err := requestOAuth2Token(ctx, cfg.HTTPClient, tokenURL, form, cfg.ClientID, cfg.ClientSecret)
+	if err != nil {
+		return "", 0, err
+	}
+	return resp.AccessToken, resp.ExpiresIn, nil
+}
+
+// oauth2TokenResponse is a successful token endpoint response.
+type oauth2TokenResponse struct {
+	AccessToken  string
+	RefreshToken string
+	Scope        string
+	ExpiresIn    time.Duration // zero if not returned
+}
+
+// OAuth2Error is an error response from an OAuth 2.0 endpoint (RFC 6749 section 5.2).
+type OAuth2Error struct {
+	StatusCode  int
+	Code        string // e.g. "invalid_client", "authorization_pending"
+	Description string
+}
+
+func (e *OAuth2Error) Error() string {
+	msg := e.Code
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("token endpoint returned HTTP %d (%s)", e.StatusCode, msg)
+}
+
+// requestOAuth2Token posts a token request. Confidential clients authenticate with HTTP Basic
+// authentication; public clients (without a secret) send their client_id in the form.
+func requestOAuth2Token(ctx context.Context, client *http.Client, tokenURL string, form url.Values, clientID, clientSecret string) (*oauth2TokenResponse, error) {
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+	body, status, err := postOAuth2Form(client, req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+
+	var tokenResp struct {
+		AccessToken      string          `json:"access_token"`
+		TokenType        string          `json:"token_type"`
+		RefreshToken     string          `json:"refresh_token"`
+		Scope            string          `json:"scope"`
+		ExpiresIn        json.RawMessage `json:"expires_in"`
+		Error            string          `json:"error"`
+		ErrorDescription string          `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &tokenResp)
+	if status < 200 || status >= 300 || tokenResp.Error != "" {
+		return nil, &OAuth2Error{StatusCode: status, Code: tokenResp.Error, Description: tokenResp.ErrorDescription}
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("token endpoint response has no access_token")
+	}
+	if tokenResp.TokenType != "" && !strings.EqualFold(tokenResp.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type %q", tokenResp.TokenType)
+	}
+	return &oauth2TokenResponse{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		Scope:        tokenResp.Scope,
+		ExpiresIn:    parseExpiresIn(tokenResp.ExpiresIn),
+	}, nil
+}
+
+// postOAuth2Form sends a request to an OAuth 2.0 endpoint and returns the (size-limited) response body.
+func postOAuth2Form(client *http.Client, req *http.Request) ([]byte, int, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	return body, resp.StatusCode, err
+}
+
+// parseExpiresIn parses expires_in, which is a number but sent as a string by some servers.
+func parseExpiresIn(raw json.RawMessage) time.Duration {
+	var expiresIn float64
+	if err := json.Unmarshal(raw, &expiresIn); err != nil {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			fmt.Sscanf(s, "%g", &expiresIn)
+		}
+	}
+	return time.Duration(expiresIn * float64(time.Second))
+}
@@ -0,0 +1,437 @@
+// oauth2_login.go
+package openapi2mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// OAuth2Credential is a user token obtained by `openapi-mcp auth login`.
+type OAuth2Credential struct {
+	TokenURL     string    `json:"token_url"`
+	RefreshURL   string    `json:"refresh_url,omitempty"`
+	ClientID     string    `json:"client_id"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+	Scopes       []string  `json:"scopes,omitempty"`
+}
+
+// refreshURL returns the endpoint refresh tokens are sent to.
+func (c *OAuth2Credential) refreshURL() string {
+	if c.RefreshURL != "" {
+		return c.RefreshURL
+	}
+	return c.TokenURL
+}
+
+// update stores a token response. Servers that do not rotate refresh tokens omit them,
+// in which case the previous one is kept.
+func (c *OAuth2Credential) update(resp *oauth2TokenResponse) {
+	c.AccessToken = resp.AccessToken
+	if resp.RefreshToken != "" {
+		c.RefreshToken = resp.RefreshToken
+	}
+	c.Expiry = time.Time{}
+	if resp.ExpiresIn > 0 {
+		c.Expiry = time.Now().Add(resp.ExpiresIn)
+	}
+	if resp.Scope != "" {
+		c.Scopes = strings.Fields(resp.Scope)
+	}
+}
+
+// CredentialFile stores OAuth 2.0 credentials, keyed by token endpoint, in a JSON file only
+// readable by the current user.
+type CredentialFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+// DefaultCredentialFilePath returns the default location of the credential file,
+// openapi-mcp/credentials.json in the user's configuration directory.
+func DefaultCredentialFilePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "openapi-mcp", "credentials.json")
+}
+
+// OpenCredentialFile returns the credential file at path. The file is created on the first Put.
+func OpenCredentialFile(path string) *CredentialFile {
+	return &CredentialFile{path: path}
+}
+
+// Path returns the location of the credential file.
+func (f *CredentialFile) Path() string {
+	return f.path
+}
+
+// Get returns the credential stored for a token endpoint, or nil if there is none.
+func (f *CredentialFile) Get(tokenURL string) (*OAuth2Credential, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	creds, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return creds[tokenURL], nil
+}
+
+// Put stores a credential, replacing any other one for the same token endpoint.
+func (f *CredentialFile) Put(cred *OAuth2Credential) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	creds, err := f.load()
+	if err != nil {
+		return err
+	}
+	creds[cred.TokenURL] = cred
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("creating credential directory: %w", err)
+	}
+	// Written to a temporary file first so that a concurrent reader never sees a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".credentials-*.json")
+	if err != nil {
+		return fmt.Errorf("writing credential file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing credential file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing credential file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing credential file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("writing credential file: %w", err)
+	}
+	return nil
+}
+
+func (f *CredentialFile) load() (map[string]*OAuth2Credential, error) {
+	creds := map[string]*OAuth2Credential{}
+	if f.path == "" {
+		return creds, nil
+	}
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading credential file: %w", err)
+	}
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("parsing credential file %s: %w", f.path, err)
+	}
+	return creds, nil
+}
+
+// OAuth2LoginOptions configures an interactive login.
+type OAuth2LoginOptions struct {
+	ClientID     string
+	ClientSecret string   // optional; public clients rely on PKCE alone
+	Scopes       []string // scopes to request (the flow's scopes if empty)
+	// RedirectPort is the loopback port for the authorization code redirect (a random port if 0).
+	// Some authorization servers require the redirect URI to be registered with a fixed port.
+	RedirectPort int
+	HTTPClient   *http.Client
+	// Prompt shows instructions to the user, such as the URL to visit or the device code to enter
+	Prompt func(string)
+	// OpenBrowser opens the authorization URL in the user's browser (optional)
+	OpenBrowser func(string) error
+}
+
+func (o OAuth2LoginOptions) prompt(msg string) {
+	if o.Prompt != nil {
+		o.Prompt(msg)
+	}
+}
+
+func (o OAuth2LoginOptions) scopes(flowScopes map[string]string) []string {
+	if len(o.Scopes) > 0 {
+		return o.Scopes
+	}
+	return sortedKeys(flowScopes)
+}
+
+// LoginAuthorizationCode runs the authorization code flow with PKCE (RFC 7636): the user authorizes
+// in a browser, which is redirected to a listener on the loopback interface (RFC 8252).
+func LoginAuthorizationCode(ctx context.Context, flow *openapi3.OAuthFlow, opts OAuth2LoginOptions) (*OAuth2Credential, error) {
+	if flow == nil || flow.AuthorizationURL == "" || flow.TokenURL == "" {
+		return nil, fmt.Errorf("the authorizationCode flow needs an authorizationUrl and a tokenUrl")
+	}
+	if opts.ClientID == "" {
+		return nil, fmt.Errorf("a client id is required")
+	}
+	verifier := randomToken(32)
+	challenge := sha256.Sum256([]byte(verifier))
+	state := randomToken(16)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", opts.RedirectPort))
+	if err != nil {
+		return nil, fmt.Errorf("starting the redirect listener: %w", err)
+	}
+	redirectURI := fmt.Sprintf("http://%s/callback", listener.Addr().String())
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/callback" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		var result callbackResult
+		switch {
+		case q.Get("state") != state:
+			result.err = fmt.Errorf("authorization response has an invalid state")
+		case q.Get("error") != "":
+			result.err = &OAuth2Error{StatusCode: http.StatusBadRequest, Code: q.Get("error"), Description: q.Get("error_description")}
+		case q.Get("code") == "":
+			result.err = fmt.Errorf("authorization response has no code")
+		default:
+			result.code = q.Get("code")
+		}
+		if result.err != nil {
+			http.Error(w, "Login failed: "+result.err.Error(), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Login successful. You can close this window.")
+		}
+		select {
+		case results <- result:
+		default:
+		}
+	})}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	authURL, err := url.Parse(flow.AuthorizationURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorizationUrl: %w", err)
+	}
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", opts.ClientID)
+	q.Set("redirect_uri", redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	scopes := opts.scopes(flow.Scopes)
+	if len(scopes) > 0 {
+		q.Set("scope", strings.Join(scopes, " "))
+	}
+	authURL.RawQuery = q.Encode()
+
+	// The URL is always shown, as opening a browser can silently fail (e.g. over SSH)
+	opts.prompt("Open this URL in your browser to log in:\n\n  " + authURL.String() + "\n")
+	if opts.OpenBrowser != nil {
+		_ = opts.OpenBrowser(authURL.String())
+	}
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {result.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	resp, err := requestOAuth2Token(ctx, opts.HTTPClient, flow.TokenURL, form, opts.ClientID, opts.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+	cred := &OAuth2Credential{TokenURL: flow.TokenURL, RefreshURL: flow.RefreshURL, ClientID: opts.ClientID, Scopes: scopes}
+	cred.update(resp)
+	return cred, nil
+}
+
+// DeviceAuthorizationFlow is the endpoints of the device authorization grant.
+type DeviceAuthorizationFlow struct {
+	DeviceAuthorizationURL string
+	TokenURL               string
+	RefreshURL             string
+	Scopes                 map[string]string
+}
+
+// DeviceAuthorization returns the device authorization flow of a security scheme, declared as the
+// OpenAPI 3.2 `deviceAuthorization` flow, or as an `x-deviceAuthorizationUrl` extension of the
+// authorizationCode flow. It returns nil if the scheme has neither.
+func DeviceAuthorization(flows *openapi3.OAuthFlows) *DeviceAuthorizationFlow {
+	if flows == nil {
+		return nil
+	}
+	if raw, ok := flows.Extensions["deviceAuthorization"].(map[string]any); ok {
+		flow := &DeviceAuthorizationFlow{Scopes: map[string]string{}}
+		flow.DeviceAuthorizationURL, _ = raw["deviceAuthorizationUrl"].(string)
+		flow.TokenURL, _ = raw["tokenUrl"].(string)
+		flow.RefreshURL, _ = raw["refreshUrl"].(string)
+		if scopes, ok := raw["scopes"].(map[string]any); ok {
+			for scope, desc := range scopes {
+				flow.Scopes[scope], _ = desc.(string)
+			}
+		}
+		if flow.DeviceAuthorizationURL != "" && flow.TokenURL != "" {
+			return flow
+		}
+	}
+	if ac := flows.AuthorizationCode; ac != nil {
+		if deviceURL, ok := ac.Extensions["x-deviceAuthorizationUrl"].(string); ok && deviceURL != "" {
+			return &DeviceAuthorizationFlow{DeviceAuthorizationURL: deviceURL, TokenURL: ac.TokenURL, RefreshURL: ac.RefreshURL, Scopes: ac.Scopes}
+		}
+	}
+	return nil
+}
+
+// userFlowTokenURL returns the token endpoint of a scheme's user-delegated flow, under which
+// `auth login` stores its credential.
+func userFlowTokenURL(flows *openapi3.OAuthFlows) string {
+	if flows.AuthorizationCode != nil && flows.AuthorizationCode.TokenURL != "" {
+		return flows.AuthorizationCode.TokenURL
+	}
+	if device := DeviceAuthorization(flows); device != nil {
+		return device.TokenURL
+	}
+	return ""
+}
+
+// deviceCodeGrantType is the grant type of the device authorization grant (RFC 8628).
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// LoginDeviceCode runs the device authorization grant (RFC 8628): the user enters a code on another
+// device while the token endpoint is polled until they approve or deny the request.
+func LoginDeviceCode(ctx context.Context, flow *DeviceAuthorizationFlow, opts OAuth2LoginOptions) (*OAuth2Credential, error) {
+	if flow == nil || flow.DeviceAuthorizationURL == "" || flow.TokenURL == "" {
+		return nil, fmt.Errorf("the device flow needs a deviceAuthorizationUrl and a tokenUrl")
+	}
+	if opts.ClientID == "" {
+		return nil, fmt.Errorf("a client id is required")
+	}
+	scopes := opts.scopes(flow.Scopes)
+	form := url.Values{"client_id": {opts.ClientID}}
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, flow.DeviceAuthorizationURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if opts.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(opts.ClientID), url.QueryEscape(opts.ClientSecret))
+	}
+	body, status, err := postOAuth2Form(opts.HTTPClient, req)
+	if err != nil {
+		return nil, fmt.Errorf("device authorization request failed: %w", err)
+	}
+	var device struct {
+		DeviceCode              string          `json:"device_code"`
+		UserCode                string          `json:"user_code"`
+		VerificationURI         string          `json:"verification_uri"`
+		VerificationURIComplete string          `json:"verification_uri_complete"`
+		ExpiresIn               json.RawMessage `json:"expires_in"`
+		Interval                json.RawMessage `json:"interval"`
+		Error                   string          `json:"error"`
+		ErrorDescription        string          `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &device)
+	if status < 200 || status >= 300 || device.Error != "" {
+		return nil, &OAuth2Error{StatusCode: status, Code: device.Error, Description: device.ErrorDescription}
+	}
+	if device.DeviceCode == "" || device.VerificationURI == "" {
+		return nil, fmt.Errorf("device authorization response has no device_code or verification_uri")
+	}
+
+	msg := fmt.Sprintf("To log in, visit %s and enter the code: %s", device.VerificationURI, device.UserCode)
+	if device.VerificationURIComplete != "" {
+		msg += fmt.Sprintf("\n(or open %s)", device.VerificationURIComplete)
+	}
+	opts.prompt(msg)
+
+	interval := parseExpiresIn(device.Interval)
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	expiresIn := parseExpiresIn(device.ExpiresIn)
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	deadline := time.Now().Add(expiresIn)
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("the device code expired before the login was approved")
+		}
+		form := url.Values{"grant_type": {deviceCodeGrantType}, "device_code": {device.DeviceCode}}
+		resp, err := requestOAuth2Token(ctx, opts.HTTPClient, flow.TokenURL, form, opts.ClientID, opts.ClientSecret)
+		var oauthErr *OAuth2Error
+		if errors.As(err, &oauthErr) {
+			switch oauthErr.Code {
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += 5 * time.Second
+				continue
+			case "access_denied":
+				return nil, fmt.Errorf("the login was denied")
+			case "expired_token":
+				return nil, fmt.Errorf("the device code expired before the login was approved")
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		cred := &OAuth2Credential{TokenURL: flow.TokenURL, RefreshURL: flow.RefreshURL, ClientID: opts.ClientID, Scopes: scopes}
+		cred.update(resp)
+		return cred, nil
+	}
+}
+
+// randomToken returns n random bytes, base64url-encoded.
+func randomToken(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
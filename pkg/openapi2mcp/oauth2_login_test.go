@@ -0,0 +1,178 @@
+package openapi2mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestLoginAuthorizationCode(t *testing.T) {
+	var challenge string
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("grant_type") != "authorization_code" || r.FormValue("code") != "the-code" ||
+			r.FormValue("client_id") != "cli" || base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"at-1","refresh_token":"rt-1","token_type":"Bearer","expires_in":3600}`))
+	}))
+	defer tokens.Close()
+
+	flow := &openapi3.OAuthFlow{AuthorizationURL: "https://auth.example.com/authorize", TokenURL: tokens.URL, Scopes: map[string]string{"read": ""}}
+	// The "browser" approves immediately, following the redirect to the loopback listener
+	browser := func(authURL string) error {
+		u, _ := url.Parse(authURL)
+		q := u.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("scope") != "read" {
+			t.Errorf("unexpected authorization request: %s", authURL)
+		}
+		challenge = q.Get("code_challenge")
+		go http.Get(q.Get("redirect_uri") + "?code=the-code&state=" + url.QueryEscape(q.Get("state")))
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cred, err := LoginAuthorizationCode(ctx, flow, OAuth2LoginOptions{ClientID: "cli", OpenBrowser: browser})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if cred.AccessToken != "at-1" || cred.RefreshToken != "rt-1" || cred.TokenURL != tokens.URL || cred.Expiry.IsZero() {
+		t.Errorf("unexpected credential: %+v", cred)
+	}
+
+	// A redirect with the wrong state is rejected
+	forged := func(authURL string) error {
+		u, _ := url.Parse(authURL)
+		go http.Get(u.Query().Get("redirect_uri") + "?code=the-code&state=forged")
+		return nil
+	}
+	if _, err := LoginAuthorizationCode(ctx, flow, OAuth2LoginOptions{ClientID: "cli", OpenBrowser: forged}); err == nil {
+		t.Errorf("expected a state mismatch to fail the login")
+	}
+}
+
+func TestLoginDeviceCode(t *testing.T) {
+	var mu sync.Mutex
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"device_code":"dc","user_code":"ABCD-EFGH","verification_uri":"https://example.com/device","expires_in":60,"interval":0.01}`))
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.FormValue("grant_type") != deviceCodeGrantType || r.FormValue("device_code") != "dc" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		polls++
+		if polls < 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"authorization_pending"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"at-device","refresh_token":"rt-device","token_type":"Bearer"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Device, version: "1.0"}
+paths: {}
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        deviceAuthorization:
+          deviceAuthorizationUrl: ` + srv.URL + `/device
+          tokenUrl: ` + srv.URL + `/token
+          scopes: {}
+`))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	flow := DeviceAuthorization(doc.Components.SecuritySchemes["oauth"].Value.Flows)
+	if flow == nil {
+		t.Fatalf("the deviceAuthorization flow was not found")
+	}
+	var prompt string
+	cred, err := LoginDeviceCode(context.Background(), flow, OAuth2LoginOptions{ClientID: "cli", Prompt: func(msg string) { prompt = msg }})
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if cred.AccessToken != "at-device" || cred.RefreshToken != "rt-device" || polls != 3 {
+		t.Errorf("unexpected credential after %d polls: %+v", polls, cred)
+	}
+	if !strings.Contains(prompt, "ABCD-EFGH") || !strings.Contains(prompt, "https://example.com/device") {
+		t.Errorf("the user code and verification URI should be shown: %q", prompt)
+	}
+}
+
+func TestOAuth2StoredCredentialRefresh(t *testing.T) {
+	var mu sync.Mutex
+	refreshes := 0
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "rt-1" || r.FormValue("client_id") != "cli" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		refreshes++
+		// The refresh token is not rotated, so the stored one must be kept
+		fmt.Fprintf(w, `{"access_token":"at-%d","token_type":"Bearer","expires_in":3600}`, refreshes+1)
+	}))
+	defer tokens.Close()
+
+	path := filepath.Join(t.TempDir(), "openapi-mcp", "credentials.json")
+	store := OpenCredentialFile(path)
+	if err := store.Put(&OAuth2Credential{TokenURL: tokens.URL, ClientID: "cli", AccessToken: "at-1", RefreshToken: "rt-1", Expiry: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("storing credential: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("the credential file should only be readable by its owner: %v %v", info.Mode(), err)
+	}
+
+	flows := &openapi3.OAuthFlows{AuthorizationCode: &openapi3.OAuthFlow{AuthorizationURL: "https://auth.example.com", TokenURL: tokens.URL}}
+	c := newOAuth2Client(&OAuth2Config{CredentialFile: path})
+	token, key, err := c.token(context.Background(), "oauth", flows, nil)
+	if err != nil || token != "at-2" {
+		t.Fatalf("expected the expired token to be refreshed, got %q: %v", token, err)
+	}
+	if stored, _ := store.Get(tokens.URL); stored.AccessToken != "at-2" || stored.RefreshToken != "rt-1" {
+		t.Errorf("the refreshed token should be saved with the previous refresh token: %+v", stored)
+	}
+	if again, _, _ := c.token(context.Background(), "oauth", flows, nil); again != "at-2" || refreshes != 1 {
+		t.Errorf("a fresh token should be reused, got %q after %d refreshes", again, refreshes)
+	}
+
+	// A token rejected by the API is refreshed even though it has not expired
+	c.invalidate(key, token)
+	if next, _, _ := c.token(context.Background(), "oauth", flows, nil); next != "at-3" {
+		t.Errorf("expected a rejected token to be refreshed, got %q", next)
+	}
+
+	// Without a stored credential, BEARER_TOKEN is used
+	other := &openapi3.OAuthFlows{AuthorizationCode: &openapi3.OAuthFlow{TokenURL: "https://other.example.com/token"}}
+	if token, _, err := c.token(context.Background(), "other", other, nil); token != "" || err != nil {
+		t.Errorf("expected no token without a login, got %q %v", token, err)
+	}
+}
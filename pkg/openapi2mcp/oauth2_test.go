@@ -0,0 +1,192 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// fakeTokenServer is a client credentials token endpoint issuing numbered tokens.
+type fakeTokenServer struct {
+	mu        sync.Mutex
+	issued    int
+	expiresIn int
+	requests  []string // "tenant scope" of each token request
+}
+
+func (f *fakeTokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, secret, _ := r.BasicAuth()
+	if id != "client" || secret != "s3cret" || r.FormValue("grant_type") != "client_credentials" {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"invalid_client"}`))
+		return
+	}
+	f.mu.Lock()
+	f.issued++
+	token := fmt.Sprintf("token-%d", f.issued)
+	f.requests = append(f.requests, strings.TrimPrefix(r.URL.Path, "/")+" "+r.FormValue("scope"))
+	f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"access_token": token, "token_type": "Bearer", "expires_in": f.expiresIn})
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	tokens := &fakeTokenServer{expiresIn: 3600}
+	tokenServer := httptest.NewServer(tokens)
+	defer tokenServer.Close()
+
+	var mu sync.Mutex
+	var seen []string
+	revoked := map[string]bool{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth := r.Header.Get("Authorization")
+		seen = append(seen, auth)
+		if revoked[auth] {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	os.Setenv("OPENAPI_BASE_URL", api.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: OAuth, version: "1.0"}
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: ` + tokenServer.URL + `/{tenant}
+          scopes: {read: Read, write: Write}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      security: [{oauth: [read]}]
+      responses:
+        "200": {description: OK}
+  /reports:
+    get:
+      operationId: listReports
+      security: [{oauth: [write, read]}]
+      responses:
+        "200": {description: OK}
+`))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{
+		OAuth2: &OAuth2Config{ClientID: "client", ClientSecret: "s3cret", Tenant: "acme", Tenants: []string{"globex"}},
+	})
+	call := func(tool string, headers map[string]string) mcp.CallToolResult {
+		t.Helper()
+		params := map[string]any{"name": tool, "arguments": map[string]any{}}
+		if headers != nil {
+			params["_meta"] = map[string]any{"headers": headers}
+		}
+		msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": params})
+		resp, ok := srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("%s: call failed", tool)
+		}
+		return resp.Result.(mcp.CallToolResult)
+	}
+
+	call("listItems", nil)
+	call("listItems", nil)
+	call("listReports", nil)
+	call("listItems", map[string]string{"X-MCP-Tenant": "globex"})
+	if got := strings.Join(tokens.requests, ","); got != "acme read,acme read write,globex read" {
+		t.Errorf("tokens should be cached per scope set and tenant, got requests: %s", got)
+	}
+	if seen[0] != "Bearer token-1" || seen[1] != "Bearer token-1" || seen[2] != "Bearer token-2" || seen[3] != "Bearer token-3" {
+		t.Errorf("unexpected Authorization headers: %v", seen)
+	}
+
+	// A revoked token is replaced and the call retried once
+	mu.Lock()
+	revoked["Bearer token-1"] = true
+	mu.Unlock()
+	if result := call("listItems", nil); result.IsError {
+		t.Errorf("expected the retry with a new token to succeed: %+v", result.Content)
+	}
+	if last := seen[len(seen)-1]; last != "Bearer token-4" {
+		t.Errorf("expected the retry to use a new token, got %s", last)
+	}
+}
+
+func TestOAuth2Tenant(t *testing.T) {
+	headers := func(tenant string) context.Context {
+		return context.WithValue(context.Background(), server.ClientHeadersKey{}, map[string]string{"X-Mcp-Tenant": tenant})
+	}
+	alice := context.WithValue(headers("globex"), identityKey{}, &Identity{Subject: "alice", Claims: map[string]any{"tenant": "initech"}})
+	for _, tc := range []struct {
+		name   string
+		cfg    OAuth2Config
+		ctx    context.Context
+		want   string
+		errors bool
+	}{
+		{"default", OAuth2Config{Tenant: "acme"}, context.Background(), "acme", false},
+		{"allowed", OAuth2Config{Tenant: "acme", Tenants: []string{"globex"}}, headers("globex"), "globex", false},
+		{"not allowed", OAuth2Config{Tenant: "acme", Tenants: []string{"globex"}}, headers("initech"), "", true},
+		{"no allow-list", OAuth2Config{Tenant: "acme"}, headers("globex"), "", true},
+		{"identity", OAuth2Config{Tenant: "identity:tenant", Tenants: []string{"globex"}}, alice, "", true},
+		{"identity without header", OAuth2Config{Tenant: "identity:tenant"}, context.WithValue(context.Background(), identityKey{}, &Identity{Claims: map[string]any{"tenant": "initech"}}), "initech", false},
+		{"identity without claim", OAuth2Config{Tenant: "identity:tenant"}, context.Background(), "", true},
+	} {
+		got, err := tc.cfg.tenant(tc.ctx)
+		if got != tc.want || (err != nil) != tc.errors {
+			t.Errorf("%s: got %q, %v", tc.name, got, err)
+		}
+	}
+}
+
+func TestOAuth2TokenRefresh(t *testing.T) {
+	tokens := &fakeTokenServer{expiresIn: 10}
+	tokenServer := httptest.NewServer(tokens)
+	defer tokenServer.Close()
+	flows := &openapi3.OAuthFlows{ClientCredentials: &openapi3.OAuthFlow{TokenURL: tokenServer.URL + "/t"}}
+
+	c := newOAuth2Client(&OAuth2Config{ClientID: "client", ClientSecret: "s3cret", RefreshBefore: time.Second})
+	first, _, err := c.token(context.Background(), "oauth", flows, nil)
+	if err != nil || first != "token-1" {
+		t.Fatalf("unexpected token %q: %v", first, err)
+	}
+	if again, _, _ := c.token(context.Background(), "oauth", flows, nil); again != first {
+		t.Errorf("token should be cached until close to expiry, got %q", again)
+	}
+	// Tokens within RefreshBefore of expiry are replaced
+	c = newOAuth2Client(&OAuth2Config{ClientID: "client", ClientSecret: "s3cret", RefreshBefore: time.Minute})
+	c.token(context.Background(), "oauth", flows, nil)
+	if next, _, _ := c.token(context.Background(), "oauth", flows, nil); next != "token-3" {
+		t.Errorf("token expiring within RefreshBefore should be refreshed, got %q", next)
+	}
+
+	c = newOAuth2Client(&OAuth2Config{ClientID: "client", ClientSecret: "wrong"})
+	if _, _, err := c.token(context.Background(), "oauth", flows, nil); err == nil || !strings.Contains(err.Error(), "invalid_client") {
+		t.Errorf("expected the token endpoint error to be reported, got %v", err)
+	}
+	if token, _, err := newOAuth2Client(nil).token(context.Background(), "oauth", flows, nil); token != "" || err != nil {
+		t.Errorf("without a client id, no token should be requested: %q %v", token, err)
+	}
+}
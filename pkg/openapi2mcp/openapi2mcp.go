@@ -3,11 +3,15 @@
 package openapi2mcp
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
 // OpenAPIOperation describes a single OpenAPI operation to be mapped to an MCP tool.
-// It includes the operation's ID, summary, description, HTTP path/method, parameters, request body, and tags.
+// It includes the operation's ID, summary, description, HTTP path/method, parameters, request body, responses, and tags,
+// with x-mcp-* vendor extensions already applied (see ExtractOpenAPIOperations).
 type OpenAPIOperation struct {
 	OperationID string
 	Summary     string
@@ -16,9 +20,16 @@ type OpenAPIOperation struct {
 	Method      string
 	Parameters  openapi3.Parameters
 	RequestBody *openapi3.RequestBodyRef
+	Responses   *openapi3.Responses
 	Tags        []string
 	Servers     openapi3.Servers
 	Security    openapi3.SecurityRequirements
+	Dangerous   *bool            // from x-mcp-dangerous; nil means PUT, POST and DELETE are dangerous
+	Timeout     time.Duration    // from x-mcp-timeout; zero means no per-operation timeout
+	Examples    []map[string]any // from x-mcp-examples: example tool arguments
+	Async       *AsyncOperation  // from x-mcp-async; nil means 202 Accepted responses are returned as is
+	Stream      *StreamOptions   // from x-mcp-stream: limits on relaying streaming responses
+	Pagination  *Pagination      // from x-mcp-pagination; nil means only the first page of a list is returned
 }
 
 // ToolGenOptions controls tool generation and output for OpenAPI-MCP conversion.
@@ -30,6 +41,25 @@ type OpenAPIOperation struct {
 // Version: version string to embed in tool annotations
 // PostProcessSchema: optional hook to modify each tool's input schema before registration/output
 // ConfirmDangerousActions: if true (default), require confirmation for PUT/POST/DELETE tools
+// MaxInlineBinarySize: largest binary response (in bytes) embedded in a tool result; larger ones are linked as temporary resources (default 1 MiB)
+// Mock: if true, skip the network and answer tool calls from the documented response examples or schemas
+// HTTPClient: client used for upstream API calls (http.DefaultClient if nil); set its Transport to record or replay traffic
+// RequestInterceptors: run in order on each upstream request before it is sent
+// ResponseInterceptors: run in reverse order on each upstream response before the tool result is built
+// ResultInterceptors: run in reverse order on each tool result
+// ParameterValues: pinned and defaulted parameter values, injected at call time and hidden from the model
+// Spec: identifies the spec for ParameterValues scoped to a spec (the CLI uses the --mount base path)
+// CoerceArguments: if true, convert mistyped arguments (e.g. "5" for an integer) to the schema's types before validation
+// OAuth2: client credentials for oauth2 security schemes (falls back to OAUTH_* environment variables if nil)
+// Credentials: credentials by security scheme name (literal values, or env:<VAR>, file:<path> and exec:<helper>, see ParseCredentialSource), taking precedence over API_KEY, BEARER_TOKEN and BASIC_AUTH
+// CredentialSources: credential sources by security scheme name, overriding Credentials
+// CredentialTTL: how long file and helper credentials are cached (DefaultCredentialTTL if 0)
+// Signers: request signers by security scheme name, overriding x-mcp-auth extensions (see RegisterSigner)
+// Policy: restricts the tools each authenticated client may list and call (see ToolPolicy)
+// Async: waits for asynchronous operations, by operationId ("*" for all), overriding the non-zero fields of x-mcp-async
+// Stream: limits on relaying streaming responses, by operationId ("*" for all), overriding the non-zero fields of x-mcp-stream
+// Pagination: fetches the following pages of GET list operations, by operationId ("*" for all), overriding the non-zero fields of x-mcp-pagination
+// ServerVariables: values of the server URL variables (e.g. {region}) by name, overriding their defaults
 //
 //	func(toolName string, schema map[string]any) map[string]any
 type ToolGenOptions struct {
@@ -40,4 +70,23 @@ type ToolGenOptions struct {
 	Version                 string
 	PostProcessSchema       func(toolName string, schema map[string]any) map[string]any
 	ConfirmDangerousActions bool // if true, add confirmation prompt for dangerous actions
+	MaxInlineBinarySize     int  // binary responses larger than this are returned as resource links
+	Mock                    bool // if true, simulate responses instead of calling the API
+	HTTPClient              *http.Client
+	RequestInterceptors     []RequestInterceptor
+	ResponseInterceptors    []ResponseInterceptor
+	ResultInterceptors      []ResultInterceptor
+	ParameterValues         []ParameterValue
+	CoerceArguments         bool // if true, normalize argument types before validation and report the changes
+	OAuth2                  *OAuth2Config
+	Credentials             map[string]string
+	CredentialSources       map[string]CredentialSource
+	CredentialTTL           time.Duration
+	Signers                 map[string]RequestSigner
+	Policy                  *ToolPolicy
+	Async                   map[string]AsyncOperation
+	Stream                  map[string]StreamOptions
+	Pagination              map[string]Pagination
+	ServerVariables         map[string]string
+	Spec                    string
 }
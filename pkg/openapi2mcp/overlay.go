@@ -0,0 +1,251 @@
+// overlay.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay is an OpenAPI Overlay 1.0 document: an ordered list of actions that update or remove
+// parts of an OpenAPI description selected by JSONPath expressions.
+// See https://spec.openapis.org/overlay/v1.0.0.html
+type Overlay struct {
+	Overlay string          `json:"overlay" yaml:"overlay"`
+	Info    OverlayInfo     `json:"info" yaml:"info"`
+	Extends string          `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Actions []OverlayAction `json:"actions" yaml:"actions"`
+}
+
+// OverlayInfo describes an overlay document.
+type OverlayInfo struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// OverlayAction updates or removes the nodes selected by Target.
+// Objects in Update are merged recursively into selected objects; an Update applied to an array is appended to it.
+type OverlayAction struct {
+	Target      string `json:"target" yaml:"target"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Update      any    `json:"update,omitempty" yaml:"update,omitempty"`
+	Remove      bool   `json:"remove,omitempty" yaml:"remove,omitempty"`
+}
+
+// LoadOverlay loads and parses an OpenAPI Overlay YAML or JSON file from the given path.
+func LoadOverlay(path string) (*Overlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := ParseOverlay(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return overlay, nil
+}
+
+// ParseOverlay parses an OpenAPI Overlay YAML or JSON document and checks that its actions are well-formed.
+func ParseOverlay(data []byte) (*Overlay, error) {
+	var overlay Overlay
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("invalid overlay: %w", err)
+	}
+	if !strings.HasPrefix(overlay.Overlay, "1.") {
+		return nil, fmt.Errorf("unsupported overlay version %q (expected 1.x)", overlay.Overlay)
+	}
+	if len(overlay.Actions) == 0 {
+		return nil, fmt.Errorf("overlay has no actions")
+	}
+	for i, action := range overlay.Actions {
+		if _, err := compileJSONPath(action.Target); err != nil {
+			return nil, fmt.Errorf("action %d: %w", i+1, err)
+		}
+		if action.Remove && action.Update != nil {
+			return nil, fmt.Errorf("action %d (%s): update and remove are mutually exclusive", i+1, action.Target)
+		}
+		action.Update = normalizeYAMLValue(action.Update)
+		overlay.Actions[i] = action
+	}
+	return &overlay, nil
+}
+
+// ApplyOverlays applies overlays, in order, to a raw OpenAPI YAML or JSON document and returns the result as JSON.
+// An action whose target selects no node is reported as an error, so that overlays do not silently
+// stop working when the underlying spec changes.
+//
+// Example usage for ApplyOverlays:
+//
+//	overlay, err := openapi2mcp.LoadOverlay("llm-descriptions.yaml")
+//	if err != nil { log.Fatal(err) }
+//	spec, err := openapi2mcp.ApplyOverlays(specBytes, overlay)
+//	if err != nil { log.Fatal(err) }
+//	doc, err := openapi2mcp.LoadOpenAPISpecFromBytes(spec)
+func ApplyOverlays(spec []byte, overlays ...*Overlay) ([]byte, error) {
+	var root any
+	if err := yaml.Unmarshal(spec, &root); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI document: %w", err)
+	}
+	root = normalizeYAMLValue(root)
+	for _, overlay := range overlays {
+		for i, action := range overlay.Actions {
+			var err error
+			if root, err = applyOverlayAction(root, action); err != nil {
+				title := overlay.Info.Title
+				if title == "" {
+					title = "overlay"
+				}
+				return nil, fmt.Errorf("%s, action %d (%s): %w", title, i+1, action.Target, err)
+			}
+		}
+	}
+	return json.Marshal(root)
+}
+
+// ApplyOverlaysToDoc applies overlays to a loaded OpenAPI document and returns the validated result.
+func ApplyOverlaysToDoc(doc *openapi3.T, overlays ...*Overlay) (*openapi3.T, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	spec, err = ApplyOverlays(spec, overlays...)
+	if err != nil {
+		return nil, err
+	}
+	return LoadOpenAPISpecFromBytes(spec)
+}
+
+// LoadOpenAPISpecWithOverlays loads an OpenAPI spec like LoadOpenAPISpec, after applying the given
+// overlay files in order. With no overlays it is equivalent to LoadOpenAPISpec.
+func LoadOpenAPISpecWithOverlays(path string, overlayPaths ...string) (*openapi3.T, error) {
+	if len(overlayPaths) == 0 {
+		return LoadOpenAPISpec(path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, generateAIOpenAPILoadError("File reading", path, err)
+	}
+	overlays := make([]*Overlay, 0, len(overlayPaths))
+	for _, overlayPath := range overlayPaths {
+		overlay, err := LoadOverlay(overlayPath)
+		if err != nil {
+			return nil, err
+		}
+		overlays = append(overlays, overlay)
+	}
+	spec, err := ApplyOverlays(data, overlays...)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := LoadOpenAPISpecFromBytes(spec)
+	if err != nil {
+		return nil, generateAIOpenAPILoadError("Spec parsing", path, err)
+	}
+	return doc, nil
+}
+
+// applyOverlayAction applies a single action and returns the (possibly replaced) root.
+func applyOverlayAction(root any, action OverlayAction) (any, error) {
+	path, err := compileJSONPath(action.Target)
+	if err != nil {
+		return root, err
+	}
+	locs := path.find(root)
+	if len(locs) == 0 {
+		return root, fmt.Errorf("target matched no nodes")
+	}
+	if action.Remove {
+		sortLocationsForRemoval(locs)
+		for _, loc := range locs {
+			if len(loc) == 0 {
+				return root, fmt.Errorf("cannot remove the document root")
+			}
+			removeAt(root, loc)
+		}
+		return root, nil
+	}
+	if action.Update == nil {
+		return root, nil
+	}
+	for _, loc := range locs {
+		merged := mergeOverlayValue(getAt(root, loc), action.Update)
+		if len(loc) == 0 {
+			root = merged
+		} else {
+			setAt(root, loc, merged)
+		}
+	}
+	return root, nil
+}
+
+// mergeOverlayValue merges an update into a target node: objects are merged recursively,
+// an update applied to an array is appended, and any other value replaces the target.
+func mergeOverlayValue(target, update any) any {
+	switch t := target.(type) {
+	case map[string]any:
+		u, ok := update.(map[string]any)
+		if !ok {
+			return update
+		}
+		for k, v := range u {
+			if existing, exists := t[k]; exists {
+				t[k] = mergeOverlayValue(existing, v)
+			} else {
+				t[k] = deepCopyValue(v)
+			}
+		}
+		return t
+	case []any:
+		if items, ok := update.([]any); ok {
+			return append(t, deepCopyValue(items).([]any)...)
+		}
+		return append(t, deepCopyValue(update))
+	}
+	return deepCopyValue(update)
+}
+
+// deepCopyValue copies a generic tree so that one update applied to several targets does not alias.
+func deepCopyValue(v any) any {
+	switch n := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(n))
+		for k, val := range n {
+			m[k] = deepCopyValue(val)
+		}
+		return m
+	case []any:
+		a := make([]any, len(n))
+		for i, val := range n {
+			a[i] = deepCopyValue(val)
+		}
+		return a
+	}
+	return v
+}
+
+// normalizeYAMLValue converts maps with non-string keys (such as unquoted status codes) into map[string]any.
+func normalizeYAMLValue(v any) any {
+	switch n := v.(type) {
+	case map[string]any:
+		for k, val := range n {
+			n[k] = normalizeYAMLValue(val)
+		}
+		return n
+	case map[any]any:
+		m := make(map[string]any, len(n))
+		for k, val := range n {
+			m[fmt.Sprint(k)] = normalizeYAMLValue(val)
+		}
+		return m
+	case []any:
+		for i, val := range n {
+			n[i] = normalizeYAMLValue(val)
+		}
+		return n
+	}
+	return v
+}
@@ -0,0 +1,124 @@
+package openapi2mcp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const overlayTestSpec = `
+openapi: 3.0.0
+info: {title: Pets, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      description: Returns pets.
+      parameters:
+        - {name: limit, in: query, schema: {type: integer}}
+        - {name: X-Trace, in: header, schema: {type: string}}
+      responses:
+        200: {description: OK}
+  /internal/stats:
+    get:
+      operationId: getStats
+      responses:
+        200: {description: OK}
+`
+
+func TestJSONPath(t *testing.T) {
+	root := map[string]any{
+		"paths": map[string]any{
+			"/pets": map[string]any{
+				"get": map[string]any{
+					"parameters": []any{
+						map[string]any{"name": "limit", "in": "query"},
+						map[string]any{"name": "X-Trace", "in": "header"},
+					},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"$.paths['/pets'].get", "[[paths /pets get]]"},
+		{"$.paths.*.get.parameters[1].name", "[[paths /pets get parameters 1 name]]"},
+		{"$.paths.*.*.parameters[?(@.in == 'header')]", "[[paths /pets get parameters 1]]"},
+		{"$.paths.*.*.parameters[?@.in != 'header' && @.name]", "[[paths /pets get parameters 0]]"},
+		{"$..name", "[[paths /pets get parameters 0 name] [paths /pets get parameters 1 name]]"},
+		{"$.paths.*.get.parameters[-1]", "[[paths /pets get parameters 1]]"},
+		{"$.missing", "[]"},
+	}
+	for _, tt := range tests {
+		p, err := compileJSONPath(tt.expr)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.expr, err)
+			continue
+		}
+		if got := fmt.Sprint(p.find(root)); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+	for _, bad := range []string{"paths", "$.paths[", "$[?(@..x == 1)]"} {
+		if _, err := compileJSONPath(bad); err == nil {
+			t.Errorf("%s: expected a compile error", bad)
+		}
+	}
+}
+
+func TestApplyOverlays(t *testing.T) {
+	overlay, err := ParseOverlay([]byte(`
+overlay: 1.0.0
+info: {title: LLM tweaks, version: "1"}
+actions:
+  - target: $.paths['/pets'].get
+    update:
+      operationId: list_pets
+      description: List pets in the store. Use limit to page.
+  - target: $.paths.*.*.parameters[?(@.in == 'header')]
+    remove: true
+  - target: $.paths['/internal/stats']
+    remove: true
+  - target: $.paths['/pets'].get.parameters
+    update: {name: offset, in: query, schema: {type: integer}}
+`))
+	if err != nil {
+		t.Fatalf("ParseOverlay: %v", err)
+	}
+	spec, err := ApplyOverlays([]byte(overlayTestSpec), overlay)
+	if err != nil {
+		t.Fatalf("ApplyOverlays: %v", err)
+	}
+	doc, err := LoadOpenAPISpecFromBytes(spec)
+	if err != nil {
+		t.Fatalf("overlaid spec does not load: %v", err)
+	}
+	ops := ExtractOpenAPIOperations(doc)
+	if len(ops) != 1 {
+		t.Fatalf("expected 1 operation after removing /internal/stats, got %d", len(ops))
+	}
+	op := ops[0]
+	if op.OperationID != "list_pets" || !strings.HasPrefix(op.Description, "List pets in the store") {
+		t.Errorf("operation was not renamed/redescribed: %s %q", op.OperationID, op.Description)
+	}
+	var names []string
+	for _, p := range op.Parameters {
+		names = append(names, p.Value.Name)
+	}
+	if got := strings.Join(names, ","); got != "limit,offset" {
+		t.Errorf("unexpected parameters after overlay: %s", got)
+	}
+
+	stale, err := ParseOverlay([]byte("overlay: 1.0.0\ninfo: {title: stale, version: '1'}\nactions:\n  - target: $.paths['/gone']\n    remove: true\n"))
+	if err != nil {
+		t.Fatalf("ParseOverlay: %v", err)
+	}
+	if _, err := ApplyOverlays([]byte(overlayTestSpec), stale); err == nil || !strings.Contains(err.Error(), "matched no nodes") {
+		t.Errorf("expected an error for a target matching nothing, got %v", err)
+	}
+	if _, err := ParseOverlay([]byte("overlay: 2.0.0\nactions: [{target: $, remove: true}]")); err == nil {
+		t.Errorf("expected an error for an unsupported overlay version")
+	}
+}
@@ -0,0 +1,650 @@
+// pagination.go
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+)
+
+// Pagination configures how a tool fetches the following pages of a GET list operation, so that a
+// single call returns the items of several pages. After each page, the next one is located by, in
+// order: an RFC 8288 Link header with rel="next"; a next-page URL or cursor in the page's JSON
+// (CursorField), a cursor being sent back in CursorParam; or else the PageParam page number or the
+// OffsetParam item offset, advanced until a page comes back short or empty. Pages are fetched until
+// none are left or a limit is reached. The result holds the items of all pages, merged into the first
+// page's document, and if more remain, the arguments with which a later call fetches them.
+//
+// Empty fields are detected from common conventions, and zero limits take their defaults.
+type Pagination struct {
+	ItemsField  string // dot-separated path of the items array in each page (default: the page itself if it is an array, else data, items, results, ...)
+	CursorField string // dot-separated path of the next cursor or next-page URL in each page (default: next_cursor, nextPageToken, next, links.next, ...)
+	CursorParam string // query parameter the cursor is sent in (default: cursor, page_token, after, ... if the operation has one)
+	PageParam   string // query parameter of the page number (default: page or page_number if the operation has one)
+	OffsetParam string // query parameter of the item offset (default: offset, skip or start if the operation has one)
+	MaxItems    int    // no more pages are fetched once this many items were (default 1000)
+	MaxPages    int    // stop after this many pages (default 10)
+	MaxBytes    int64  // no more pages are fetched once the pages total this many bytes (default 1 MiB)
+}
+
+const (
+	defaultPaginationMaxItems = 1000
+	defaultPaginationMaxPages = 10
+	defaultPaginationMaxBytes = 1 << 20
+	// nextPageTTL is how long the next page of a listing stopped by a limit can be fetched with its token.
+	nextPageTTL = time.Hour
+)
+
+// nextPageArgument is the reserved tool argument continuing a listing at a next-page URL returned
+// by the API, where an earlier call stopped.
+const nextPageArgument = "__next_page"
+
+var errUnknownNextPage = errors.New("unknown or expired " + nextPageArgument)
+
+// Conventions used to detect the pagination of an operation, in order of preference.
+var (
+	paginationItemsFields   = []string{"data", "items", "results", "records", "entries", "value", "elements", "content"}
+	paginationCursorFields  = []string{"next_cursor", "nextCursor", "next_page_token", "nextPageToken", "next_token", "nextToken", "continuation_token", "continuationToken", "@odata.nextLink", "nextLink", "next_url", "nextUrl", "next_page_url", "next", "meta.next_cursor", "meta.nextCursor", "meta.next", "pagination.next_cursor", "pagination.nextCursor", "pagination.next", "response_metadata.next_cursor", "links.next", "_links.next.href", "paging.next"}
+	paginationHasMoreFields = []string{"has_more", "hasMore", "has_next", "hasNext", "meta.has_more", "meta.hasMore", "pagination.has_more", "pagination.hasMore"}
+	paginationCursorParams  = []string{"cursor", "page_token", "pageToken", "next_token", "nextToken", "continuation_token", "continuationToken", "continuation", "starting_after", "after", "marker"}
+	paginationPageParams    = []string{"page", "page_number", "pageNumber"}
+	paginationOffsetParams  = []string{"offset", "skip", "start"}
+)
+
+// paginationFor returns the pagination of a GET operation with defaults applied: its x-mcp-pagination,
+// overridden by the non-zero fields of configured[operationId] or else configured["*"]. Parameters not
+// configured are detected among the operation's query parameters; configured ones that aren't query
+// parameters are ignored with a warning. It returns nil if only the first page is returned.
+func paginationFor(op OpenAPIOperation, configured map[string]Pagination) *Pagination {
+	if !strings.EqualFold(op.Method, http.MethodGet) {
+		return nil
+	}
+	override, ok := configured[op.OperationID]
+	if !ok {
+		override, ok = configured["*"]
+	}
+	if !ok && op.Pagination == nil {
+		return nil
+	}
+	var pagination Pagination
+	if op.Pagination != nil {
+		pagination = *op.Pagination
+	}
+	for _, field := range []struct{ value, override *string }{
+		{&pagination.ItemsField, &override.ItemsField},
+		{&pagination.CursorField, &override.CursorField},
+		{&pagination.CursorParam, &override.CursorParam},
+		{&pagination.PageParam, &override.PageParam},
+		{&pagination.OffsetParam, &override.OffsetParam},
+	} {
+		if *field.override != "" {
+			*field.value = *field.override
+		}
+	}
+	if override.MaxItems > 0 {
+		pagination.MaxItems = override.MaxItems
+	}
+	if override.MaxPages > 0 {
+		pagination.MaxPages = override.MaxPages
+	}
+	if override.MaxBytes > 0 {
+		pagination.MaxBytes = override.MaxBytes
+	}
+	if pagination.MaxItems <= 0 {
+		pagination.MaxItems = defaultPaginationMaxItems
+	}
+	if pagination.MaxPages <= 0 {
+		pagination.MaxPages = defaultPaginationMaxPages
+	}
+	if pagination.MaxBytes <= 0 {
+		pagination.MaxBytes = defaultPaginationMaxBytes
+	}
+	for _, param := range []struct {
+		name        *string
+		conventions []string
+	}{
+		{&pagination.CursorParam, paginationCursorParams},
+		{&pagination.PageParam, paginationPageParams},
+		{&pagination.OffsetParam, paginationOffsetParams},
+	} {
+		if *param.name != "" && !hasQueryParameter(op.Parameters, *param.name) {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring the pagination parameter %q of %s: it is not a query parameter\n", *param.name, op.OperationID)
+			*param.name = ""
+		}
+		if *param.name == "" {
+			for _, name := range param.conventions {
+				if hasQueryParameter(op.Parameters, name) {
+					*param.name = name
+					break
+				}
+			}
+		}
+	}
+	return &pagination
+}
+
+// nextPage is a next-page URL where a listing stopped by a limit can be continued.
+type nextPage struct {
+	tool    string
+	caller  string   // client whose listing stopped (see callerFromContext); only it may continue it
+	origin  *url.URL // URL of the listing's first request; credentials are only sent to its host
+	url     string
+	expires time.Time
+}
+
+// nextPages remembers the next pages of the listings of the tools of a server, by random token.
+// Tokens do not survive restarts.
+type nextPages struct {
+	mu    sync.Mutex
+	pages map[string]nextPage
+}
+
+func newNextPages() *nextPages {
+	return &nextPages{pages: map[string]nextPage{}}
+}
+
+// put stores a next page and returns its new token.
+func (n *nextPages) put(page nextPage) string {
+	token := randomToken(16)
+	now := time.Now()
+	page.expires = now.Add(nextPageTTL)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for k, p := range n.pages {
+		if now.After(p.expires) {
+			delete(n.pages, k)
+		}
+	}
+	n.pages[token] = page
+	return token
+}
+
+// get returns the next page of a token issued by tool to caller.
+func (n *nextPages) get(token, tool, caller string) (nextPage, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	page, ok := n.pages[token]
+	// Another client gets the same answer as for an expired token
+	if !ok || page.tool != tool || page.caller != caller || time.Now().After(page.expires) {
+		return nextPage{}, false
+	}
+	return page, true
+}
+
+// pager fetches the pages of the listings of a tool.
+type pager struct {
+	config               *Pagination
+	tool                 string
+	tmpl                 *operationTemplate
+	client               *http.Client
+	requestInterceptors  []RequestInterceptor
+	responseInterceptors []ResponseInterceptor
+	pages                *nextPages
+}
+
+// pageLink locates a next page: a URL returned by the API, or the arguments of the operation's request.
+type pageLink struct {
+	url  string
+	args map[string]any
+	// changed are the arguments that differ from the current page's, if the page is requested by arguments
+	changed map[string]any
+}
+
+// key identifies the page, to stop at a cursor that was already followed.
+func (l *pageLink) key() string {
+	if l.url != "" {
+		return l.url
+	}
+	return encodeJSON(l.changed)
+}
+
+// paginatedList is the outcome of fetching the pages of a listing.
+type paginatedList struct {
+	doc          any // the first page, with the items of all pages
+	items        int
+	pages        int
+	bytes        int64
+	stopReason   string // last_page, max_items, max_pages, max_bytes or error
+	stopDetail   string
+	continuation map[string]any // arguments added to the next call to fetch the following pages
+}
+
+// resume fetches the next page of a token returned by an earlier call.
+func (p *pager) resume(ctx context.Context, token string, args map[string]any) (*http.Response, nextPage, error) {
+	page, ok := p.pages.get(token, p.tool, callerFromContext(ctx))
+	if !ok {
+		return nil, page, fmt.Errorf("%w %q: call %s without it to list from the first page", errUnknownNextPage, token, p.tool)
+	}
+	resp, err := p.fetch(ctx, page.origin, "", &pageLink{url: page.url, args: args})
+	return resp, page, err
+}
+
+// collect fetches the pages following the first one, a successful response to a request for origin
+// with args, or for a next-page URL if byURL, until none are left or a limit is reached. Pages
+// requested by arguments are sent to baseURL, the server of the first request. It returns nil if
+// the first page isn't a JSON list, which is then returned as is.
+func (p *pager) collect(ctx context.Context, origin *url.URL, baseURL string, resp *http.Response, body []byte, args map[string]any, byURL bool, progress *callProgress) *paginatedList {
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return nil
+	}
+	itemsPath, items, ok := p.firstItems(doc)
+	if !ok {
+		return nil
+	}
+	list := &paginatedList{doc: doc, pages: 1, bytes: int64(len(body))}
+	merged := items
+	firstSize := len(items)
+	page, pageArgs, pageItems := doc, args, len(items)
+	header, base := resp.Header, origin
+	if resp.Request != nil {
+		base = resp.Request.URL
+	}
+	cursorPath := ""
+	seen := map[string]bool{}
+	for {
+		link, path := p.next(header, base, page, pageArgs, pageItems, firstSize, byURL)
+		if list.pages == 1 {
+			cursorPath = path
+		}
+		if link == nil || seen[link.key()] {
+			list.stopReason = "last_page"
+			break
+		}
+		seen[link.key()] = true
+		switch {
+		case len(merged) >= p.config.MaxItems:
+			list.stopReason = "max_items"
+		case list.pages >= p.config.MaxPages:
+			list.stopReason = "max_pages"
+		case list.bytes >= p.config.MaxBytes:
+			list.stopReason = "max_bytes"
+		}
+		if list.stopReason != "" {
+			list.continuation = p.continuation(ctx, origin, link)
+			break
+		}
+
+		progress.step(fmt.Sprintf("Fetching page %d (%d items so far)", list.pages+1, len(merged)))
+		next, nextItems, size, nextResp, err := p.page(ctx, origin, baseURL, link, itemsPath)
+		if err != nil {
+			list.stopReason, list.stopDetail = "error", fmt.Sprintf("page %d: %v", list.pages+1, err)
+			list.continuation = p.continuation(ctx, origin, link)
+			break
+		}
+		merged = append(merged, nextItems...)
+		list.pages++
+		list.bytes += int64(size)
+		page, pageArgs, pageItems, header, byURL = next, link.args, len(nextItems), nextResp.Header, link.url != ""
+		if nextResp.Request != nil {
+			base = nextResp.Request.URL
+		}
+	}
+
+	list.items = len(merged)
+	if itemsPath == "" {
+		list.doc = merged
+	} else {
+		setField(list.doc, itemsPath, merged)
+	}
+	// The first page's cursor would lead back to the second page
+	if cursorPath != "" && list.pages > 1 {
+		cursor, _ := lookupPath(page, cursorPath)
+		setField(list.doc, cursorPath, cursor)
+	}
+	return list
+}
+
+// page fetches a next page and returns its JSON document, its items and its size.
+func (p *pager) page(ctx context.Context, origin *url.URL, baseURL string, link *pageLink, itemsPath string) (any, []any, int, *http.Response, error) {
+	resp, err := p.fetch(ctx, origin, baseURL, link)
+	if err != nil {
+		return nil, nil, 0, nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, nil, 0, nil, err
+	}
+	if body, err = applyResponseInterceptors(ctx, p.responseInterceptors, p.tmpl.op, resp, body); err != nil {
+		return nil, nil, 0, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, nil, 0, nil, fmt.Errorf("HTTP %d %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	doc, err := decodeJSON(body)
+	if err != nil {
+		return nil, nil, 0, nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	items, ok := lookupItems(doc, itemsPath)
+	if !ok {
+		return nil, nil, 0, nil, fmt.Errorf("no list of items")
+	}
+	return doc, items, len(body), resp, nil
+}
+
+// fetch sends the request of a next page: to its URL resolved against origin, or to baseURL if it is
+// requested by arguments.
+func (p *pager) fetch(ctx context.Context, origin *url.URL, baseURL string, link *pageLink) (*http.Response, error) {
+	return sendFollowUpRequest(ctx, p.client, p.tmpl, p.requestInterceptors, func() (*preparedRequest, error) {
+		if link.url != "" {
+			return buildFollowUpRequest(ctx, p.tmpl, origin, link.url, link.args)
+		}
+		return buildOperationRequest(ctx, p.tmpl, baseURL, link.args)
+	})
+}
+
+// firstItems returns the items of the first page of a listing and their path in the page: the
+// configured ItemsField, the page itself, or the first conventional (or the only) array field.
+func (p *pager) firstItems(doc any) (string, []any, bool) {
+	if p.config.ItemsField != "" {
+		items, ok := lookupItems(doc, p.config.ItemsField)
+		return p.config.ItemsField, items, ok
+	}
+	if items, ok := doc.([]any); ok {
+		return "", items, true
+	}
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return "", nil, false
+	}
+	for _, field := range paginationItemsFields {
+		if items, ok := obj[field].([]any); ok {
+			return field, items, true
+		}
+	}
+	var arrays []string
+	for _, field := range sortedKeys(obj) {
+		if _, ok := obj[field].([]any); ok {
+			arrays = append(arrays, field)
+		}
+	}
+	if len(arrays) != 1 {
+		return "", nil, false
+	}
+	return arrays[0], obj[arrays[0]].([]any), true
+}
+
+// next locates the page following a page of count items, and returns the path of the cursor field
+// found in the page, if any. It returns a nil link after the last page. Pages requested by a URL
+// returned by the API (byURL) are never followed by page numbers or offsets.
+func (p *pager) next(header http.Header, base *url.URL, doc any, args map[string]any, count, firstSize int, byURL bool) (*pageLink, string) {
+	for _, field := range paginationHasMoreFields {
+		if more, ok := lookupPath(doc, field); ok && more == false {
+			return nil, ""
+		}
+	}
+	if next := linkNext(header); next != "" {
+		if u, err := base.Parse(next); err == nil {
+			return &pageLink{url: u.String(), args: args}, ""
+		}
+	}
+
+	fields := paginationCursorFields
+	if p.config.CursorField != "" {
+		fields = []string{p.config.CursorField}
+	}
+	for _, field := range fields {
+		v, ok := lookupPath(doc, field)
+		if !ok {
+			continue
+		}
+		switch cursor := v.(type) {
+		case nil:
+			return nil, field
+		case string:
+			if cursor == "" {
+				return nil, field
+			}
+			if strings.HasPrefix(cursor, "http://") || strings.HasPrefix(cursor, "https://") || strings.HasPrefix(cursor, "/") || strings.HasPrefix(cursor, "?") {
+				if u, err := base.Parse(cursor); err == nil {
+					return &pageLink{url: u.String(), args: args}, field
+				}
+			} else if p.config.CursorParam != "" {
+				return p.withParameter(args, p.config.CursorParam, cursor), field
+			}
+		case json.Number:
+			if p.config.CursorParam != "" {
+				return p.withParameter(args, p.config.CursorParam, cursor), field
+			}
+		}
+	}
+
+	// Page numbers and offsets: a short or empty page is the last one
+	if byURL || count == 0 || count < firstSize {
+		return nil, ""
+	}
+	if name := p.config.PageParam; name != "" {
+		current, ok := p.intParameter(args, name)
+		if !ok {
+			current = parameterStart(p.tmpl.op, name, 1)
+		}
+		return p.withParameter(args, name, current+1), ""
+	}
+	if name := p.config.OffsetParam; name != "" {
+		current, ok := p.intParameter(args, name)
+		if !ok {
+			current = parameterStart(p.tmpl.op, name, 0)
+		}
+		return p.withParameter(args, name, current+count), ""
+	}
+	return nil, ""
+}
+
+// withParameter returns the link of the page requested with a query parameter set to value.
+func (p *pager) withParameter(args map[string]any, name string, value any) *pageLink {
+	argName := escapeParameterName(name)
+	for _, tp := range p.tmpl.queryParams {
+		if tp.name == name {
+			argName = tp.argName
+		}
+	}
+	next := make(map[string]any, len(args)+1)
+	for k, v := range args {
+		next[k] = v
+	}
+	next[argName] = value
+	return &pageLink{args: next, changed: map[string]any{argName: value}}
+}
+
+// intParameter returns the integer value of a query parameter in args.
+func (p *pager) intParameter(args map[string]any, name string) (int, bool) {
+	for _, tp := range p.tmpl.queryParams {
+		if tp.name != name {
+			continue
+		}
+		v, ok := tp.value(args)
+		if !ok {
+			return 0, false
+		}
+		n, err := strconv.Atoi(formatParameterValue(v, true))
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// parameterStart returns the first value of a page number or offset parameter: its schema default
+// or minimum, or fallback.
+func parameterStart(op OpenAPIOperation, name string, fallback int) int {
+	for _, paramRef := range op.Parameters {
+		if paramRef == nil || paramRef.Value == nil || paramRef.Value.In != "query" || paramRef.Value.Name != name {
+			continue
+		}
+		if schema := paramRef.Value.Schema; schema != nil && schema.Value != nil {
+			if n, ok := schema.Value.Default.(float64); ok {
+				return int(n)
+			}
+			if schema.Value.Min != nil {
+				return int(*schema.Value.Min)
+			}
+		}
+	}
+	return fallback
+}
+
+// continuation returns the arguments added to a call to continue a listing at link: the changed
+// cursor, page number or offset, or the token of a next-page URL.
+func (p *pager) continuation(ctx context.Context, origin *url.URL, link *pageLink) map[string]any {
+	if link.url == "" {
+		return link.changed
+	}
+	token := p.pages.put(nextPage{tool: p.tool, caller: callerFromContext(ctx), origin: origin, url: link.url})
+	return map[string]any{nextPageArgument: token}
+}
+
+// linkNext returns the target of the rel="next" link of RFC 8288 Link headers, or "".
+func linkNext(h http.Header) string {
+	for _, v := range h.Values("Link") {
+		for {
+			start := strings.IndexByte(v, '<')
+			end := strings.IndexByte(v, '>')
+			if start < 0 || end < start {
+				break
+			}
+			target := v[start+1 : end]
+			params := v[end+1:]
+			if i := strings.IndexByte(params, '<'); i >= 0 {
+				params, v = params[:i], params[i:]
+			} else {
+				v = ""
+			}
+			for _, param := range strings.Split(params, ";") {
+				key, value, _ := strings.Cut(param, "=")
+				if !strings.EqualFold(strings.TrimSpace(key), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `",`)) {
+					if strings.EqualFold(rel, "next") {
+						return target
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// decodeJSON decodes a JSON document, keeping numbers as json.Number so that large identifiers
+// survive being encoded again.
+func decodeJSON(body []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("trailing data after the JSON document")
+	}
+	return doc, nil
+}
+
+// encodeJSON encodes a value as compact JSON without escaping HTML characters.
+func encodeJSON(v any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// lookupPath is lookupField for field names that may contain dots, such as "@odata.nextLink":
+// a top-level field with the whole path as its name takes precedence.
+func lookupPath(doc any, path string) (any, bool) {
+	if obj, ok := doc.(map[string]any); ok {
+		if v, ok := obj[path]; ok {
+			return v, true
+		}
+	}
+	return lookupField(doc, path)
+}
+
+// lookupItems returns the items array at a path of a page ("" for the page itself).
+func lookupItems(doc any, path string) ([]any, bool) {
+	if path != "" {
+		doc, _ = lookupPath(doc, path)
+	}
+	items, ok := doc.([]any)
+	return items, ok
+}
+
+// setField sets the value at a path found by lookupPath.
+func setField(doc any, path string, value any) {
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return
+	}
+	if _, ok := obj[path]; ok {
+		obj[path] = value
+		return
+	}
+	names := strings.Split(path, ".")
+	for _, name := range names[:len(names)-1] {
+		if obj, ok = obj[name].(map[string]any); !ok {
+			return
+		}
+	}
+	obj[names[len(names)-1]] = value
+}
+
+// stopDescription describes why no more pages were fetched.
+func (l *paginatedList) stopDescription(config *Pagination) string {
+	switch l.stopReason {
+	case "max_items":
+		return fmt.Sprintf("reached the limit of %d items", config.MaxItems)
+	case "max_pages":
+		return fmt.Sprintf("reached the limit of %d pages", config.MaxPages)
+	case "max_bytes":
+		return fmt.Sprintf("reached the limit of %s", formatBytes(config.MaxBytes))
+	case "error":
+		return "failed to fetch " + l.stopDetail
+	}
+	return "no more pages"
+}
+
+// result returns the tool result of a listing. It is partial if more pages remain.
+func (l *paginatedList) result(name, method, fullURL string, status int, config *Pagination, inputSchema, args map[string]any) *mcp.CallToolResult {
+	text := fmt.Sprintf("HTTP %s %s\nStatus: %d\nPages: %d (%d items, %s); stopped: %s\nResponse:\n%s",
+		strings.ToUpper(method), fullURL, status, l.pages, l.items, formatBytes(l.bytes), l.stopDescription(config), encodeJSON(l.doc))
+	structured := map[string]any{
+		"type":       "paginated_list",
+		"pages":      l.pages,
+		"items":      l.items,
+		"bytes":      l.bytes,
+		"stopReason": l.stopReason,
+	}
+	nextSteps := []string{"list", "schema <tool>"}
+	if l.continuation != nil {
+		text += fmt.Sprintf("\nMore items are available: call %s again with the same arguments and %s added to fetch the following pages.", name, encodeJSON(l.continuation))
+		structured["continuation"] = l.continuation
+		nextSteps = []string{"call " + name + " with the continuation to fetch the following pages"}
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+		StructuredContent: structured,
+		Schema:            inputSchema,
+		Arguments:         args,
+		Usage:             "call <tool> <json-args>",
+		NextSteps:         nextSteps,
+		Partial:           l.continuation != nil,
+		OutputFormat:      "unstructured",
+		OutputType:        "text",
+	}
+}
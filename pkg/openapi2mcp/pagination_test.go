@@ -0,0 +1,232 @@
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// paginatedTestAPI lists the items 1 to 7, three at a time, with the pagination style of each path.
+func paginatedTestAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	const total, pageSize = 7, 3
+	itemsFrom := func(start int) []int {
+		var items []int
+		for i := start; i < start+pageSize && i <= total; i++ {
+			items = append(items, i)
+		}
+		return items
+	}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Key") != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/links":
+			page, _ := strconv.Atoi(query.Get("page"))
+			if page == 0 {
+				page = 1
+			}
+			if (page-1)*pageSize+pageSize < total {
+				w.Header().Add("Link", fmt.Sprintf(`</links?page=1>; rel="first", </links?page=%d>; rel="next"`, page+1))
+			}
+			json.NewEncoder(w).Encode(itemsFrom((page-1)*pageSize + 1))
+		case "/cursors":
+			start, _ := strconv.Atoi(strings.TrimPrefix(query.Get("cursor"), "c"))
+			if start == 0 {
+				start = 1
+			}
+			page := map[string]any{"data": itemsFrom(start), "meta": map[string]any{"next_cursor": nil}}
+			if start+pageSize <= total {
+				page["meta"] = map[string]any{"next_cursor": fmt.Sprintf("c%d", start+pageSize)}
+			}
+			json.NewEncoder(w).Encode(page)
+		case "/offsets":
+			offset, _ := strconv.Atoi(query.Get("offset"))
+			json.NewEncoder(w).Encode(map[string]any{"total": total, "results": itemsFrom(offset + 1)})
+		}
+	}))
+	t.Cleanup(upstream.Close)
+	return upstream
+}
+
+func newPaginationTestServer(t *testing.T, pagination map[string]Pagination) *server.MCPServer {
+	t.Helper()
+	upstream := paginatedTestAPI(t)
+	return newTestServer(t, `
+openapi: 3.0.0
+info: {title: Items, version: "1.0"}
+servers: [{url: "`+upstream.URL+`"}]
+components:
+  securitySchemes:
+    key: {type: apiKey, in: header, name: X-Key}
+security: [{key: []}]
+paths:
+  /links:
+    get:
+      operationId: listByLinks
+      x-mcp-pagination: true
+      parameters:
+        - {name: page, in: query, schema: {type: integer}}
+      responses: {"200": {description: OK}}
+  /cursors:
+    get:
+      operationId: listByCursors
+      x-mcp-pagination: {cursorField: meta.next_cursor}
+      parameters:
+        - {name: cursor, in: query, schema: {type: string}}
+      responses: {"200": {description: OK}}
+  /offsets:
+    get:
+      operationId: listByOffsets
+      parameters:
+        - {name: offset, in: query, schema: {type: integer}}
+      responses: {"200": {description: OK}}
+`, &ToolGenOptions{Credentials: map[string]string{"key": "s3cret"}, Pagination: pagination})
+}
+
+// callPaginatedTool calls a listing tool and returns its result with the merged document.
+func callPaginatedTool(t *testing.T, srv *server.MCPServer, tool, args string) (mcp.CallToolResult, any) {
+	t.Helper()
+	result := callTool(t, srv, nil, tool, args)
+	text := resultText(result)
+	_, body, found := strings.Cut(text, "Response:\n")
+	if !found {
+		t.Fatalf("expected a response, got %s", text)
+	}
+	body, _, _ = strings.Cut(body, "\nMore items are available")
+	var doc any
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatalf("invalid merged response %q: %v", body, err)
+	}
+	return result, doc
+}
+
+func TestPaginationStyles(t *testing.T) {
+	srv := newPaginationTestServer(t, map[string]Pagination{"listByOffsets": {}})
+	for _, tc := range []struct {
+		tool string
+		want string
+	}{
+		{"listByLinks", "[1 2 3 4 5 6 7]"},
+		{"listByCursors", "map[data:[1 2 3 4 5 6 7] meta:map[next_cursor:<nil>]]"},
+		{"listByOffsets", "map[results:[1 2 3 4 5 6 7] total:7]"},
+	} {
+		result, doc := callPaginatedTool(t, srv, tc.tool, `{}`)
+		summary := result.StructuredContent.(map[string]any)
+		if got := fmt.Sprint(doc); got != tc.want || summary["pages"] != 3 || summary["stopReason"] != "last_page" || result.Partial {
+			t.Errorf("%s: unexpected result %v: %s", tc.tool, summary, got)
+		}
+	}
+}
+
+func TestPaginationContinuation(t *testing.T) {
+	srv := newPaginationTestServer(t, map[string]Pagination{"*": {MaxPages: 2}})
+
+	// Cursors continue with the cursor argument, which the merged document points to
+	result, doc := callPaginatedTool(t, srv, "listByCursors", `{}`)
+	continuation := result.StructuredContent.(map[string]any)["continuation"]
+	if fmt.Sprint(doc) != "map[data:[1 2 3 4 5 6] meta:map[next_cursor:c7]]" || fmt.Sprint(continuation) != "map[cursor:c7]" || !result.Partial {
+		t.Fatalf("unexpected result %v: %s", continuation, resultText(result))
+	}
+	if _, doc = callPaginatedTool(t, srv, "listByCursors", `{"cursor": "c7"}`); fmt.Sprint(doc) != "map[data:[7] meta:map[next_cursor:<nil>]]" {
+		t.Errorf("unexpected continued result %v", doc)
+	}
+
+	// Link headers continue with a token of the next URL
+	result, doc = callPaginatedTool(t, srv, "listByLinks", `{}`)
+	continuation = result.StructuredContent.(map[string]any)["continuation"]
+	token, _ := continuation.(map[string]any)[nextPageArgument].(string)
+	if fmt.Sprint(doc) != "[1 2 3 4 5 6]" || token == "" || result.StructuredContent.(map[string]any)["stopReason"] != "max_pages" {
+		t.Fatalf("unexpected result %v: %s", continuation, resultText(result))
+	}
+	result, doc = callPaginatedTool(t, srv, "listByLinks", `{"`+nextPageArgument+`": "`+token+`"}`)
+	if fmt.Sprint(doc) != "[7]" || result.Partial || !strings.Contains(resultText(result), "/links?page=3") {
+		t.Errorf("unexpected continued result %v: %s", doc, resultText(result))
+	}
+	// Tokens belong to the tool that issued them
+	if result = callTool(t, srv, nil, "listByCursors", `{"`+nextPageArgument+`": "`+token+`"}`); !result.IsError {
+		t.Error("expected the token of another tool to be rejected")
+	}
+}
+
+func TestNextPageBoundToCaller(t *testing.T) {
+	srv := newPaginationTestServer(t, map[string]Pagination{"*": {MaxPages: 1}})
+	call := func(identity *Identity, args string) mcp.CallToolResult {
+		return callTool(t, srv, identity, "listByLinks", args)
+	}
+	alice := &Identity{Subject: "alice", Method: "jwt"}
+	continuation, _ := call(alice, `{}`).StructuredContent.(map[string]any)["continuation"].(map[string]any)
+	token, _ := continuation[nextPageArgument].(string)
+	if token == "" {
+		t.Fatalf("expected a next-page token, got %v", continuation)
+	}
+	next := `{"` + nextPageArgument + `": "` + token + `"}`
+	for _, other := range []*Identity{nil, {Subject: "bob", Method: "jwt"}} {
+		if result := call(other, next); !result.IsError || !strings.Contains(resultText(result), "unknown or expired") {
+			t.Errorf("%+v: expected the token to be rejected, got %s", other, resultText(result))
+		}
+	}
+	if result := call(alice, next); result.IsError || !strings.Contains(resultText(result), "/links?page=2") {
+		t.Errorf("expected the listing to continue, got %s", resultText(result))
+	}
+}
+
+func TestLinkNext(t *testing.T) {
+	for header, want := range map[string]string{
+		`<https://api.example.com/items?page=2>; rel="next"`:                                        "https://api.example.com/items?page=2",
+		`</items?page=1>; rel="prev first", </items?page=3>; rel=next, </items?page=9>; rel="last"`: "/items?page=3",
+		`<https://api.example.com/items?page=1>; rel="prev"`:                                        "",
+	} {
+		if got := linkNext(http.Header{"Link": {header}}); got != want {
+			t.Errorf("%s: expected %q, got %q", header, want, got)
+		}
+	}
+}
+
+func TestPaginationKeepsServer(t *testing.T) {
+	var servers []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+		servers = append(servers, prefix)
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		w.Header().Set("Content-Type", "application/json")
+		results := []int{}
+		if offset < 3 {
+			results = append(results, offset+1)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"results": results})
+	}))
+	defer upstream.Close()
+	srv := newTestServer(t, `
+openapi: 3.0.0
+info: {title: Items, version: "1.0"}
+servers: [{url: "`+upstream.URL+`/a"}, {url: "`+upstream.URL+`/b"}]
+paths:
+  /items:
+    get:
+      operationId: listItems
+      parameters:
+        - {name: offset, in: query, schema: {type: integer}}
+      responses: {"200": {description: OK}}
+`, &ToolGenOptions{Pagination: map[string]Pagination{"listItems": {}}})
+
+	// Pages requested by arguments go to the server picked for the first page
+	for i := 0; i < 10; i++ {
+		servers = nil
+		callPaginatedTool(t, srv, "listItems", `{}`)
+		if len(servers) != 4 || strings.Count(strings.Join(servers, ""), servers[0]) != 4 {
+			t.Fatalf("pages were fetched from different servers: %v", servers)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// params.go
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	mcpserver "github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// ParameterValue fixes or defaults the value of a parameter for a set of operations.
+//
+// A pinned parameter is removed from the tool's input schema and is always sent with Value.
+// A defaulted parameter stays in the schema, is no longer required, and Value is sent when the model omits it.
+// Value may reference environment variables ($VAR or ${VAR}), expanded at call time, or be
+// "identity:<claim>" to send the authenticated client's subject ("identity:subject") or JWT claim
+// (see IdentityFromContext); calls without that claim are then rejected.
+// If Overridable is set, clients can override the value for their session with an "X-MCP-Param-<name>" header,
+// except for values taken from the identity.
+type ParameterValue struct {
+	Name        string // parameter name, as in the spec
+	Value       string
+	Pinned      bool
+	Overridable bool   // if true, an "X-MCP-Param-<name>" client header replaces Value
+	Spec        string // if set, only applies when ToolGenOptions.Spec is the same (with the CLI, a --mount base path)
+	Operation   string // if set, only applies to this operation (operationId or x-mcp-name)
+	Tag         string // if set, only applies to operations with this tag
+}
+
+// identityValuePrefix introduces parameter values taken from the authenticated client identity.
+const identityValuePrefix = "identity:"
+
+// parameterOverrideHeaderPrefix is the client header prefix for per-session parameter value overrides.
+// These headers are never forwarded to the upstream API.
+const parameterOverrideHeaderPrefix = "X-Mcp-Param-"
+
+// ParseParameterValue parses a "[spec:<spec>:][operation:<id>:|tag:<tag>:][override:]name=value" binding,
+// as used by --pin and --default.
+func ParseParameterValue(s string, pinned bool) (ParameterValue, error) {
+	eq := strings.Index(s, "=")
+	if eq < 1 {
+		return ParameterValue{}, fmt.Errorf("invalid parameter value %q (expected [spec:<spec>:][operation:<id>:|tag:<tag>:][override:]name=value)", s)
+	}
+	pv := ParameterValue{Value: s[eq+1:], Pinned: pinned}
+	scope := strings.Split(s[:eq], ":")
+	if len(scope) > 2 && scope[0] == "spec" {
+		pv.Spec, scope = scope[1], scope[2:]
+		if pv.Spec == "" {
+			return ParameterValue{}, fmt.Errorf("invalid parameter value %q: empty spec", s)
+		}
+	}
+	if len(scope) > 2 && (scope[0] == "operation" || scope[0] == "tag") {
+		if scope[1] == "" {
+			return ParameterValue{}, fmt.Errorf("invalid parameter value %q: empty %s", s, scope[0])
+		}
+		if scope[0] == "operation" {
+			pv.Operation = scope[1]
+		} else {
+			pv.Tag = scope[1]
+		}
+		scope = scope[2:]
+	}
+	if len(scope) == 2 && scope[0] == "override" {
+		pv.Overridable, scope = true, scope[1:]
+	}
+	if len(scope) != 1 {
+		return ParameterValue{}, fmt.Errorf("invalid parameter scope in %q (expected spec:<spec>:, operation:<id>:, tag:<tag>: or override:)", s)
+	}
+	pv.Name = scope[0]
+	if pv.Name == "" {
+		return ParameterValue{}, fmt.Errorf("invalid parameter value %q: empty name", s)
+	}
+	return pv, nil
+}
+
+// specificity ranks scopes: operation bindings override tag bindings, which override spec-wide ones.
+// At the same level, bindings scoped to a spec override unscoped ones.
+func (pv ParameterValue) specificity() int {
+	level := 0
+	switch {
+	case pv.Operation != "":
+		level = 2
+	case pv.Tag != "":
+		level = 1
+	}
+	if pv.Spec != "" {
+		return 2*level + 1
+	}
+	return 2 * level
+}
+
+// resolve returns the value to send: from the client identity for "identity:<claim>" values,
+// or with environment variables expanded.
+func (pv ParameterValue) resolve(ctx context.Context) (string, error) {
+	claim, fromIdentity := strings.CutPrefix(pv.Value, identityValuePrefix)
+	if !fromIdentity {
+		return os.ExpandEnv(pv.Value), nil
+	}
+	if value, ok := identityValue(IdentityFromContext(ctx), claim); ok {
+		return value, nil
+	}
+	return "", fmt.Errorf("parameter %s is taken from the %q claim of the authenticated client, which this client does not have", pv.Name, claim)
+}
+
+// identityValue returns the subject ("subject") or a scalar JWT claim of an identity.
+func identityValue(identity *Identity, claim string) (string, bool) {
+	if identity == nil {
+		return "", false
+	}
+	if claim == "subject" {
+		return identity.Subject, identity.Subject != ""
+	}
+	switch v := identity.Claims[claim].(type) {
+	case string:
+		return v, v != ""
+	case float64, bool, json.Number:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+func (pv ParameterValue) appliesTo(op OpenAPIOperation, spec string) bool {
+	if pv.Spec != "" && pv.Spec != spec {
+		return false
+	}
+	if pv.Operation != "" && pv.Operation != op.OperationID {
+		return false
+	}
+	if pv.Tag != "" {
+		for _, tag := range op.Tags {
+			if tag == pv.Tag {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// parameterValuesFor returns the bindings that apply to an operation's parameters in the given spec,
+// keyed by parameter name. When several bindings match a parameter, the most specific wins; among equals,
+// the last one wins.
+func parameterValuesFor(op OpenAPIOperation, spec string, values []ParameterValue) map[string]ParameterValue {
+	bound := map[string]ParameterValue{}
+	for _, pv := range values {
+		if !pv.appliesTo(op, spec) || findParameter(op.Parameters, pv.Name) == nil {
+			continue
+		}
+		if existing, ok := bound[pv.Name]; ok && existing.specificity() > pv.specificity() {
+			continue
+		}
+		bound[pv.Name] = pv
+	}
+	return bound
+}
+
+func findParameter(params openapi3.Parameters, name string) *openapi3.Parameter {
+	for _, paramRef := range params {
+		if paramRef != nil && paramRef.Value != nil && paramRef.Value.Name == name {
+			return paramRef.Value
+		}
+	}
+	return nil
+}
+
+// applyParameterValuesToSchema removes pinned parameters from an input schema and makes defaulted ones optional.
+func applyParameterValuesToSchema(schema map[string]any, op OpenAPIOperation, bound map[string]ParameterValue) {
+	if len(bound) == 0 {
+		return
+	}
+	props, _ := schema["properties"].(map[string]any)
+	required, _ := schema["required"].([]string)
+	for name, pv := range bound {
+		argName := parameterArgumentName(findParameter(op.Parameters, name))
+		if pv.Pinned {
+			delete(props, argName)
+		} else if prop, ok := props[argName].(map[string]any); ok {
+			note := "Optional: a configured value is used if omitted."
+			if desc, _ := prop["description"].(string); desc != "" {
+				note = desc + " " + note
+			}
+			prop["description"] = note
+		}
+		filtered := required[:0]
+		for _, r := range required {
+			if r != argName {
+				filtered = append(filtered, r)
+			}
+		}
+		required = filtered
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	} else {
+		delete(schema, "required")
+	}
+}
+
+// withParameterValues returns the tool arguments with pinned and defaulted values injected, along with
+// the schema defaults of hidden parameters. Values passed for hidden parameters are dropped, as they
+// are not part of the tool schema. For overridable bindings, per-session overrides from
+// "X-MCP-Param-<name>" client headers take precedence over configured values.
+func withParameterValues(ctx context.Context, op OpenAPIOperation, args map[string]any, bound map[string]ParameterValue) (map[string]any, error) {
+	clientHeaders, _ := ctx.Value(mcpserver.ClientHeadersKey{}).(map[string]string)
+	var effective map[string]any
+	copyArgs := func() {
+		if effective == nil {
+			effective = make(map[string]any, len(args)+1)
+			for k, v := range args {
+				effective[k] = v
+			}
+		}
+	}
+	set := func(name string, value any) {
+		copyArgs()
+		effective[name] = value
+	}
+
+	for _, paramRef := range op.Parameters {
+		if paramRef == nil || paramRef.Value == nil {
+			continue
+		}
+		p := paramRef.Value
+		argName := parameterArgumentName(p)
+		_, provided := args[argName]
+		hidden := isParameterHidden(p)
+		if hidden && provided {
+			copyArgs()
+			delete(effective, argName)
+			provided = false
+		}
+		pv, isBound := bound[p.Name]
+		switch {
+		case isBound && (pv.Pinned || !provided):
+			// Values taken from the client identity are never overridden
+			if override, ok := parameterOverride(clientHeaders, p.Name); ok && pv.Overridable && !strings.HasPrefix(pv.Value, identityValuePrefix) {
+				set(argName, override)
+				continue
+			}
+			value, err := pv.resolve(ctx)
+			if err != nil {
+				return nil, err
+			}
+			set(argName, value)
+		case hidden && p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Default != nil:
+			set(argName, p.Schema.Value.Default)
+		}
+	}
+	if effective == nil {
+		return args, nil
+	}
+	return effective, nil
+}
+
+// parameterOverride looks up a per-session override header for a parameter.
+func parameterOverride(clientHeaders map[string]string, name string) (string, bool) {
+	for key, value := range clientHeaders {
+		if isParameterOverrideHeader(key) && strings.EqualFold(key[len(parameterOverrideHeaderPrefix):], name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func isParameterOverrideHeader(key string) bool {
+	return len(key) > len(parameterOverrideHeaderPrefix) && strings.EqualFold(key[:len(parameterOverrideHeaderPrefix)], parameterOverrideHeaderPrefix)
+}
@@ -0,0 +1,197 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+const paramsTestSpec = `
+openapi: 3.0.0
+info: {title: Params, version: "1.0"}
+paths:
+  /accounts/{account_id}/invoices:
+    get:
+      operationId: listInvoices
+      tags: [billing]
+      parameters:
+        - {name: account_id, in: path, required: true, schema: {type: string}}
+        - {name: currency, in: query, required: true, schema: {type: string}}
+        - {name: limit, in: query, description: Page size, schema: {type: integer}}
+        - {name: region, in: query, x-mcp-hidden: true, schema: {type: string, default: eu}}
+      responses:
+        "200": {description: OK}
+`
+
+func TestParseParameterValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ParameterValue
+	}{
+		{"limit=20", ParameterValue{Name: "limit", Value: "20"}},
+		{"account_id=$ACCOUNT", ParameterValue{Name: "account_id", Value: "$ACCOUNT"}},
+		{"operation:listInvoices:limit=a=b", ParameterValue{Name: "limit", Value: "a=b", Operation: "listInvoices"}},
+		{"tag:billing:currency=EUR", ParameterValue{Name: "currency", Value: "EUR", Tag: "billing"}},
+		{"override:limit=20", ParameterValue{Name: "limit", Value: "20", Overridable: true}},
+		{"spec:/billing:tag:invoices:override:currency=EUR", ParameterValue{Name: "currency", Value: "EUR", Spec: "/billing", Tag: "invoices", Overridable: true}},
+		{"tenant_id=identity:tenant", ParameterValue{Name: "tenant_id", Value: "identity:tenant"}},
+		{"override=1", ParameterValue{Name: "override", Value: "1"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseParameterValue(tt.in, false)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"limit", "=1", "path:x:limit=1", "tag::limit=1", "tag:billing:=1", "spec::limit=1", "override:tag:billing:limit=1"} {
+		if _, err := ParseParameterValue(bad, true); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
+
+func TestParameterValues(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+	os.Setenv("OPENAPI_BASE_URL", ts.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+	os.Setenv("PARAMS_TEST_ACCOUNT", "acct-42")
+	defer os.Unsetenv("PARAMS_TEST_ACCOUNT")
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(paramsTestSpec))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	ops := ExtractOpenAPIOperations(doc)
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ops, doc, &ToolGenOptions{ParameterValues: []ParameterValue{
+		{Name: "account_id", Value: "${PARAMS_TEST_ACCOUNT}", Pinned: true},
+		{Name: "currency", Value: "USD", Pinned: true},
+		{Name: "currency", Value: "EUR", Pinned: true, Tag: "billing", Overridable: true},
+		{Name: "limit", Value: "20"},
+		{Name: "limit", Value: "99", Operation: "otherOperation"},
+	}})
+
+	var rawSchema json.RawMessage
+	for _, tool := range srv.ListTools() {
+		if tool.Name == "listInvoices" {
+			rawSchema = tool.RawInputSchema
+		}
+	}
+	var schema struct {
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err := json.Unmarshal(rawSchema, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	if _, ok := schema.Properties["account_id"]; ok {
+		t.Errorf("pinned parameter is still in the schema")
+	}
+	if _, ok := schema.Properties["currency"]; ok {
+		t.Errorf("pinned parameter is still in the schema")
+	}
+	if desc, _ := schema.Properties["limit"]["description"].(string); !strings.HasPrefix(desc, "Page size") || strings.Contains(desc, "20") {
+		t.Errorf("unexpected description for defaulted parameter: %q", desc)
+	}
+	if len(schema.Required) != 0 {
+		t.Errorf("pinned and defaulted parameters should not be required: %v", schema.Required)
+	}
+
+	call := func(args string, headers map[string]string) url.Values {
+		t.Helper()
+		got = nil
+		params := map[string]any{"name": "listInvoices", "arguments": json.RawMessage(args)}
+		if headers != nil {
+			params["_meta"] = map[string]any{"headers": headers}
+		}
+		msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": params})
+		srv.HandleMessage(context.Background(), msg)
+		if got == nil {
+			t.Fatalf("no request was sent for %s", args)
+		}
+		if !strings.HasPrefix(got.URL.Path, "/accounts/acct-42/") && headers == nil {
+			t.Errorf("pinned path parameter not injected: %s", got.URL.Path)
+		}
+		return got.URL.Query()
+	}
+
+	q := call(`{}`, nil)
+	if q.Get("currency") != "EUR" || q.Get("limit") != "20" || q.Get("region") != "eu" {
+		t.Errorf("unexpected query with injected values: %s", q.Encode())
+	}
+	q = call(`{"limit": 5}`, nil)
+	if q.Get("limit") != "5" {
+		t.Errorf("a provided argument should win over a default: %s", q.Encode())
+	}
+	// Only the overridable binding takes the session's value
+	q = call(`{}`, map[string]string{"X-MCP-Param-currency": "CHF", "X-MCP-Param-account_id": "acct-7"})
+	if q.Get("currency") != "CHF" || got.URL.Path != "/accounts/acct-42/invoices" {
+		t.Errorf("unexpected session overrides: %s %s", got.URL.Path, q.Encode())
+	}
+	if got.Header.Get("X-MCP-Param-currency") != "" {
+		t.Errorf("parameter override header was forwarded upstream")
+	}
+}
+
+func TestParameterValuesScopes(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+	os.Setenv("OPENAPI_BASE_URL", ts.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(paramsTestSpec))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	values := []ParameterValue{
+		{Name: "account_id", Value: "identity:account", Pinned: true, Overridable: true},
+		{Name: "currency", Value: "USD", Pinned: true},
+		{Name: "currency", Value: "EUR", Pinned: true, Spec: "/billing"},
+	}
+	call := func(spec string, identity *Identity) (*http.Request, string) {
+		t.Helper()
+		got = nil
+		srv := server.NewMCPServer("test", "1.0.0")
+		RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{ParameterValues: values, Spec: spec})
+		ctx := context.WithValue(context.Background(), server.ClientHeadersKey{}, map[string]string{"X-MCP-Param-account_id": "acct-7"})
+		if identity != nil {
+			ctx = context.WithValue(ctx, identityKey{}, identity)
+		}
+		msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"listInvoices","arguments":{}}}`)
+		result := srv.HandleMessage(ctx, msg).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+		return got, result.Content[0].(mcp.TextContent).Text
+	}
+
+	// The identity's claim is sent, whatever the client's header says, and spec-scoped values only apply to their spec
+	alice := &Identity{Subject: "alice", Claims: map[string]any{"account": "acct-alice"}}
+	for spec, currency := range map[string]string{"/billing": "EUR", "/crm": "USD"} {
+		req, text := call(spec, alice)
+		if req == nil || req.URL.Path != "/accounts/acct-alice/invoices" || req.URL.Query().Get("currency") != currency {
+			t.Errorf("%s: unexpected request: %v %s", spec, req, text)
+		}
+	}
+	// Without the claim, nothing is sent
+	if req, text := call("", &Identity{Subject: "bob"}); req != nil || !strings.Contains(text, `"account" claim`) {
+		t.Errorf("expected the call to be rejected without the claim, got %v: %s", req, text)
+	}
+}
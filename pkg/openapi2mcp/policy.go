@@ -0,0 +1,347 @@
+// policy.go
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"gopkg.in/yaml.v3"
+)
+
+// ToolPolicy restricts the tools generated from OpenAPI operations that each client identity may see
+// and call (see IdentityFromContext). Other tools are not affected.
+//
+// A rule applies to the identities it names by subject, group or OAuth scope; a rule naming none
+// applies to every client, including unauthenticated ones. A tool is allowed if an applicable rule
+// allows it and none denies it. Otherwise, Default decides.
+//
+//	default: deny
+//	rules:
+//	  - name: support
+//	    groups: [support]
+//	    allow:
+//	      - {tags: [tickets], methods: [GET]}
+//	    deny:
+//	      - {tools: ["*Internal*"]}
+type ToolPolicy struct {
+	Default string       `json:"default,omitempty" yaml:"default,omitempty"` // "deny" (default) or "allow"
+	Rules   []PolicyRule `json:"rules" yaml:"rules"`
+
+	// AuditLog receives a JSON line for each decision on a tool call, if set
+	AuditLog io.Writer `json:"-" yaml:"-"`
+
+	mu      sync.RWMutex
+	path    string
+	modTime time.Time
+	tools   map[string]policyTool
+	parent  *ToolPolicy // holds the rules and the audit log of clones
+}
+
+// PolicyRule grants or denies tools to the identities it names.
+type PolicyRule struct {
+	Name     string        `json:"name,omitempty" yaml:"name,omitempty"`
+	Subjects []string      `json:"subjects,omitempty" yaml:"subjects,omitempty"` // subject globs
+	Groups   []string      `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Scopes   []string      `json:"scopes,omitempty" yaml:"scopes,omitempty"` // OAuth scopes of the client's token
+	Allow    []ToolMatcher `json:"allow,omitempty" yaml:"allow,omitempty"`
+	Deny     []ToolMatcher `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// ToolMatcher selects tools. All the fields set must match; each matches if any of its values does.
+type ToolMatcher struct {
+	Tools   []string `json:"tools,omitempty" yaml:"tools,omitempty"` // tool name globs
+	Tags    []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Methods []string `json:"methods,omitempty" yaml:"methods,omitempty"`
+	// SecurityScopes matches operations all of whose required OpenAPI security scopes are listed
+	// (including operations requiring none). "$token" stands for the OAuth scopes of the client's token.
+	SecurityScopes []string `json:"securityScopes,omitempty" yaml:"securityScopes,omitempty"`
+}
+
+// PolicyDecision is the outcome of a policy check.
+type PolicyDecision struct {
+	Allowed bool
+	Rule    string // the deciding rule, or "default"
+}
+
+// policyTool holds the facts about a tool that rules match on.
+type policyTool struct {
+	tags   []string
+	method string
+	scopes []string // OpenAPI security scopes required by the operation
+}
+
+// LoadToolPolicy loads a policy YAML or JSON file. The file is read again when it changes.
+func LoadToolPolicy(path string) (*ToolPolicy, error) {
+	p, err := readToolPolicy(path)
+	if err != nil {
+		return nil, err
+	}
+	p.path = path
+	return p, nil
+}
+
+func readToolPolicy(path string) (*ToolPolicy, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := ParseToolPolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p.modTime = info.ModTime()
+	return p, nil
+}
+
+// ParseToolPolicy parses a policy YAML or JSON document.
+func ParseToolPolicy(data []byte) (*ToolPolicy, error) {
+	var p ToolPolicy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid policy: %w", err)
+	}
+	if p.Default != "" && p.Default != "allow" && p.Default != "deny" {
+		return nil, fmt.Errorf("invalid policy default %q (expected allow or deny)", p.Default)
+	}
+	for i, rule := range p.Rules {
+		if len(rule.Allow) == 0 && len(rule.Deny) == 0 {
+			return nil, fmt.Errorf("rule %d (%s) neither allows nor denies tools", i+1, rule.Name)
+		}
+		for _, glob := range rule.Subjects {
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("rule %d (%s): invalid subject pattern %q", i+1, rule.Name, glob)
+			}
+		}
+		for _, m := range append(append([]ToolMatcher(nil), rule.Allow...), rule.Deny...) {
+			for _, glob := range m.Tools {
+				if _, err := path.Match(glob, ""); err != nil {
+					return nil, fmt.Errorf("rule %d (%s): invalid tool pattern %q", i+1, rule.Name, glob)
+				}
+			}
+		}
+	}
+	return &p, nil
+}
+
+// reload reads the policy file again if it changed. A file that became invalid is reported and ignored.
+func (p *ToolPolicy) reload() {
+	if p.path == "" {
+		return
+	}
+	info, err := os.Stat(p.path)
+	p.mu.RLock()
+	unchanged := err == nil && info.ModTime().Equal(p.modTime)
+	p.mu.RUnlock()
+	if unchanged {
+		return
+	}
+	updated, err := readToolPolicy(p.path)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] keeping the previous tool policy: %v\n", err)
+		if info != nil {
+			p.modTime = info.ModTime()
+		}
+		return
+	}
+	p.Default, p.Rules, p.modTime = updated.Default, updated.Rules, updated.modTime
+}
+
+// Clone returns a policy applying the same rules, reloaded from the same file and writing to the same
+// audit log, for another MCP server. Tools are known by name only, so each server whose tools may share
+// names with another's (such as the specs mounted on one HTTP listener) needs its own clone.
+func (p *ToolPolicy) Clone() *ToolPolicy {
+	return &ToolPolicy{parent: p.base()}
+}
+
+// base returns the policy holding the rules and the audit log: p, or the policy it was cloned from.
+func (p *ToolPolicy) base() *ToolPolicy {
+	if p.parent != nil {
+		return p.parent
+	}
+	return p
+}
+
+// register records the facts of a tool generated from an operation, placing the tool under the policy.
+func (p *ToolPolicy) register(name string, op OpenAPIOperation) {
+	tool := policyTool{tags: op.Tags, method: op.Method}
+	for _, requirement := range op.Security {
+		for _, scopes := range requirement {
+			tool.scopes = append(tool.scopes, scopes...)
+		}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tools == nil {
+		p.tools = make(map[string]policyTool)
+	}
+	p.tools[name] = tool
+}
+
+// Decide tells whether an identity (nil for unauthenticated clients) may use a tool.
+// Tools not generated from OpenAPI operations are always allowed.
+func (p *ToolPolicy) Decide(identity *Identity, toolName string) PolicyDecision {
+	p.mu.RLock()
+	tool, governed := p.tools[toolName]
+	p.mu.RUnlock()
+	if !governed {
+		return PolicyDecision{Allowed: true}
+	}
+	rules := p.base()
+	rules.reload()
+	rules.mu.RLock()
+	defer rules.mu.RUnlock()
+	allowedBy := ""
+	for i, rule := range rules.Rules {
+		if !rule.appliesTo(identity) {
+			continue
+		}
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i+1)
+		}
+		for _, m := range rule.Deny {
+			if m.matches(toolName, tool, identity) {
+				return PolicyDecision{Allowed: false, Rule: name}
+			}
+		}
+		for _, m := range rule.Allow {
+			if allowedBy == "" && m.matches(toolName, tool, identity) {
+				allowedBy = name
+			}
+		}
+	}
+	if allowedBy != "" {
+		return PolicyDecision{Allowed: true, Rule: allowedBy}
+	}
+	return PolicyDecision{Allowed: rules.Default == "allow", Rule: "default"}
+}
+
+// appliesTo tells whether a rule names an identity.
+func (r *PolicyRule) appliesTo(identity *Identity) bool {
+	if len(r.Subjects) == 0 && len(r.Groups) == 0 && len(r.Scopes) == 0 {
+		return true
+	}
+	if identity == nil {
+		return false
+	}
+	for _, glob := range r.Subjects {
+		if ok, _ := path.Match(glob, identity.Subject); ok {
+			return true
+		}
+	}
+	return intersects(r.Groups, identity.Groups) || intersects(r.Scopes, identity.Scopes)
+}
+
+// matches tells whether a matcher selects a tool.
+func (m *ToolMatcher) matches(name string, tool policyTool, identity *Identity) bool {
+	if len(m.Tools) > 0 {
+		matched := false
+		for _, glob := range m.Tools {
+			if ok, _ := path.Match(glob, name); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(m.Tags) > 0 && !intersects(m.Tags, tool.tags) {
+		return false
+	}
+	if len(m.Methods) > 0 {
+		matched := false
+		for _, method := range m.Methods {
+			matched = matched || strings.EqualFold(method, tool.method)
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(m.SecurityScopes) > 0 {
+		granted := make(map[string]bool)
+		for _, scope := range m.SecurityScopes {
+			if scope == "$token" && identity != nil {
+				for _, s := range identity.Scopes {
+					granted[s] = true
+				}
+			}
+			granted[scope] = true
+		}
+		for _, scope := range tool.scopes {
+			if !granted[scope] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// intersects tells whether two lists have a value in common.
+func intersects(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterTools removes the tools the client may not use from a tools/list result. It can be installed
+// with mcpserver.WithToolFilter (RegisterOpenAPITools does it when ToolGenOptions.Policy is set).
+func (p *ToolPolicy) FilterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	identity := IdentityFromContext(ctx)
+	filtered := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if p.Decide(identity, tool.Name).Allowed {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// authorizeCall checks a tool call against the policy and records the decision in the audit log.
+// It returns a denial result if the call is not allowed.
+func (p *ToolPolicy) authorizeCall(ctx context.Context, toolName string) *mcp.CallToolResult {
+	identity := IdentityFromContext(ctx)
+	decision := p.Decide(identity, toolName)
+	subject, method := "anonymous", ""
+	if identity != nil {
+		subject, method = identity.Subject, identity.Method
+	}
+	if audit := p.base(); audit.AuditLog != nil {
+		entry, _ := json.Marshal(map[string]any{
+			"time":     time.Now().UTC().Format(time.RFC3339),
+			"event":    "tool_call",
+			"subject":  subject,
+			"auth":     method,
+			"tool":     toolName,
+			"decision": map[bool]string{true: "allow", false: "deny"}[decision.Allowed],
+			"rule":     decision.Rule,
+		})
+		audit.mu.Lock()
+		audit.AuditLog.Write(append(entry, '\n'))
+		audit.mu.Unlock()
+	}
+	if decision.Allowed {
+		return nil
+	}
+	result := mcp.NewToolResultError(fmt.Sprintf("Access denied: %s may not call %s (policy: %s). Ask the server operator for access.", subject, toolName, decision.Rule), nil, nil, nil, "", nil)
+	result.StructuredContent = map[string]any{"error": "access_denied", "tool": toolName, "subject": subject, "rule": decision.Rule}
+	return result
+}
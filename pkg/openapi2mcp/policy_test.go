@@ -0,0 +1,229 @@
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+const policyTestSpec = `
+openapi: 3.0.0
+info: {title: Helpdesk, version: "1.0"}
+servers: [{url: "http://127.0.0.1:1"}]
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: http://127.0.0.1:1/token
+          scopes: {tickets:read: "", admin: ""}
+paths:
+  /tickets:
+    get:
+      operationId: listTickets
+      tags: [tickets]
+      security: [{oauth: [tickets:read]}]
+      responses: {"200": {description: OK}}
+    post:
+      operationId: createTicket
+      tags: [tickets]
+      security: [{oauth: [tickets:read]}]
+      responses: {"201": {description: Created}}
+  /tickets/internal:
+    get:
+      operationId: listInternalTickets
+      tags: [tickets]
+      responses: {"200": {description: OK}}
+  /users:
+    get:
+      operationId: listUsers
+      tags: [admin]
+      security: [{oauth: [admin]}]
+      responses: {"200": {description: OK}}
+`
+
+const testPolicy = `
+rules:
+  - name: support
+    groups: [support]
+    allow:
+      - {tags: [tickets], methods: [get]}
+    deny:
+      - {tools: ["*Internal*"]}
+  - name: scoped
+    scopes: [admin]
+    allow:
+      - {securityScopes: [$token]}
+  - name: ci
+    subjects: ["ci-*"]
+    allow:
+      - {tools: ["*"]}
+`
+
+func listToolNames(t *testing.T, srv *server.MCPServer, identity *Identity) []string {
+	t.Helper()
+	ctx := ContextWithIdentity(context.Background(), identity)
+	resp, ok := srv.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatal("expected a JSON-RPC response")
+	}
+	var names []string
+	for _, tool := range resp.Result.(mcp.ListToolsResult).Tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestToolPolicyDecide(t *testing.T) {
+	policy, err := ParseToolPolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestServer(t, policyTestSpec, &ToolGenOptions{Mock: true, Policy: policy})
+	for _, tc := range []struct {
+		identity *Identity
+		want     string
+	}{
+		{nil, "describe info"},
+		{&Identity{Subject: "bob", Groups: []string{"support"}}, "describe info listTickets"},
+		{&Identity{Subject: "carol", Scopes: []string{"admin"}}, "describe info listInternalTickets listUsers"},
+		{&Identity{Subject: "carol", Scopes: []string{"admin", "tickets:read"}}, "createTicket describe info listInternalTickets listTickets listUsers"},
+		{&Identity{Subject: "ci-nightly"}, "createTicket describe info listInternalTickets listTickets listUsers"},
+	} {
+		if got := strings.Join(listToolNames(t, srv, tc.identity), " "); got != tc.want {
+			t.Errorf("%+v: got tools %q, want %q", tc.identity, got, tc.want)
+		}
+	}
+
+	policy.Default = "allow"
+	if got := policy.Decide(nil, "createTicket"); !got.Allowed || got.Rule != "default" {
+		t.Errorf("expected the default to allow, got %+v", got)
+	}
+	// Deny rules win over allow rules and over the default
+	if got := policy.Decide(&Identity{Subject: "bob", Groups: []string{"support"}}, "listInternalTickets"); got.Allowed || got.Rule != "support" {
+		t.Errorf("expected the support rule to deny, got %+v", got)
+	}
+	if _, err := ParseToolPolicy([]byte("default: maybe")); err == nil {
+		t.Error("expected an invalid default to be rejected")
+	}
+	if _, err := ParseToolPolicy([]byte("rules: [{name: empty, groups: [x]}]")); err == nil {
+		t.Error("expected a rule without allow or deny to be rejected")
+	}
+}
+
+func TestToolPolicyEnforcedOnCall(t *testing.T) {
+	policy, _ := ParseToolPolicy([]byte(testPolicy))
+	var audit bytes.Buffer
+	policy.AuditLog = &audit
+	srv := newTestServer(t, policyTestSpec, &ToolGenOptions{Mock: true, Policy: policy})
+	call := func(identity *Identity, tool string) mcp.CallToolResult {
+		return callTool(t, srv, identity, tool, `{}`)
+	}
+
+	support := &Identity{Subject: "bob", Method: "basic", Groups: []string{"support"}}
+	if result := call(support, "listTickets"); result.IsError {
+		t.Errorf("expected listTickets to be allowed: %+v", result)
+	}
+	result := call(support, "createTicket")
+	denial, _ := result.StructuredContent.(map[string]any)
+	if !result.IsError || denial["error"] != "access_denied" || denial["rule"] != "default" || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "Access denied") {
+		t.Errorf("expected createTicket to be denied: %+v", result)
+	}
+
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(audit.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid audit line %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) != 2 || entries[0]["decision"] != "allow" || entries[0]["rule"] != "support" ||
+		entries[1]["decision"] != "deny" || entries[1]["tool"] != "createTicket" || entries[1]["subject"] != "bob" || entries[1]["auth"] != "basic" {
+		t.Errorf("unexpected audit log: %v", entries)
+	}
+}
+
+func TestToolPolicyReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(testPolicy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadToolPolicy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newTestServer(t, policyTestSpec, &ToolGenOptions{Mock: true, Policy: policy})
+	bob := &Identity{Subject: "bob", Groups: []string{"support"}}
+	if got := strings.Join(listToolNames(t, srv, bob), " "); got != "describe info listTickets" {
+		t.Fatalf("unexpected tools %q", got)
+	}
+
+	later := time.Now().Add(time.Minute)
+	os.WriteFile(path, []byte("rules: [{groups: [support], allow: [{tags: [admin]}]}]"), 0o600)
+	os.Chtimes(path, later, later)
+	if got := strings.Join(listToolNames(t, srv, bob), " "); got != "describe info listUsers" {
+		t.Errorf("expected the changed policy to apply, got %q", got)
+	}
+
+	// An invalid change is ignored
+	later = later.Add(time.Minute)
+	os.WriteFile(path, []byte("default: maybe"), 0o600)
+	os.Chtimes(path, later, later)
+	if got := strings.Join(listToolNames(t, srv, bob), " "); got != "describe info listUsers" {
+		t.Errorf("expected the previous policy to be kept, got %q", got)
+	}
+}
+
+func TestToolPolicyClonesKeepMountedToolsApart(t *testing.T) {
+	policy, _ := ParseToolPolicy([]byte("rules: [{groups: [support], allow: [{tags: [tickets], methods: [GET]}]}]"))
+	var audit bytes.Buffer
+	policy.AuditLog = &audit
+	readOnly := newTestServer(t, policyTestSpec, &ToolGenOptions{Mock: true, Policy: policy.Clone()})
+	// A second mounted spec whose listTickets deletes tickets
+	mutating := newTestServer(t, `
+openapi: 3.0.0
+info: {title: Admin, version: "1.0"}
+servers: [{url: "http://127.0.0.1:1"}]
+paths:
+  /tickets:
+    delete:
+      operationId: listTickets
+      tags: [admin]
+      responses: {"204": {description: Deleted}}
+`, &ToolGenOptions{Mock: true, Policy: policy.Clone()})
+
+	bob := &Identity{Subject: "bob", Groups: []string{"support"}}
+	if got := strings.Join(listToolNames(t, readOnly, bob), " "); got != "describe info listInternalTickets listTickets" {
+		t.Errorf("expected the GET listTickets to be allowed, got %q", got)
+	}
+	if got := strings.Join(listToolNames(t, mutating, bob), " "); got != "describe info" {
+		t.Errorf("expected the DELETE listTickets to be denied, got %q", got)
+	}
+	if result := callTool(t, mutating, bob, "listTickets", `{}`); !result.IsError {
+		t.Errorf("expected the DELETE listTickets call to be denied: %+v", result)
+	}
+	if !strings.Contains(audit.String(), `"decision":"deny"`) {
+		t.Errorf("expected clones to write to the audit log, got %q", audit.String())
+	}
+
+	// Rule changes apply to every clone
+	policy.mu.Lock()
+	policy.Default = "allow"
+	policy.Rules = nil
+	policy.mu.Unlock()
+	if got := strings.Join(listToolNames(t, mutating, bob), " "); got != "describe info listTickets" {
+		t.Errorf("expected the changed rules to apply to clones, got %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+// progress.go
+package openapi2mcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	mcpserver "github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// progressInterval is the minimum time between two byte progress notifications.
+const progressInterval = 250 * time.Millisecond
+
+// callProgress reports the progress of a tool call to the client, if it asked for it: the bytes sent
+// and received, and steps such as retries. Progress counts bytes across all the HTTP exchanges of the
+// call, plus one per step, so that it always increases. A nil callProgress reports nothing.
+type callProgress struct {
+	reporter *mcpserver.ProgressReporter
+
+	mu       sync.Mutex
+	base     int64 // progress of the previous exchanges and steps
+	lastSent time.Time
+}
+
+// newCallProgress returns the progress of a tool call, or nil if the client didn't ask for it.
+func newCallProgress(ctx context.Context) *callProgress {
+	reporter := mcpserver.ProgressReporterFromContext(ctx)
+	if reporter == nil {
+		return nil
+	}
+	return &callProgress{reporter: reporter}
+}
+
+// step reports a step of the call, e.g. a retry.
+func (p *callProgress) step(message string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	p.base++
+	progress := p.base
+	p.lastSent = time.Now()
+	p.mu.Unlock()
+	p.reporter.Report(float64(progress), 0, message)
+}
+
+// upload reports the bytes of a request body as they are sent.
+func (p *callProgress) upload(req *http.Request) {
+	if p == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	req.Body = p.counting(req.Body, req.ContentLength, "Uploading request body")
+}
+
+// download reports the bytes of a response body as they are read.
+func (p *callProgress) download(resp *http.Response) {
+	if p == nil || resp.Body == nil {
+		return
+	}
+	resp.Body = p.counting(resp.Body, resp.ContentLength, "Downloading response body")
+}
+
+func (p *callProgress) counting(body io.ReadCloser, size int64, message string) io.ReadCloser {
+	p.mu.Lock()
+	base := p.base
+	p.mu.Unlock()
+	return &progressReader{ReadCloser: body, progress: p, base: base, size: size, message: message}
+}
+
+// transferred reports that n bytes of a body of size bytes (-1 if unknown) were transferred.
+func (p *callProgress) transferred(base, n, size int64, message string, final bool) {
+	p.mu.Lock()
+	if base+n > p.base {
+		p.base = base + n
+	}
+	if !final && time.Since(p.lastSent) < progressInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastSent = time.Now()
+	p.mu.Unlock()
+
+	var total float64
+	if size >= 0 {
+		total = float64(base + size)
+		message = fmt.Sprintf("%s: %s of %s", message, formatBytes(n), formatBytes(size))
+	} else {
+		message = fmt.Sprintf("%s: %s", message, formatBytes(n))
+	}
+	p.reporter.Report(float64(base+n), total, message)
+}
+
+// progressReader counts the bytes read from a body.
+type progressReader struct {
+	io.ReadCloser
+	progress *callProgress
+	base     int64
+	size     int64
+	read     int64
+	message  string
+	done     bool
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	r.read += int64(n)
+	if !r.done && (n > 0 || err == io.EOF) {
+		r.done = err == io.EOF
+		r.progress.transferred(r.base, r.read, r.size, r.message, r.done)
+	}
+	return n, err
+}
+
+// formatBytes formats a byte count for humans.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GiB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
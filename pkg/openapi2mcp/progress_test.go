@@ -0,0 +1,121 @@
+package openapi2mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func TestProgressNotifications(t *testing.T) {
+	attempts := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", "65536")
+		w.Write([]byte(strings.Repeat("x", 64<<10)))
+	}))
+	defer upstream.Close()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Files, version: "1.0"}
+servers: [{url: "` + upstream.URL + `"}]
+components:
+  securitySchemes:
+    key: {type: apiKey, in: header, name: X-Key}
+security: [{key: []}]
+paths:
+  /files:
+    post:
+      operationId: convertFile
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object, properties: {text: {type: string}}}
+      responses: {"200": {description: OK}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{
+		CredentialSources: map[string]CredentialSource{"key": &revocableCredential{"k"}},
+	})
+	ts := httptest.NewServer(HandlerForStreamableHTTP(srv, "/mcp"))
+	defer ts.Close()
+
+	post := func(session, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	resp.Body.Close()
+	session := resp.Header.Get("Mcp-Session-Id")
+
+	resp = post(session, `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"convertFile","arguments":{"requestBody":{"text":"hello"}},"_meta":{"progressToken":"p1"}}}`)
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected progress to be streamed, got %s", resp.Header.Get("Content-Type"))
+	}
+	var messages []string
+	var last float64
+	var result map[string]any
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var message struct {
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+			Result map[string]any `json:"result"`
+		}
+		json.Unmarshal([]byte(data), &message)
+		if message.Method != "notifications/progress" {
+			result = message.Result
+			break
+		}
+		progress := message.Params["progress"].(float64)
+		if message.Params["progressToken"] != "p1" || progress <= last {
+			t.Errorf("unexpected progress notification %v after %v", message.Params, last)
+		}
+		last = progress
+		messages = append(messages, message.Params["message"].(string))
+	}
+	if result == nil || result["isError"] == true {
+		t.Fatalf("unexpected result %v", result)
+	}
+	all := strings.Join(messages, "\n")
+	for _, want := range []string{"Calling POST /files", "Uploading request body: 16 bytes of 16 bytes", "Retrying with refreshed credentials", "Downloading response body: 64.0 KiB of 64.0 KiB"} {
+		if !strings.Contains(all, want) {
+			t.Errorf("missing progress %q in:\n%s", want, all)
+		}
+	}
+}
+
+// revocableCredential is a credential source that can be invalidated.
+type revocableCredential struct{ value string }
+
+func (c *revocableCredential) Credential(ctx context.Context) (string, error) { return c.value, nil }
+func (c *revocableCredential) Invalidate(string)                              {}
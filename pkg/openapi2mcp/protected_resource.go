@@ -0,0 +1,106 @@
+// protected_resource.go
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProtectedResourceMetadata describes an MCP endpoint as an OAuth 2.0 protected resource (RFC 9728),
+// so that remote MCP clients can discover the authorization servers to obtain tokens from.
+type ProtectedResourceMetadata struct {
+	// Resource is the canonical URL of the MCP endpoint, e.g. "https://mcp.example.com/mcp".
+	// Access tokens must be issued for it: their aud claim must contain it (RFC 8707).
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers,omitempty"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported,omitempty"`
+	ResourceName           string   `json:"resource_name,omitempty"`
+	ResourceDocumentation  string   `json:"resource_documentation,omitempty"`
+}
+
+// protectedResourceWellKnown is the well-known URI suffix of protected resource metadata.
+const protectedResourceWellKnown = "/.well-known/oauth-protected-resource"
+
+// WithProtectedResource publishes protected resource metadata for the MCP endpoint, at the path given by
+// ProtectedResourceMetadataPath (ServeHTTP and ServeStreamableHTTP serve it; users of the HandlerFor*
+// functions mount ProtectedResourceHandler themselves). 401 responses then point clients to it, and
+// JWTs must be issued for meta.Resource, unless the authenticator accepts other audiences.
+func WithProtectedResource(meta ProtectedResourceMetadata) HTTPOption {
+	return func(o *httpOptions) {
+		o.resource = &meta
+	}
+}
+
+// ProtectedResourceMetadataURL returns the URL of the metadata of a resource: the well-known suffix
+// is inserted between the host and the path, e.g. https://host/.well-known/oauth-protected-resource/mcp.
+func ProtectedResourceMetadataURL(resource string) string {
+	u, err := url.Parse(resource)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + ProtectedResourceMetadataPath(resource)
+}
+
+// ProtectedResourceMetadataPath returns the path of ProtectedResourceMetadataURL.
+func ProtectedResourceMetadataPath(resource string) string {
+	path := ""
+	if u, err := url.Parse(resource); err == nil {
+		path = strings.TrimSuffix(u.Path, "/")
+	}
+	return protectedResourceWellKnown + path
+}
+
+// ProtectedResourceHandler serves protected resource metadata as JSON. Browser-based clients may
+// fetch it from other origins.
+func ProtectedResourceHandler(meta ProtectedResourceMetadata) http.Handler {
+	if len(meta.BearerMethodsSupported) == 0 {
+		meta.BearerMethodsSupported = []string{"header"}
+	}
+	body, _ := json.MarshalIndent(meta, "", "  ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodOptions:
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		default:
+			w.Header().Set("Allow", "GET, HEAD, OPTIONS")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Write(body)
+	})
+}
+
+// resourceKey is the context key for the protected resource a request is addressed to.
+type resourceKey struct{}
+
+// resourceFromContext returns the protected resource a request is addressed to, if any.
+func resourceFromContext(ctx context.Context) *ProtectedResourceMetadata {
+	meta, _ := ctx.Value(resourceKey{}).(*ProtectedResourceMetadata)
+	return meta
+}
+
+// challengeParams returns the WWW-Authenticate parameters pointing clients to a resource's metadata
+// and the scopes to request.
+func (meta *ProtectedResourceMetadata) challengeParams() string {
+	if meta == nil {
+		return ""
+	}
+	params := ""
+	if metadataURL := ProtectedResourceMetadataURL(meta.Resource); metadataURL != "" {
+		params += ", resource_metadata=\"" + metadataURL + "\""
+	}
+	if len(meta.ScopesSupported) > 0 {
+		params += ", scope=\"" + strings.Join(meta.ScopesSupported, " ") + "\""
+	}
+	return params
+}
@@ -0,0 +1,95 @@
+package openapi2mcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProtectedResourceMetadataURL(t *testing.T) {
+	for resource, want := range map[string]string{
+		"https://mcp.example.com/mcp":        "https://mcp.example.com/.well-known/oauth-protected-resource/mcp",
+		"https://mcp.example.com/":           "https://mcp.example.com/.well-known/oauth-protected-resource",
+		"http://localhost:8080/petstore/mcp": "http://localhost:8080/.well-known/oauth-protected-resource/petstore/mcp",
+		"not a url":                          "",
+	} {
+		if got := ProtectedResourceMetadataURL(resource); got != want {
+			t.Errorf("%s: got %q, want %q", resource, got, want)
+		}
+	}
+}
+
+func TestProtectedResource(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, jwksPath, ecKey, rsaKey)
+	auth, err := NewAuthenticator(HTTPAuthConfig{JWKSFile: jwksPath, Issuer: "https://idp.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := ProtectedResourceMetadata{
+		Resource:             "https://mcp.example.com/mcp",
+		AuthorizationServers: []string{"https://idp.example.com"},
+		ScopesSupported:      []string{"mcp:tools"},
+	}
+	o := newHTTPOptions([]HTTPOption{WithAuthenticator(auth), WithProtectedResource(meta)})
+	var reached *Identity
+	ts := httptest.NewServer(o.mux("/mcp", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = IdentityFromContext(r.Context())
+	})))
+	defer ts.Close()
+
+	// The metadata is published at the endpoint's well-known path, and at the root one
+	for _, path := range []string{"/.well-known/oauth-protected-resource/mcp", "/.well-known/oauth-protected-resource"} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got ProtectedResourceMetadata
+		json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || got.Resource != meta.Resource || got.AuthorizationServers[0] != "https://idp.example.com" || got.BearerMethodsSupported[0] != "header" {
+			t.Errorf("%s: unexpected metadata %d %+v", path, resp.StatusCode, got)
+		}
+	}
+
+	call := func(token string) *http.Response {
+		reached = nil
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader("{}"))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	resp := call("")
+	want := `Bearer realm="openapi-mcp", resource_metadata="https://mcp.example.com/.well-known/oauth-protected-resource/mcp", scope="mcp:tools"`
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") != want {
+		t.Errorf("unexpected challenge %d %q", resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+
+	// Only tokens issued for this resource are accepted
+	claims := map[string]any{"iss": "https://idp.example.com", "sub": "alice", "exp": float64(time.Now().Add(time.Hour).Unix())}
+	claims["aud"] = "https://mcp.example.com/mcp"
+	if resp := call(signJWT(t, ecKey, "ec1", claims)); resp.StatusCode != http.StatusOK || reached == nil || reached.Subject != "alice" {
+		t.Errorf("expected a token for the resource to be accepted, got %d", resp.StatusCode)
+	}
+	claims["aud"] = "https://other.example.com/mcp"
+	resp = call(signJWT(t, ecKey, "ec1", claims))
+	if resp.StatusCode != http.StatusUnauthorized || reached != nil || !strings.Contains(resp.Header.Get("WWW-Authenticate"), `error="invalid_token"`) {
+		t.Errorf("expected a token for another resource to be rejected, got %d %q", resp.StatusCode, resp.Header.Get("WWW-Authenticate"))
+	}
+}
@@ -2,16 +2,14 @@
 package openapi2mcp
 
 import (
-	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"math/rand"
 	"net/http"
-	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -25,6 +23,14 @@ import (
 // getParameterValue retrieves a parameter value from args using the escaped parameter name.
 // It tries the escaped name first, then falls back to the original name if not found.
 func getParameterValue(args map[string]any, paramName string, paramNameMapping map[string]string) (any, bool) {
+	// Arguments renamed with x-mcp-name
+	for argName, original := range paramNameMapping {
+		if original == paramName {
+			if val, ok := args[argName]; ok {
+				return val, true
+			}
+		}
+	}
 	escapedName := escapeParameterName(paramName)
 	if val, ok := args[escapedName]; ok {
 		return val, true
@@ -71,9 +77,7 @@ func logHTTPRequest(req *http.Request, body []byte) {
 	if len(req.Header) > 0 {
 		log.Printf("│ 📋 Headers:")
 		for name, values := range req.Header {
-			if strings.ToLower(name) == "authorization" {
-				log.Printf("│    %s: [REDACTED]", name)
-			} else if strings.ToLower(name) == "cookie" {
+			if isSensitiveHeader(name) {
 				log.Printf("│    %s: [REDACTED]", name)
 			} else {
 				log.Printf("│    %s: %s", name, strings.Join(values, ", "))
@@ -289,7 +293,7 @@ func generateAI400ErrorResponse(op OpenAPIOperation, inputSchemaJSON []byte, arg
 
 // generateAIFriendlyDescription creates a comprehensive, AI-optimized description for an operation
 // that includes all the information an AI agent needs to understand how to use the tool.
-func generateAIFriendlyDescription(op OpenAPIOperation, inputSchema map[string]any, apiKeyHeader string) string {
+func generateAIFriendlyDescription(op OpenAPIOperation, inputSchema map[string]any) string {
 	var desc strings.Builder
 
 	// Start with the original description or summary
@@ -302,14 +306,8 @@ func generateAIFriendlyDescription(op OpenAPIOperation, inputSchema map[string]a
 	// Add authentication requirements if any
 	if len(op.Security) > 0 {
 		desc.WriteString("\n\nAUTHENTICATION: ")
-		var authMethods []string
-		for _, secReq := range op.Security {
-			for schemeName := range secReq {
-				authMethods = append(authMethods, schemeName)
-			}
-		}
-		desc.WriteString("Required (" + strings.Join(authMethods, " OR ") + "). ")
-		desc.WriteString("Set environment variables: API_KEY, BEARER_TOKEN, or BASIC_AUTH")
+		desc.WriteString("Required (" + describeSecurityRequirements(op.Security) + "). ")
+		desc.WriteString("Credentials are configured by the server operator, not passed as arguments")
 	}
 
 	// Extract required parameters first
@@ -390,8 +388,37 @@ func generateAIFriendlyDescription(op OpenAPIOperation, inputSchema map[string]a
 		}
 	}
 
-	// Add example usage
-	desc.WriteString("\n\nEXAMPLE: call " + op.OperationID + " ")
+	// Add example usage, preferring examples provided with x-mcp-examples
+	if len(op.Examples) > 0 {
+		for _, example := range op.Examples {
+			exampleJSON, _ := json.Marshal(example)
+			desc.WriteString("\n\nEXAMPLE: call " + op.OperationID + " " + string(exampleJSON))
+		}
+	} else {
+		desc.WriteString("\n\nEXAMPLE: call " + op.OperationID + " ")
+		desc.WriteString(string(generatedExampleArgs(inputSchema, requiredParams)))
+	}
+
+	// Add response format info
+	if op.Method == "get" || op.Method == "post" || op.Method == "put" {
+		desc.WriteString("\n\nRESPONSE: Returns HTTP status, headers, and response body. ")
+		desc.WriteString("Success responses (2xx) return the data. ")
+		desc.WriteString("Error responses include troubleshooting guidance.")
+	}
+
+	// Add safety note for dangerous operations
+	if isDangerousOperation(op) {
+		desc.WriteString("\n\n⚠️  SAFETY: This operation modifies data. ")
+		desc.WriteString("You will be asked to confirm before execution. ")
+		desc.WriteString("Add {\"" + previewArgument + "\": true} to inspect the exact HTTP request without sending it.")
+	}
+
+	return desc.String()
+}
+
+// generatedExampleArgs builds example tool arguments from the input schema: all required
+// parameters and up to two optional ones.
+func generatedExampleArgs(inputSchema map[string]any, requiredParams []string) []byte {
 	exampleArgs := make(map[string]any)
 
 	// Generate example based on actual parameters
@@ -418,22 +445,21 @@ func generateAIFriendlyDescription(op OpenAPIOperation, inputSchema map[string]a
 	}
 
 	exampleJSON, _ := json.Marshal(exampleArgs)
-	desc.WriteString(string(exampleJSON))
+	return exampleJSON
+}
 
-	// Add response format info
-	if op.Method == "get" || op.Method == "post" || op.Method == "put" {
-		desc.WriteString("\n\nRESPONSE: Returns HTTP status, headers, and response body. ")
-		desc.WriteString("Success responses (2xx) return the data. ")
-		desc.WriteString("Error responses include troubleshooting guidance.")
-	}
+// previewArgument is the reserved tool argument that returns the resolved request instead of sending it.
+const previewArgument = "__preview"
 
-	// Add safety note for dangerous operations
-	if op.Method == "delete" || op.Method == "put" || op.Method == "post" {
-		desc.WriteString("\n\n⚠️  SAFETY: This operation modifies data. ")
-		desc.WriteString("You will be asked to confirm before execution.")
+// isTruthy reports whether a reserved flag argument is set (true or "true").
+func isTruthy(v any) bool {
+	switch val := v.(type) {
+	case bool:
+		return val
+	case string:
+		return strings.EqualFold(val, "true")
 	}
-
-	return desc.String()
+	return false
 }
 
 // generateExampleValue creates appropriate example values based on the parameter schema
@@ -465,6 +491,14 @@ func generateExampleValue(prop map[string]any) any {
 				return "2024-01-01T00:00:00Z"
 			case "uuid":
 				return "123e4567-e89b-12d3-a456-426614174000"
+			case "hostname":
+				return "example.com"
+			case "ipv4":
+				return "192.0.2.1"
+			case "ipv6":
+				return "2001:db8::1"
+			case "byte":
+				return "ZXhhbXBsZQ=="
 			default:
 				return "example_string"
 			}
@@ -489,7 +523,7 @@ func generateExampleValue(prop map[string]any) any {
 }
 
 // generateAI401403ErrorResponse creates comprehensive, AI-optimized error response for authentication/authorization failures
-func generateAI401403ErrorResponse(op OpenAPIOperation, inputSchemaJSON []byte, args map[string]any, responseBody string, statusCode int) string {
+func generateAI401403ErrorResponse(op OpenAPIOperation, doc *openapi3.T, inputSchemaJSON []byte, args map[string]any, responseBody string, statusCode int) string {
 	var response strings.Builder
 
 	if statusCode == 401 {
@@ -528,19 +562,35 @@ func generateAI401403ErrorResponse(op OpenAPIOperation, inputSchemaJSON []byte,
 	response.WriteString("\n")
 
 	response.WriteString("AUTHENTICATION SETUP:\n")
-	response.WriteString("Set one of these environment variables based on your API:\n\n")
-
-	response.WriteString("• API Key Authentication:\n")
-	response.WriteString("  export API_KEY=\"your-api-key-here\"\n")
-	response.WriteString("  # Common header names: X-API-Key, Authorization, Api-Key\n\n")
+	if len(op.Security) > 0 {
+		response.WriteString("Configure credentials for the security schemes above:\n\n")
+		schemes := map[string]bool{}
+		for _, secReq := range op.Security {
+			for schemeName := range secReq {
+				schemes[schemeName] = true
+			}
+		}
+		for _, schemeName := range sortedKeys(schemes) {
+			var scheme *openapi3.SecurityScheme
+			if doc != nil && doc.Components != nil {
+				if ref := doc.Components.SecuritySchemes[schemeName]; ref != nil {
+					scheme = ref.Value
+				}
+			}
+			response.WriteString(fmt.Sprintf("• %s: %s\n", schemeName, describeSecurityScheme(scheme)))
+			response.WriteString(fmt.Sprintf("  %s\n\n", credentialHint(schemeName, scheme)))
+		}
+	} else {
+		response.WriteString("Set one of these environment variables based on your API:\n\n")
 
-	response.WriteString("• Bearer Token Authentication:\n")
-	response.WriteString("  export BEARER_TOKEN=\"your-bearer-token-here\"\n")
-	response.WriteString("  # Sets Authorization: Bearer <token>\n\n")
+		response.WriteString("• Bearer Token Authentication:\n")
+		response.WriteString("  export BEARER_TOKEN=\"your-bearer-token-here\"\n")
+		response.WriteString("  # Sets Authorization: Bearer <token>\n\n")
 
-	response.WriteString("• Basic Authentication:\n")
-	response.WriteString("  export BASIC_AUTH=\"username:password\"\n")
-	response.WriteString("  # Sets Authorization: Basic <base64-encoded-credentials>\n\n")
+		response.WriteString("• Basic Authentication:\n")
+		response.WriteString("  export BASIC_AUTH=\"username:password\"\n")
+		response.WriteString("  # Sets Authorization: Basic <base64-encoded-credentials>\n\n")
+	}
 
 	// Server error details if available
 	if responseBody != "" {
@@ -870,14 +920,38 @@ func hasDateTimeInSchema(schema *openapi3.Schema) bool {
 // Returns the list of tool names registered.
 func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, doc *openapi3.T, opts *ToolGenOptions) []string {
 
-	// Extract API key header name from securitySchemes
-	apiKeyHeader := "Fastly-Key" // default fallback
-	if doc.Components != nil && doc.Components.SecuritySchemes != nil {
-		if sec, ok := doc.Components.SecuritySchemes["ApiKeyAuth"]; ok && sec.Value != nil {
-			if sec.Value.Type == "apiKey" && sec.Value.In == "header" && sec.Value.Name != "" {
-				apiKeyHeader = sec.Value.Name
-			}
+	maxInlineBinary := defaultMaxInlineBinarySize
+	if opts != nil && opts.MaxInlineBinarySize > 0 {
+		maxInlineBinary = opts.MaxInlineBinarySize
+	}
+	httpClient := http.DefaultClient
+	if opts != nil && opts.HTTPClient != nil {
+		httpClient = opts.HTTPClient
+	}
+	// OAuth 2.0 tokens are shared by all tools of this spec; no tokens are needed in mock mode
+	var oauth2 *oauth2Client
+	if opts == nil || !opts.Mock {
+		var oauth2Config *OAuth2Config
+		if opts != nil {
+			oauth2Config = opts.OAuth2
 		}
+		oauth2 = newOAuth2Client(oauth2Config)
+	}
+	var credentials map[string]CredentialSource
+	var configuredSigners map[string]RequestSigner
+	var serverVariables map[string]string
+	if opts != nil {
+		credentials = credentialSources(opts.Credentials, opts.CredentialSources, opts.CredentialTTL)
+		configuredSigners = opts.Signers
+		serverVariables = opts.ServerVariables
+	}
+	signers := schemeSigners(doc, configuredSigners)
+	handles := newAsyncHandles()
+	pages := newNextPages()
+	var policy *ToolPolicy
+	if opts != nil && opts.Policy != nil && !opts.DryRun {
+		policy = opts.Policy
+		mcpserver.WithToolFilter(policy.FilterTools)(server)
 	}
 
 	// Map from operationID to inputSchema JSON for validation
@@ -905,16 +979,47 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 			continue
 		}
 		inputSchema := BuildInputSchema(op.Parameters, op.RequestBody)
+		var boundParams map[string]ParameterValue
+		if opts != nil {
+			boundParams = parameterValuesFor(op, opts.Spec, opts.ParameterValues)
+			applyParameterValuesToSchema(inputSchema, op, boundParams)
+		}
 		if opts != nil && opts.PostProcessSchema != nil {
 			inputSchema = opts.PostProcessSchema(op.OperationID, inputSchema)
 		}
 		inputSchemaJSON, _ := json.MarshalIndent(inputSchema, "", "  ")
 		// Generate AI-friendly description
-		desc := generateAIFriendlyDescription(op, inputSchema, apiKeyHeader)
+		desc := generateAIFriendlyDescription(op, inputSchema)
+		if opts != nil && opts.Mock {
+			desc += "\n\nMOCK MODE: Responses are simulated from the API documentation; no request is sent. " +
+				"Add {\"" + mockStatusArgument + "\": \"404\"} to simulate another documented status code."
+		}
 		name := op.OperationID
 		if opts != nil && opts.NameFormat != nil {
 			name = opts.NameFormat(name)
 		}
+		var async *AsyncOperation
+		if opts == nil || !opts.Mock {
+			var configured map[string]AsyncOperation
+			if opts != nil {
+				configured = opts.Async
+			}
+			if async = asyncOperationFor(op, configured); async != nil {
+				desc += fmt.Sprintf("\n\nASYNC: If the API accepts the request for background processing, the call waits up to %s for it to complete. "+
+					"If it is still running, the result includes a handle: call again with the same arguments and {\"%s\": \"<handle>\"} to keep waiting.", async.Deadline, asyncHandleArgument)
+			}
+		}
+		var pagination *Pagination
+		if opts == nil || !opts.Mock {
+			var configured map[string]Pagination
+			if opts != nil {
+				configured = opts.Pagination
+			}
+			if pagination = paginationFor(op, configured); pagination != nil {
+				desc += fmt.Sprintf("\n\nPAGINATION: The call follows the API's pagination and returns the items of up to %d pages (about %d items) at once. "+
+					"If more remain, the result says which arguments to add to fetch the following pages.", pagination.MaxPages, pagination.MaxItems)
+			}
+		}
 		annotations := mcp.ToolAnnotation{}
 		var titleParts []string
 		if opts != nil && opts.Version != "" {
@@ -941,7 +1046,32 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 			toolNames = append(toolNames, name)
 			continue
 		}
-		server.AddTool(tool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Compile the input schema and request template once, rather than on every call
+		compiledSchema, schemaErr := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(inputSchemaJSON))
+		var schemaObj map[string]any
+		_ = json.Unmarshal(inputSchemaJSON, &schemaObj)
+		tmpl := newOperationTemplate(op, doc, oauth2, credentials, signers, serverVariables)
+		var streamOptions map[string]StreamOptions
+		if opts != nil {
+			streamOptions = opts.Stream
+		}
+		stream := streamConfigFor(op, streamOptions)
+		var waiter *asyncWaiter
+		if async != nil {
+			waiter = &asyncWaiter{config: async, tool: name, tmpl: tmpl, client: httpClient, handles: handles}
+			if opts != nil {
+				waiter.interceptors = opts.RequestInterceptors
+			}
+		}
+		var lister *pager
+		if pagination != nil {
+			lister = &pager{config: pagination, tool: name, tmpl: tmpl, client: httpClient, pages: pages}
+			if opts != nil {
+				lister.requestInterceptors = opts.RequestInterceptors
+				lister.responseInterceptors = opts.ResponseInterceptors
+			}
+		}
+		handler := func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Extract client headers and add them to context
 			clientHeaders := req.GetHeaders()
 			if len(clientHeaders) > 0 {
@@ -953,15 +1083,26 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				args = map[string]any{}
 			}
 
-			// Build parameter name mapping for escaped parameter names
-			paramNameMapping := buildParameterNameMapping(opCopy.Parameters)
+			// Apply the per-operation timeout (x-mcp-timeout) to the upstream call
+			if opCopy.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, opCopy.Timeout)
+				defer cancel()
+			}
 
-			// Validate arguments against inputSchema
-			inputSchemaJSON := toolSchemas[name]
-			argsJSON, _ := json.Marshal(args)
-			schemaLoader := gojsonschema.NewBytesLoader(inputSchemaJSON)
-			argsLoader := gojsonschema.NewBytesLoader(argsJSON)
-			result, err := gojsonschema.Validate(schemaLoader, argsLoader)
+			// Validate arguments against the precompiled inputSchema
+			if schemaErr != nil {
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{
+							Type: "text",
+							Text: "Validation error: " + schemaErr.Error(),
+						},
+					},
+					IsError: true,
+				}, nil
+			}
+			result, err := compiledSchema.Validate(gojsonschema.NewGoLoader(args))
 			if err != nil {
 				return &mcp.CallToolResult{
 					Content: []mcp.Content{
@@ -974,346 +1115,184 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				}, nil
 			}
 			if !result.Valid() {
-				var missingFields []string
-				var suggestions []string
-				errMsgs := ""
-				// Parse the input schema for property descriptions
-				var schemaObj map[string]any
-				_ = json.Unmarshal(inputSchemaJSON, &schemaObj)
-				properties, _ := schemaObj["properties"].(map[string]any)
-				for _, verr := range result.Errors() {
-					errMsg := ""
-
-					// Handle different validation error types with plain text messages
-					switch verr.Type() {
-					case "required":
-						if missingRaw, ok := verr.Details()["property"]; ok {
-							if missing, ok := missingRaw.(string); ok {
-								missingFields = append(missingFields, missing)
-								if prop, ok := properties[missing].(map[string]any); ok {
-									desc, _ := prop["description"].(string)
-									typeStr, _ := prop["type"].(string)
-									info := ""
-									if desc != "" {
-										info = desc
-									}
-									if typeStr != "" {
-										if info != "" {
-											info += ", "
-										}
-										info += "type: " + typeStr
-									}
-									if info != "" {
-										errMsg = "Missing required parameter: '" + missing + "' (" + info + "). Please provide this parameter."
-									} else {
-										errMsg = "Missing required parameter: '" + missing + "'"
-									}
-								} else {
-									errMsg = "Missing required parameter: '" + missing + "'"
-								}
-							}
-						}
-					case "invalid_type":
-						// Convert "Invalid type. Expected: string, given: integer" to plain text
-						errMsg = verr.String()
-					case "enum":
-						// Convert enum validation errors to plain text
-						errMsg = verr.String()
-					case "invalid_union", "one_of", "any_of":
-						// Convert union/oneOf/anyOf errors to plain text
-						errMsg = "Invalid value. " + verr.String()
-					default:
-						// For any other validation error types, ensure it's plain text
-						errMsg = verr.String()
-					}
-
-					if errMsg != "" {
-						errMsgs += errMsg + "\n"
-					}
-				}
-				// Suggest a retry with an example argument set
-				exampleArgs := map[string]any{}
-				for k, v := range properties {
-					if prop, ok := v.(map[string]any); ok {
-						typeStr, _ := prop["type"].(string)
-						switch typeStr {
-						case "string":
-							exampleArgs[k] = "example"
-						case "number":
-							exampleArgs[k] = 123.45
-						case "integer":
-							exampleArgs[k] = 123
-						case "boolean":
-							exampleArgs[k] = true
-						case "array":
-							exampleArgs[k] = []any{"item1", "item2"}
-						case "object":
-							exampleArgs[k] = map[string]any{"key": "value"}
-						default:
-							exampleArgs[k] = nil
-						}
-					} else {
-						exampleArgs[k] = nil
-					}
-				}
-				suggestionStr := "Try again with: call " + name + " "
-				exampleJSON, _ := json.Marshal(exampleArgs)
-				suggestionStr += string(exampleJSON)
-				suggestions = append(suggestions, suggestionStr)
-
-				// Create a simple text error message
-				errorText := strings.TrimSpace(errMsgs)
-				if len(suggestions) > 0 {
-					errorText += "\n\n" + strings.Join(suggestions, "\n")
-				}
-
-				return mcp.NewToolResultError(
-					errorText,
+				validationErr := newValidationError(name, schemaObj, args, result.Errors())
+				toolResult := mcp.NewToolResultError(
+					validationErr.Text(),
 					inputSchema,
 					args,
 					[]any{args},
 					"call <tool> <json-args>",
 					[]string{"list", "schema <tool>"},
-				), nil
+				)
+				toolResult.StructuredContent = validationErr
+				return toolResult, nil
 			}
 
-			// Build URL path with path parameters
-			path := opCopy.Path
-			for _, paramRef := range opCopy.Parameters {
-				if paramRef == nil || paramRef.Value == nil {
-					continue
-				}
-				p := paramRef.Value
-				if p.In == "path" {
-					if val, ok := getParameterValue(args, p.Name, paramNameMapping); ok {
-						// Check if parameter is integer type
-						isInteger := false
-						if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
-							isInteger = p.Schema.Value.Type.Is("integer")
-						}
-						path = strings.ReplaceAll(path, "{"+p.Name+"}", formatParameterValue(val, isInteger))
-					}
-				}
+			// Inject pinned and defaulted parameter values, which the model does not control
+			callArgs, err := withParameterValues(ctx, opCopy, args, boundParams)
+			if err != nil {
+				return mcp.NewToolResultError(err.Error(), inputSchema, args, nil, "", nil), nil
 			}
-			// Build query parameters
-			query := url.Values{}
-			for _, paramRef := range opCopy.Parameters {
-				if paramRef == nil || paramRef.Value == nil {
-					continue
+
+			// The server is picked once, so that previews and retries show and use the same one
+			baseURL := tmpl.baseURL()
+			prepare := func(ctx context.Context) (*preparedRequest, error) {
+				prepared, err := buildOperationRequest(ctx, tmpl, baseURL, callArgs)
+				if err != nil {
+					return nil, err
 				}
-				p := paramRef.Value
-				if p.In == "query" {
-					if val, ok := getParameterValue(args, p.Name, paramNameMapping); ok {
-						// Check if parameter is integer type
-						isInteger := false
-						if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
-							isInteger = p.Schema.Value.Type.Is("integer")
-						}
-						query.Set(p.Name, formatParameterValue(val, isInteger))
+				if opts != nil {
+					if err := applyRequestInterceptors(ctx, opts.RequestInterceptors, opCopy, prepared); err != nil {
+						return nil, err
 					}
 				}
+				if err := prepared.sign(); err != nil {
+					return nil, err
+				}
+				return prepared, nil
 			}
 
-			baseURLs := []string{}
-			if os.Getenv("OPENAPI_BASE_URL") != "" {
-				baseURLs = append(baseURLs, os.Getenv("OPENAPI_BASE_URL"))
-			} else if opCopy.Servers != nil && len(opCopy.Servers) > 0 {
-				for _, s := range opCopy.Servers {
-					if s != nil && s.URL != "" {
-						baseURLs = append(baseURLs, s.URL)
-					}
+			// Preview mode: return the fully resolved request without sending it. Credentials that aren't
+			// at hand are shown as placeholders rather than fetched.
+			if isTruthy(args[previewArgument]) {
+				prepared, err := prepare(withPreview(ctx))
+				if err != nil {
+					return mcp.NewToolResultError(err.Error(), inputSchema, args, nil, "", nil), nil
 				}
-			} else if doc.Servers != nil && len(doc.Servers) > 0 {
-				for _, s := range doc.Servers {
-					if s != nil && s.URL != "" {
-						baseURLs = append(baseURLs, s.URL)
-					}
+				previewObj := prepared.preview()
+				previewObj["type"] = "request_preview"
+				previewObj["operation"] = opCopy.OperationID
+				if prepared.missing != nil {
+					previewObj["missingCredentials"] = prepared.missing.Alternatives
 				}
-			} else {
-				baseURLs = append(baseURLs, "http://localhost:8080")
-			}
-
-			// Pick a random baseURL for each call using the global rand
-			baseURL := baseURLs[rand.Intn(len(baseURLs))]
-			fullURL, err := url.JoinPath(baseURL, path)
-			if err != nil {
-				return nil, err
-			}
-			if len(query) > 0 {
-				fullURL += "?" + query.Encode()
-			}
-			// Build request body if needed
-			var body []byte
-			var requestContentType string
-			if opCopy.RequestBody != nil && opCopy.RequestBody.Value != nil {
-				// Check for application/json first, then application/vnd.api+json (including with parameters)
-				mt := getContentByType(opCopy.RequestBody.Value.Content, "application/json")
-				if mt != nil {
-					requestContentType = "application/json"
-				} else {
-					mt = getContentByType(opCopy.RequestBody.Value.Content, "application/vnd.api+json")
-					if mt != nil {
-						requestContentType = "application/vnd.api+json"
-					}
+				if len(prepared.unresolved) > 0 {
+					previewObj["unresolvedCredentials"] = prepared.unresolved
 				}
+				previewJSON, _ := json.MarshalIndent(previewObj, "", "  ")
+				return &mcp.CallToolResult{
+					Content: []mcp.Content{
+						mcp.TextContent{
+							Type: "text",
+							Text: string(previewJSON),
+						},
+					},
+					Schema:       inputSchema,
+					Arguments:    args,
+					Usage:        "call <tool> <json-args>",
+					NextSteps:    []string{"call " + name + " without " + previewArgument + " to send the request"},
+					OutputFormat: "structured",
+					OutputType:   "json",
+				}, nil
+			}
 
-				if mt != nil && mt.Schema != nil && mt.Schema.Value != nil {
-					if v, ok := args["requestBody"]; ok && v != nil {
-						body, _ = json.Marshal(v)
-					}
+			// Dangerous operations are not sent until the call is confirmed. Resuming the wait for one
+			// that was already sent sends nothing.
+			_, resuming := args[asyncHandleArgument].(string)
+			resuming = resuming && waiter != nil
+			if (opts == nil || opts.ConfirmDangerousActions) && isDangerousOperation(opCopy) && !resuming {
+				if _, confirmed := args["__confirmed"]; !confirmed {
+					confirmText := fmt.Sprintf("⚠️  CONFIRMATION REQUIRED\n\nAction: %s\nThis action is irreversible. Proceed?\n\nTo confirm, retry the call with {\"__confirmed\": true} added to your arguments.", name)
+					return &mcp.CallToolResult{
+						Content: []mcp.Content{
+							mcp.TextContent{
+								Type: "text",
+								Text: confirmText,
+							},
+						},
+						OutputFormat: "unstructured",
+						OutputType:   "text",
+					}, nil
 				}
 			}
-			// Build HTTP request
-			method := strings.ToUpper(opCopy.Method)
-			httpReq, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewReader(body))
+
+			// Requests that can't be built are reported to the model, which may be able to fix them
+			prepared, err := prepare(ctx)
 			if err != nil {
-				return nil, err
+				return mcp.NewToolResultError(err.Error(), inputSchema, args, nil, "", nil), nil
 			}
-			if len(body) > 0 && requestContentType != "" {
-				httpReq.Header.Set("Content-Type", requestContentType)
-			}
-			// Set Accept header to accept both JSON and JSON:API responses
-			httpReq.Header.Set("Accept", "application/json, application/vnd.api+json")
-			// --- AUTH HANDLING: inject per-operation security requirements ---
-			// For each security requirement object, try to satisfy at least one scheme
-			securitySatisfied := false
-			for _, secReq := range opCopy.Security {
-				for secName := range secReq {
-					if doc.Components != nil && doc.Components.SecuritySchemes != nil {
-						if secSchemeRef, ok := doc.Components.SecuritySchemes[secName]; ok && secSchemeRef.Value != nil {
-							secScheme := secSchemeRef.Value
-							switch secScheme.Type {
-							case "http":
-								if secScheme.Scheme == "bearer" {
-									if bearer := os.Getenv("BEARER_TOKEN"); bearer != "" {
-										httpReq.Header.Set("Authorization", "Bearer "+bearer)
-										securitySatisfied = true
-									}
-								} else if secScheme.Scheme == "basic" {
-									if basic := os.Getenv("BASIC_AUTH"); basic != "" {
-										encoded := base64.StdEncoding.EncodeToString([]byte(basic))
-										httpReq.Header.Set("Authorization", "Basic "+encoded)
-										securitySatisfied = true
-									}
-								}
-							case "apiKey":
-								if secScheme.In == "header" && secScheme.Name != "" {
-									if apiKey := os.Getenv("API_KEY"); apiKey != "" {
-										httpReq.Header.Set(secScheme.Name, apiKey)
-										securitySatisfied = true
-									}
-								} else if secScheme.In == "query" && secScheme.Name != "" {
-									if apiKey := os.Getenv("API_KEY"); apiKey != "" {
-										q := httpReq.URL.Query()
-										q.Set(secScheme.Name, apiKey)
-										httpReq.URL.RawQuery = q.Encode()
-										securitySatisfied = true
-									}
-								} else if secScheme.In == "cookie" && secScheme.Name != "" {
-									if apiKey := os.Getenv("API_KEY"); apiKey != "" {
-										cookie := httpReq.Header.Get("Cookie")
-										if cookie != "" {
-											cookie += "; "
-										}
-										cookie += secScheme.Name + "=" + apiKey
-										httpReq.Header.Set("Cookie", cookie)
-										securitySatisfied = true
-									}
-								}
-							case "oauth2":
-								if bearer := os.Getenv("BEARER_TOKEN"); bearer != "" {
-									httpReq.Header.Set("Authorization", "Bearer "+bearer)
-									securitySatisfied = true
-								}
-							}
-						}
-					}
-				}
+			httpReq, body, fullURL := prepared.req, prepared.body, prepared.url
+
+			// Requests that cannot be authenticated are not sent (mock responses need no credentials)
+			if prepared.missing != nil && (opts == nil || !opts.Mock) {
+				missing := *prepared.missing
+				missing.Tool = name
+				toolResult := mcp.NewToolResultError(missing.Error(), inputSchema, args, nil, "", nil)
+				toolResult.StructuredContent = &missing
+				return toolResult, nil
 			}
-			// If no security requirements, fallback to legacy env handling (for backward compatibility)
-			if !securitySatisfied {
-				if apiKey := os.Getenv("API_KEY"); apiKey != "" {
-					httpReq.Header.Set(apiKeyHeader, apiKey)
-				}
-				if bearer := os.Getenv("BEARER_TOKEN"); bearer != "" {
-					httpReq.Header.Set("Authorization", "Bearer "+bearer)
-				} else if basic := os.Getenv("BASIC_AUTH"); basic != "" {
-					encoded := base64.StdEncoding.EncodeToString([]byte(basic))
-					httpReq.Header.Set("Authorization", "Basic "+encoded)
-				}
+
+			// Log HTTP request if logging is enabled
+			if os.Getenv("MCP_LOG_HTTP") != "" || os.Getenv("DEBUG") != "" {
+				logHTTPRequest(httpReq, body)
 			}
-			// Add header parameters
-			for _, paramRef := range opCopy.Parameters {
-				if paramRef == nil || paramRef.Value == nil {
-					continue
+
+			var resp *http.Response
+			var progress *callProgress
+			origin, continued := httpReq.URL, false
+			if opts != nil && opts.Mock {
+				resp, err = mockResponse(opCopy, httpReq, requestedMockStatus(args, httpReq))
+				if errors.Is(err, errUndocumentedMockStatus) {
+					return mcp.NewToolResultError(err.Error(), inputSchema, args, nil, "", nil), nil
 				}
-				p := paramRef.Value
-				if p.In == "header" {
-					if val, ok := getParameterValue(args, p.Name, paramNameMapping); ok {
-						// Check if parameter is integer type
-						isInteger := false
-						if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
-							isInteger = p.Schema.Value.Type.Is("integer")
-						}
-						httpReq.Header.Set(p.Name, formatParameterValue(val, isInteger))
+			} else {
+				progress = newCallProgress(ctx)
+				var outcome *asyncOutcome
+				if resuming {
+					// Resume waiting for an operation started by an earlier call, instead of starting another
+					outcome, err = waiter.resume(ctx, args[asyncHandleArgument].(string), callArgs, progress)
+					if errors.Is(err, errUnknownAsyncHandle) {
+						return mcp.NewToolResultError(err.Error(), inputSchema, args, nil, "", nil), nil
 					}
-				}
-			}
-			// Add cookie parameters (RFC 6265)
-			var cookiePairs []string
-			for _, paramRef := range opCopy.Parameters {
-				if paramRef == nil || paramRef.Value == nil {
-					continue
-				}
-				p := paramRef.Value
-				if p.In == "cookie" {
-					if val, ok := getParameterValue(args, p.Name, paramNameMapping); ok {
-						// Check if parameter is integer type
-						isInteger := false
-						if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
-							isInteger = p.Schema.Value.Type.Is("integer")
+				} else if token, continuing := args[nextPageArgument].(string); continuing && lister != nil {
+					// Continue a listing at the next-page URL where an earlier call stopped
+					progress.step(fmt.Sprintf("Calling %s %s, continuing an earlier listing", opCopy.Method, opCopy.Path))
+					var page nextPage
+					resp, page, err = lister.resume(ctx, token, callArgs)
+					if errors.Is(err, errUnknownNextPage) {
+						return mcp.NewToolResultError(err.Error(), inputSchema, args, nil, "", nil), nil
+					}
+					origin, fullURL, continued = page.origin, page.url, true
+				} else {
+					progress.step(fmt.Sprintf("Calling %s %s", opCopy.Method, opCopy.Path))
+					progress.upload(httpReq)
+					resp, err = httpClient.Do(httpReq)
+					// Credentials may be revoked or rotated before they expire: retry once with fresh ones
+					if err == nil && resp.StatusCode == http.StatusUnauthorized && prepared.invalidate(tmpl) {
+						resp.Body.Close()
+						progress.step("Retrying with refreshed credentials after HTTP 401")
+						if prepared, err = prepare(ctx); err != nil {
+							return mcp.NewToolResultError(err.Error(), inputSchema, args, nil, "", nil), nil
 						}
-						cookiePairs = append(cookiePairs, fmt.Sprintf("%s=%s", p.Name, formatParameterValue(val, isInteger)))
+						httpReq, body, fullURL = prepared.req, prepared.body, prepared.url
+						origin = httpReq.URL
+						progress.upload(httpReq)
+						resp, err = httpClient.Do(httpReq)
+					}
+					if err == nil && resp.StatusCode == http.StatusAccepted && waiter != nil {
+						outcome, err = waiter.accepted(ctx, httpReq, resp, callArgs, progress)
 					}
 				}
-			}
-			if len(cookiePairs) > 0 {
-				httpReq.Header.Set("Cookie", strings.Join(cookiePairs, "; "))
-			}
-
-			// Add custom headers from environment variable
-			if customHeaders := os.Getenv("CUSTOM_HEADERS"); customHeaders != "" {
-				// Split headers by delimiter
-				headerPairs := strings.Split(customHeaders, "|HEADER_DELIMITER|")
-				for _, headerPair := range headerPairs {
-					// Parse header in format "Key: Value"
-					if colonIndex := strings.Index(headerPair, ":"); colonIndex > 0 {
-						key := strings.TrimSpace(headerPair[:colonIndex])
-						value := strings.TrimSpace(headerPair[colonIndex+1:])
-						if key != "" && value != "" {
-							httpReq.Header.Set(key, value)
-						}
+				if err == nil && outcome != nil {
+					if result := outcome.result(name, opCopy, fullURL, inputSchema, args); result != nil {
+						return result, nil
 					}
+					resp = outcome.resp
 				}
-			}
-
-			// Add custom headers from client request
-			if clientHeaders, ok := ctx.Value(mcpserver.ClientHeadersKey{}).(map[string]string); ok {
-				for key, value := range clientHeaders {
-					httpReq.Header.Set(key, value)
+				// Streaming responses report their events rather than their bytes
+				if err == nil && streamFormat(resp.Header.Get("Content-Type")) == "" {
+					progress.download(resp)
 				}
 			}
-
-			// Log HTTP request if logging is enabled
-			if os.Getenv("MCP_LOG_HTTP") != "" || os.Getenv("DEBUG") != "" {
-				logHTTPRequest(httpReq, body)
-			}
-
-			resp, err := http.DefaultClient.Do(httpReq)
 			if err != nil {
 				return nil, err
 			}
 			defer resp.Body.Close()
+
+			// Relay streaming responses (SSE, NDJSON) as they arrive instead of waiting for their end
+			if format := streamFormat(resp.Header.Get("Content-Type")); format != "" && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				summary := relayStream(ctx, server, name, format, resp, stream, progress)
+				return summary.result(opCopy.Method, fullURL, resp.StatusCode, stream, inputSchema, args), nil
+			}
 			respBody, _ := io.ReadAll(resp.Body)
 
 			// Log HTTP response if logging is enabled
@@ -1321,10 +1300,24 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				logHTTPResponse(resp, respBody)
 			}
 
+			if opts != nil {
+				respBody, err = applyResponseInterceptors(ctx, opts.ResponseInterceptors, opCopy, resp, respBody)
+				if err != nil {
+					return mcp.NewToolResultError(err.Error(), inputSchema, args, nil, "", nil), nil
+				}
+			}
+
+			// Fetch the following pages of a list and return the items of all pages
+			if lister != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				if list := lister.collect(ctx, origin, baseURL, resp, respBody, callArgs, continued, progress); list != nil {
+					return list.result(name, opCopy.Method, fullURL, resp.StatusCode, pagination, inputSchema, args), nil
+				}
+			}
+
 			contentType := resp.Header.Get("Content-Type")
 			isJSON := strings.HasPrefix(contentType, "application/json") || strings.HasPrefix(contentType, "application/vnd.api+json")
 			isText := strings.HasPrefix(contentType, "text/")
-			isBinary := !isJSON && !isText
+			isBinary := !isJSON && !isText && len(respBody) > 0
 
 			// LLM-friendly error handling for non-2xx responses
 			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
@@ -1332,51 +1325,40 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				if opSummary == "" {
 					opSummary = opCopy.Description
 				}
-				opDesc := opCopy.Description
+				// Binary bodies are returned as content below, not inlined into the text guidance
+				errorBody := string(respBody)
+				if isBinary {
+					errorBody = ""
+				}
 				suggestion := "Check the input parameters, authentication, and consult the tool schema. See the OpenAPI documentation for more details."
 				if resp.StatusCode == 401 || resp.StatusCode == 403 {
-					suggestion = generateAI401403ErrorResponse(opCopy, inputSchemaJSON, args, string(respBody), resp.StatusCode)
+					suggestion = generateAI401403ErrorResponse(opCopy, doc, inputSchemaJSON, args, errorBody, resp.StatusCode)
 				} else if resp.StatusCode == 404 {
-					suggestion = generateAI404ErrorResponse(opCopy, inputSchemaJSON, args, string(respBody))
+					suggestion = generateAI404ErrorResponse(opCopy, inputSchemaJSON, args, errorBody)
 				} else if resp.StatusCode == 400 {
-					suggestion = generateAI400ErrorResponse(opCopy, inputSchemaJSON, args, string(respBody))
+					suggestion = generateAI400ErrorResponse(opCopy, inputSchemaJSON, args, errorBody)
 				} else if resp.StatusCode >= 500 {
-					suggestion = generateAI5xxErrorResponse(opCopy, inputSchemaJSON, args, string(respBody), resp.StatusCode)
+					suggestion = generateAI5xxErrorResponse(opCopy, inputSchemaJSON, args, errorBody, resp.StatusCode)
 				}
-				// For binary error responses, include base64 and mime type
+				// For binary error responses, return the body as native MCP content
 				if isBinary {
-					fileBase64 := base64.StdEncoding.EncodeToString(respBody)
-					fileName := "file"
-					if cd := resp.Header.Get("Content-Disposition"); cd != "" {
-						if parts := strings.Split(cd, "filename="); len(parts) > 1 {
-							fileName = strings.Trim(parts[1], `"`)
-						}
+					fileName := parseContentDispositionFilename(resp.Header.Get("Content-Disposition"))
+					if fileName == "" {
+						fileName = "file"
 					}
-					errorObj := map[string]any{
-						"type": "api_response",
-						"error": map[string]any{
-							"code":        "http_error",
-							"http_status": resp.StatusCode,
-							"message":     fmt.Sprintf("%s (HTTP %d)", http.StatusText(resp.StatusCode), resp.StatusCode),
-							"details":     "Binary response (see file_base64)",
-							"suggestion":  suggestion,
-							"mime_type":   contentType,
-							"file_base64": fileBase64,
-							"file_name":   fileName,
-							"operation": map[string]any{
-								"id":          opCopy.OperationID,
-								"summary":     opSummary,
-								"description": opDesc,
-							},
-						},
+					fileContent, outputType := binaryResponseContent(ctx, server, contentType, fileName, respBody, maxInlineBinary)
+					errorText := fmt.Sprintf("HTTP %s %s\nError: %s (HTTP %d)\nBinary response: %s (%s, %d bytes)", opCopy.Method, fullURL, http.StatusText(resp.StatusCode), resp.StatusCode, fileName, contentType, len(respBody))
+					if suggestion != "" {
+						errorText += "\nSuggestion: " + suggestion
 					}
-					errorJSON, _ := json.MarshalIndent(errorObj, "", "  ")
+					errorText += fmt.Sprintf("\nOperation: %s (%s)", opCopy.OperationID, opSummary)
 					return &mcp.CallToolResult{
 						Content: []mcp.Content{
 							mcp.TextContent{
-								Type: "json",
-								Text: string(errorJSON),
+								Type: "text",
+								Text: errorText,
 							},
+							fileContent,
 						},
 						IsError:      true,
 						Schema:       inputSchema,
@@ -1385,7 +1367,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 						Usage:        "call <tool> <json-args>",
 						NextSteps:    []string{"list", "schema <tool>"},
 						OutputFormat: "structured",
-						OutputType:   "file",
+						OutputType:   outputType,
 					}, nil
 				}
 				// Create a simple text error message
@@ -1410,32 +1392,19 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 
 			// Handle binary/file responses for success
 			if isBinary && resp.StatusCode >= 200 && resp.StatusCode < 300 {
-				fileBase64 := base64.StdEncoding.EncodeToString(respBody)
-				fileName := "file"
-				if cd := resp.Header.Get("Content-Disposition"); cd != "" {
-					if parts := strings.Split(cd, "filename="); len(parts) > 1 {
-						fileName = strings.Trim(parts[1], `"`)
-					}
-				}
-				resultObj := map[string]any{
-					"type":        "api_response",
-					"http_status": resp.StatusCode,
-					"mime_type":   contentType,
-					"file_base64": fileBase64,
-					"file_name":   fileName,
-					"operation": map[string]any{
-						"id":          opCopy.OperationID,
-						"summary":     opCopy.Summary,
-						"description": opCopy.Description,
-					},
+				fileName := parseContentDispositionFilename(resp.Header.Get("Content-Disposition"))
+				if fileName == "" {
+					fileName = "file"
 				}
-				resultJSON, _ := json.MarshalIndent(resultObj, "", "  ")
+				fileContent, outputType := binaryResponseContent(ctx, server, contentType, fileName, respBody, maxInlineBinary)
+				summaryText := fmt.Sprintf("HTTP %s %s\nStatus: %d\nBinary response: %s (%s, %d bytes)", opCopy.Method, fullURL, resp.StatusCode, fileName, contentType, len(respBody))
 				return &mcp.CallToolResult{
 					Content: []mcp.Content{
 						mcp.TextContent{
-							Type: "json",
-							Text: string(resultJSON),
+							Type: "text",
+							Text: summaryText,
 						},
+						fileContent,
 					},
 					Schema:       inputSchema,
 					Arguments:    args,
@@ -1443,7 +1412,7 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					Usage:        "call <tool> <json-args>",
 					NextSteps:    []string{"list", "schema <tool>"},
 					OutputFormat: "structured",
-					OutputType:   "file",
+					OutputType:   outputType,
 				}, nil
 			}
 
@@ -1493,21 +1462,6 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 					OutputType:   "text",
 				}, nil
 			}
-			if (opts == nil || opts.ConfirmDangerousActions) && (method == "PUT" || method == "POST" || method == "DELETE") {
-				if _, confirmed := args["__confirmed"]; !confirmed {
-					confirmText := fmt.Sprintf("⚠️  CONFIRMATION REQUIRED\n\nAction: %s\nThis action is irreversible. Proceed?\n\nTo confirm, retry the call with {\"__confirmed\": true} added to your arguments.", name)
-					return &mcp.CallToolResult{
-						Content: []mcp.Content{
-							mcp.TextContent{
-								Type: "text",
-								Text: confirmText,
-							},
-						},
-						OutputFormat: "unstructured",
-						OutputType:   "text",
-					}, nil
-				}
-			}
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					mcp.TextContent{
@@ -1523,7 +1477,47 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 				OutputFormat: "unstructured",
 				OutputType:   "text",
 			}, nil
-		})
+		}
+		if opts != nil && opts.CoerceArguments {
+			inner := handler
+			handler = func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				args, changes := coerceArguments(schemaObj, req.GetArguments())
+				if len(changes) == 0 {
+					return inner(ctx, req)
+				}
+				req.Params.Arguments = args
+				result, err := inner(ctx, req)
+				if err != nil || result == nil {
+					return result, err
+				}
+				result.Content = append(result.Content, mcp.TextContent{
+					Type: "text",
+					Text: "NOTE: Some arguments were converted to match the input schema:\n- " + strings.Join(changes, "\n- "),
+				})
+				return result, nil
+			}
+		}
+		if opts != nil && len(opts.ResultInterceptors) > 0 {
+			inner := handler
+			handler = func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				result, err := inner(ctx, req)
+				if err != nil || result == nil {
+					return result, err
+				}
+				return applyResultInterceptors(ctx, opts.ResultInterceptors, opCopy, result)
+			}
+		}
+		if policy != nil {
+			policy.register(name, op)
+			inner := handler
+			handler = func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				if denied := policy.authorizeCall(ctx, name); denied != nil {
+					return denied, nil
+				}
+				return inner(ctx, req)
+			}
+		}
+		server.AddTool(tool, handler)
 		toolNames = append(toolNames, name)
 	}
 
@@ -1618,7 +1612,11 @@ func RegisterOpenAPITools(server *mcpserver.MCPServer, ops []OpenAPIOperation, d
 		server.AddTool(describeTool, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			// Gather all tools and their schemas
 			tools := []map[string]any{}
-			for _, tool := range server.ListTools() {
+			available := server.ListTools()
+			if policy != nil {
+				available = policy.FilterTools(ctx, available)
+			}
+			for _, tool := range available {
 				toolInfo := map[string]any{
 					"name":         tool.Name,
 					"description":  tool.Description,
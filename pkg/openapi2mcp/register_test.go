@@ -1,10 +1,12 @@
 package openapi2mcp
 
 import (
+	"context"
 	"strings"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
 	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
 )
 
@@ -33,6 +35,40 @@ func minimalOpenAPIDoc() *openapi3.T {
 	}
 }
 
+// newTestServer registers the tools of a YAML or JSON spec on a new MCP server.
+func newTestServer(t *testing.T, spec string, opts *ToolGenOptions, serverOpts ...server.ServerOption) *server.MCPServer {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	srv := server.NewMCPServer("test", "1.0.0", serverOpts...)
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, opts)
+	return srv
+}
+
+// callTool calls a tool with JSON arguments as identity (nil for an unauthenticated client).
+func callTool(t *testing.T, srv *server.MCPServer, identity *Identity, tool, args string) mcp.CallToolResult {
+	t.Helper()
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"` + tool + `","arguments":` + args + `}}`)
+	resp, ok := srv.HandleMessage(ContextWithIdentity(context.Background(), identity), msg).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a JSON-RPC response for %s %s", tool, args)
+	}
+	return resp.Result.(mcp.CallToolResult)
+}
+
+// resultText joins the text contents of a tool result.
+func resultText(result mcp.CallToolResult) string {
+	var texts []string
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
 func toolSetEqual(a, b []string) bool {
 	if len(a) != len(b) {
 		return false
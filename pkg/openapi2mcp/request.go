@@ -0,0 +1,410 @@
+// request.go
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	mcpserver "github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// preparedRequest is a fully built upstream HTTP request for an OpenAPI operation.
+type preparedRequest struct {
+	req  *http.Request
+	body []byte
+	// url is the request URL before credentials were injected, safe to show to the model
+	url string
+	// secretHeaders and secretQuery name the headers and query parameters that carry credentials
+	secretHeaders []string
+	secretQuery   []string
+	// credentials are the resolved credentials, invalidated when the API rejects them
+	credentials []*schemeCredential
+	// missing is set when no security requirement could be satisfied; the request is then not sent
+	missing *MissingCredentialsError
+	// signatures are computed by sign, once the request is final
+	signatures []*schemeCredential
+	// unresolved names the security schemes whose credentials a preview shows as placeholders
+	unresolved []string
+}
+
+// previewKey is the context key marking the requests built for a preview.
+type previewKey struct{}
+
+// withPreview marks the requests built with ctx as previews, which must not have side effects such as
+// fetching OAuth 2.0 tokens or running credential helpers.
+func withPreview(ctx context.Context) context.Context {
+	return context.WithValue(ctx, previewKey{}, true)
+}
+
+// isPreview reports whether requests built with ctx are previews.
+func isPreview(ctx context.Context) bool {
+	preview, _ := ctx.Value(previewKey{}).(bool)
+	return preview
+}
+
+// errCredentialNotResolved is returned in previews for credentials that can't be obtained without side effects.
+var errCredentialNotResolved = errors.New("credential not resolved yet")
+
+// credentialPlaceholder stands for the credential of a security scheme in previews, until it is obtained.
+func credentialPlaceholder(scheme string) string {
+	return "<" + scheme + " credential>"
+}
+
+// buildOperationRequest builds the HTTP request for an operation from validated tool arguments:
+// path, query, header and cookie parameters, the JSON request body, authentication and custom headers.
+// It is the single request-building code path shared by tool execution and request previews.
+// baseURL is the server the request is sent to (see operationTemplate.baseURL).
+func buildOperationRequest(ctx context.Context, tmpl *operationTemplate, baseURL string, args map[string]any) (*preparedRequest, error) {
+	op := tmpl.op
+	// Build URL path with path parameters
+	path := op.Path
+	for _, p := range tmpl.pathParams {
+		if val, ok := p.value(args); ok {
+			path = strings.ReplaceAll(path, "{"+p.name+"}", formatParameterValue(val, p.isInteger))
+		}
+	}
+	// Build query parameters
+	query := url.Values{}
+	for _, p := range tmpl.queryParams {
+		if val, ok := p.value(args); ok {
+			query.Set(p.name, formatParameterValue(val, p.isInteger))
+		}
+	}
+
+	fullURL, err := url.JoinPath(baseURL, path)
+	if err != nil {
+		return nil, err
+	}
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
+	}
+	// Build request body if needed
+	var body []byte
+	if tmpl.requestContentType != "" {
+		if v, ok := args["requestBody"]; ok && v != nil {
+			body, _ = json.Marshal(v)
+		}
+	}
+	// Build HTTP request
+	method := strings.ToUpper(op.Method)
+	httpReq, err := http.NewRequestWithContext(ctx, method, fullURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > 0 {
+		httpReq.Header.Set("Content-Type", tmpl.requestContentType)
+	}
+	// Set Accept header to accept both JSON and JSON:API responses
+	httpReq.Header.Set("Accept", "application/json, application/vnd.api+json")
+	var cookiePairs []string
+	prepared, err := authenticateRequest(ctx, tmpl, httpReq, &cookiePairs)
+	if err != nil {
+		return nil, err
+	}
+	// Add header parameters
+	for _, p := range tmpl.headerParams {
+		if val, ok := p.value(args); ok {
+			httpReq.Header.Set(p.name, formatParameterValue(val, p.isInteger))
+		}
+	}
+	// Add cookie parameters (RFC 6265)
+	for _, p := range tmpl.cookieParams {
+		if val, ok := p.value(args); ok {
+			cookiePairs = append(cookiePairs, fmt.Sprintf("%s=%s", p.name, formatParameterValue(val, p.isInteger)))
+		}
+	}
+	if len(cookiePairs) > 0 {
+		httpReq.Header.Set("Cookie", strings.Join(cookiePairs, "; "))
+	}
+	prepared.secretHeaders = append(prepared.secretHeaders, applyCustomHeaders(ctx, httpReq)...)
+
+	// Carry the credential names with the request so that transports such as the HAR recorder can redact them
+	prepared.req = httpReq.WithContext(withRequestSecrets(ctx, prepared.secretHeaders, prepared.secretQuery))
+	prepared.body = body
+	prepared.url = fullURL
+	return prepared, nil
+}
+
+// buildFollowUpRequest builds a GET request for a URL returned by the API, such as the status monitor
+// of an asynchronous operation. Relative URLs are resolved against origin, the URL of the operation's
+// request. Credentials, header parameters and custom headers are only sent to the origin's host, so
+// that an API response cannot send them elsewhere.
+func buildFollowUpRequest(ctx context.Context, tmpl *operationTemplate, origin *url.URL, ref string, args map[string]any) (*preparedRequest, error) {
+	u, err := origin.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q returned by the API: %w", ref, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL %q returned by the API", ref)
+	}
+	fullURL := u.String()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json, application/vnd.api+json")
+	prepared := &preparedRequest{}
+	if u.Scheme == origin.Scheme && strings.EqualFold(u.Host, origin.Host) {
+		var cookiePairs []string
+		if prepared, err = authenticateRequest(ctx, tmpl, httpReq, &cookiePairs); err != nil {
+			return nil, err
+		}
+		for _, p := range tmpl.headerParams {
+			if val, ok := p.value(args); ok {
+				httpReq.Header.Set(p.name, formatParameterValue(val, p.isInteger))
+			}
+		}
+		if len(cookiePairs) > 0 {
+			httpReq.Header.Set("Cookie", strings.Join(cookiePairs, "; "))
+		}
+		prepared.secretHeaders = append(prepared.secretHeaders, applyCustomHeaders(ctx, httpReq)...)
+	}
+	prepared.req = httpReq.WithContext(withRequestSecrets(ctx, prepared.secretHeaders, prepared.secretQuery))
+	prepared.url = fullURL
+	return prepared, nil
+}
+
+// sendFollowUpRequest sends a request made by build after the operation's own, such as a status poll
+// or the next page of a list, through the request interceptors. After a 401, the request is rebuilt
+// with fresh credentials and sent once more.
+func sendFollowUpRequest(ctx context.Context, client *http.Client, tmpl *operationTemplate, interceptors []RequestInterceptor, build func() (*preparedRequest, error)) (*http.Response, error) {
+	for retried := false; ; retried = true {
+		prepared, err := build()
+		if err != nil {
+			return nil, err
+		}
+		if err := applyRequestInterceptors(ctx, interceptors, tmpl.op, prepared); err != nil {
+			return nil, err
+		}
+		if err := prepared.sign(); err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(prepared.req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || retried || !prepared.invalidate(tmpl) {
+			return resp, nil
+		}
+		resp.Body.Close()
+	}
+}
+
+// authenticateRequest adds the credentials of one of the operation's security requirements
+// to a request (cookies are appended to cookiePairs), or the legacy environment credentials
+// for operations without security requirements. Signatures are left to sign.
+func authenticateRequest(ctx context.Context, tmpl *operationTemplate, httpReq *http.Request, cookiePairs *[]string) (*preparedRequest, error) {
+	creds, err := tmpl.resolveSecurity(ctx)
+	var missing *MissingCredentialsError
+	if errors.As(err, &missing) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prepared := &preparedRequest{credentials: creds, missing: missing}
+	for _, cred := range creds {
+		if cred.placeholder {
+			prepared.unresolved = append(prepared.unresolved, cred.name)
+		}
+		if cred.signer != nil {
+			prepared.signatures = append(prepared.signatures, cred)
+			continue
+		}
+		cred.apply(httpReq, cookiePairs)
+		if cred.value == "" {
+			continue
+		}
+		if cred.scheme.Type == "apiKey" && cred.scheme.In == "header" {
+			prepared.secretHeaders = append(prepared.secretHeaders, cred.scheme.Name)
+		} else if cred.scheme.Type == "apiKey" && cred.scheme.In == "query" {
+			prepared.secretQuery = append(prepared.secretQuery, cred.scheme.Name)
+		}
+	}
+	// Operations without security requirements keep the legacy environment variables
+	if len(tmpl.op.Security) == 0 {
+		if bearer := os.Getenv("BEARER_TOKEN"); bearer != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+bearer)
+		} else if basic := os.Getenv("BASIC_AUTH"); basic != "" {
+			encoded := base64.StdEncoding.EncodeToString([]byte(basic))
+			httpReq.Header.Set("Authorization", "Basic "+encoded)
+		}
+	}
+	return prepared, nil
+}
+
+// applyCustomHeaders adds the CUSTOM_HEADERS environment headers and the headers forwarded by the MCP client.
+// It returns the names of the headers set, which often carry credentials (e.g. --header "X-API-Key: ...")
+// and are redacted like them.
+func applyCustomHeaders(ctx context.Context, httpReq *http.Request) []string {
+	var names []string
+	// Add custom headers from environment variable
+	if customHeaders := os.Getenv("CUSTOM_HEADERS"); customHeaders != "" {
+		// Split headers by delimiter
+		headerPairs := strings.Split(customHeaders, "|HEADER_DELIMITER|")
+		for _, headerPair := range headerPairs {
+			// Parse header in format "Key: Value"
+			if colonIndex := strings.Index(headerPair, ":"); colonIndex > 0 {
+				key := strings.TrimSpace(headerPair[:colonIndex])
+				value := strings.TrimSpace(headerPair[colonIndex+1:])
+				if key != "" && value != "" {
+					httpReq.Header.Set(key, value)
+					names = append(names, key)
+				}
+			}
+		}
+	}
+
+	// Add custom headers from client request
+	if clientHeaders, ok := ctx.Value(mcpserver.ClientHeadersKey{}).(map[string]string); ok {
+		for key, value := range clientHeaders {
+			if isParameterOverrideHeader(key) || strings.EqualFold(key, oauth2TenantHeader) {
+				continue
+			}
+			httpReq.Header.Set(key, value)
+			names = append(names, key)
+		}
+	}
+	return names
+}
+
+// sign computes the signatures of signed security schemes over the final request.
+// It must run after request interceptors, which may still change the request.
+func (p *preparedRequest) sign() error {
+	for _, cred := range p.signatures {
+		// A placeholder can't sign previews
+		if cred.placeholder {
+			continue
+		}
+		if err := cred.signer.SignRequest(p.req, p.body, cred.value); err != nil {
+			return fmt.Errorf("signing the request for security scheme %q: %w", cred.name, err)
+		}
+	}
+	return nil
+}
+
+// invalidate drops the cached OAuth 2.0 tokens and refreshable credentials used by the request,
+// after the API rejected them. It reports whether a retry may use different credentials.
+func (p *preparedRequest) invalidate(tmpl *operationTemplate) bool {
+	invalidated := false
+	for _, cred := range p.credentials {
+		if cred.oauth2Key != "" {
+			tmpl.oauth2.invalidate(cred.oauth2Key, cred.value)
+			invalidated = true
+		} else if source, ok := cred.source.(interface{ Invalidate(string) }); ok {
+			source.Invalidate(cred.value)
+			invalidated = true
+		}
+	}
+	return invalidated
+}
+
+// redactedValue replaces credentials in request previews and logs.
+const redactedValue = "[REDACTED]"
+
+// isSensitiveHeader reports whether a header always carries credentials.
+func isSensitiveHeader(name string) bool {
+	switch strings.ToLower(name) {
+	case "authorization", "proxy-authorization", "cookie", "x-amz-security-token":
+		return true
+	}
+	return false
+}
+
+// redactedHeaders returns the request headers with credential values replaced.
+func (p *preparedRequest) redactedHeaders() map[string]string {
+	headers := make(map[string]string, len(p.req.Header))
+	for name, values := range p.req.Header {
+		value := strings.Join(values, ", ")
+		if isSensitiveHeader(name) {
+			value = redactedValue
+		}
+		for _, secret := range p.secretHeaders {
+			if strings.EqualFold(name, secret) {
+				value = redactedValue
+			}
+		}
+		headers[name] = value
+	}
+	return headers
+}
+
+// redactedQuery returns the request query parameters with credential values replaced.
+func (p *preparedRequest) redactedQuery() url.Values {
+	query := p.req.URL.Query()
+	for _, secret := range p.secretQuery {
+		if query.Has(secret) {
+			query.Set(secret, redactedValue)
+		}
+	}
+	return query
+}
+
+// redactedURL returns the full request URL with credential query parameters replaced.
+func (p *preparedRequest) redactedURL() string {
+	u := *p.req.URL
+	u.RawQuery = p.redactedQuery().Encode()
+	return u.String()
+}
+
+// preview describes the request without sending it: method, URL, query, redacted headers,
+// body and an equivalent curl command.
+func (p *preparedRequest) preview() map[string]any {
+	headers := p.redactedHeaders()
+	query := map[string]any{}
+	for name, values := range p.redactedQuery() {
+		if len(values) == 1 {
+			query[name] = values[0]
+		} else {
+			query[name] = values
+		}
+	}
+	var body any
+	if len(p.body) > 0 {
+		if err := json.Unmarshal(p.body, &body); err != nil {
+			body = string(p.body)
+		}
+	}
+	return map[string]any{
+		"method":  p.req.Method,
+		"url":     p.redactedURL(),
+		"path":    p.req.URL.Path,
+		"query":   query,
+		"headers": headers,
+		"body":    body,
+		"curl":    curlCommand(p.req.Method, p.redactedURL(), headers, p.body),
+	}
+}
+
+// curlCommand renders an equivalent curl command line with shell-quoted arguments.
+func curlCommand(method, rawURL string, headers map[string]string, body []byte) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var cmd strings.Builder
+	cmd.WriteString("curl -X " + method + " " + shellQuote(rawURL))
+	for _, name := range names {
+		cmd.WriteString(" -H " + shellQuote(name+": "+headers[name]))
+	}
+	if len(body) > 0 {
+		cmd.WriteString(" --data-raw " + shellQuote(string(body)))
+	}
+	return cmd.String()
+}
+
+// shellQuote quotes a string for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
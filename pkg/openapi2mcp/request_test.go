@@ -0,0 +1,214 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func TestCurlCommand(t *testing.T) {
+	cmd := curlCommand("POST", "https://api.example.com/items?q=a", map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json",
+	}, []byte(`{"name":"it's"}`))
+	expected := `curl -X POST 'https://api.example.com/items?q=a' -H 'Accept: application/json' -H 'Content-Type: application/json' --data-raw '{"name":"it'\''s"}'`
+	if cmd != expected {
+		t.Errorf("unexpected curl command:\n got: %s\nwant: %s", cmd, expected)
+	}
+}
+
+func TestRequestPreview(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(200)
+	}))
+	defer ts.Close()
+	os.Setenv("OPENAPI_BASE_URL", ts.URL)
+	defer os.Unsetenv("OPENAPI_BASE_URL")
+	os.Setenv("API_KEY", "super-secret")
+	defer os.Unsetenv("API_KEY")
+	os.Setenv("CUSTOM_HEADERS", "X-Static-Key: header-secret")
+	defer os.Unsetenv("CUSTOM_HEADERS")
+
+	paths := openapi3.NewPaths()
+	paths.Set("/items/{id}", &openapi3.PathItem{
+		Put: &openapi3.Operation{
+			OperationID: "updateItem",
+			Parameters: openapi3.Parameters{
+				&openapi3.ParameterRef{Value: &openapi3.Parameter{Name: "id", In: "path", Required: true, Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: typesPtr("integer")}}}},
+				&openapi3.ParameterRef{Value: &openapi3.Parameter{Name: "dry", In: "query", Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: typesPtr("boolean")}}}},
+			},
+			RequestBody: &openapi3.RequestBodyRef{Value: &openapi3.RequestBody{
+				Content: openapi3.Content{
+					"application/json": &openapi3.MediaType{Schema: &openapi3.SchemaRef{Value: &openapi3.Schema{Type: typesPtr("object")}}},
+				},
+			}},
+			Security: &openapi3.SecurityRequirements{{"key": []string{}}},
+		},
+	})
+	doc := &openapi3.T{
+		Info:  &openapi3.Info{Title: "Test API", Version: "1.0.0"},
+		Paths: paths,
+		Components: &openapi3.Components{
+			SecuritySchemes: openapi3.SecuritySchemes{
+				"key": &openapi3.SecuritySchemeRef{Value: &openapi3.SecurityScheme{Type: "apiKey", In: "query", Name: "api_key"}},
+			},
+		},
+	}
+
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{})
+
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"updateItem","arguments":{"id":7,"dry":true,"requestBody":{"name":"x"},"__preview":true}}}`)
+	ctx := context.WithValue(context.Background(), server.ClientHeadersKey{}, map[string]string{"X-Client-Key": "client-secret"})
+	resp, ok := srv.HandleMessage(ctx, msg).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("expected a JSON-RPC response")
+	}
+	result := resp.Result.(mcp.CallToolResult)
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Fatalf("preview must not send the request, upstream got %d hits", hits)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, secret := range []string{"super-secret", "header-secret", "client-secret"} {
+		if strings.Contains(text, secret) {
+			t.Fatalf("preview leaked %s: %s", secret, text)
+		}
+	}
+
+	var preview map[string]any
+	if err := json.Unmarshal([]byte(text), &preview); err != nil {
+		t.Fatalf("preview is not JSON: %v", err)
+	}
+	if preview["method"] != "PUT" {
+		t.Errorf("expected method PUT, got %v", preview["method"])
+	}
+	if u, _ := preview["url"].(string); !strings.HasPrefix(u, ts.URL+"/items/7?") || !strings.Contains(u, "dry=true") {
+		t.Errorf("unexpected preview url: %v", preview["url"])
+	}
+	if query, _ := preview["query"].(map[string]any); query["api_key"] != redactedValue {
+		t.Errorf("expected api_key to be redacted, got %v", preview["query"])
+	}
+	if body, _ := preview["body"].(map[string]any); body["name"] != "x" {
+		t.Errorf("unexpected preview body: %v", preview["body"])
+	}
+	if curl, _ := preview["curl"].(string); !strings.HasPrefix(curl, "curl -X PUT ") || !strings.Contains(curl, "--data-raw") {
+		t.Errorf("unexpected curl command: %v", preview["curl"])
+	}
+}
+
+func TestServerURL(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Regions, version: "1.0"}
+servers:
+  - url: "https://{region}.api.example.com/{version}"
+    variables:
+      region: {default: us, enum: [us, eu]}
+      version: {default: v2}
+  - url: https://backup.example.com
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses: {"200": {description: OK}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := ExtractOpenAPIOperations(doc)[0]
+	for _, tc := range []struct {
+		overrides map[string]string
+		want      string
+	}{
+		{nil, "https://us.api.example.com/v2/items"},
+		{map[string]string{"region": "eu"}, "https://eu.api.example.com/v2/items"},
+	} {
+		tmpl := newOperationTemplate(op, doc, nil, nil, nil, tc.overrides)
+		if len(tmpl.servers) != 2 || tmpl.servers[1] != "https://backup.example.com" {
+			t.Fatalf("%v: unexpected servers %v", tc.overrides, tmpl.servers)
+		}
+		prepared, err := buildOperationRequest(context.Background(), tmpl, tmpl.servers[0], map[string]any{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prepared.url != tc.want {
+			t.Errorf("%v: expected %s, got %s", tc.overrides, tc.want, prepared.url)
+		}
+	}
+}
+
+func TestRequestPreviewWithoutSideEffects(t *testing.T) {
+	var tokens, calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			atomic.AddInt32(&tokens, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token": "minted", "expires_in": 3600}`))
+			return
+		}
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	marker := t.TempDir() + "/ran"
+	srv := newTestServer(t, `
+openapi: 3.0.0
+info: {title: Items, version: "1.0"}
+servers: [{url: "`+ts.URL+`"}]
+components:
+  securitySchemes:
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials: {tokenUrl: "`+ts.URL+`/token", scopes: {}}
+    helper: {type: apiKey, in: header, name: X-Key}
+security: [{oauth: [], helper: []}]
+paths:
+  /items:
+    get:
+      operationId: listItems
+      responses: {"204": {description: OK}}
+`, &ToolGenOptions{
+		OAuth2:      &OAuth2Config{ClientID: "client", ClientSecret: "secret"},
+		Credentials: map[string]string{"helper": `exec:touch ` + marker + ` && echo '{"value": "from-helper"}'`},
+	})
+	preview := func() map[string]any {
+		result := callTool(t, srv, nil, "listItems", `{"__preview": true}`)
+		var preview map[string]any
+		if err := json.Unmarshal([]byte(resultText(result)), &preview); err != nil {
+			t.Fatalf("preview is not JSON: %v", err)
+		}
+		return preview
+	}
+
+	p := preview()
+	if _, err := os.Stat(marker); err == nil || atomic.LoadInt32(&tokens) != 0 || atomic.LoadInt32(&calls) != 0 {
+		t.Fatalf("expected the preview to have no side effects, got %d token requests, %d calls", tokens, calls)
+	}
+	if got, _ := json.Marshal(p["unresolvedCredentials"]); string(got) != `["helper","oauth"]` {
+		t.Errorf("expected the credentials to be listed as unresolved, got %s", got)
+	}
+	if p["missingCredentials"] != nil {
+		t.Errorf("expected placeholders rather than missing credentials, got %v", p["missingCredentials"])
+	}
+
+	// Once a call obtained them, previews use the cached credentials
+	callTool(t, srv, nil, "listItems", `{}`)
+	if _, err := os.Stat(marker); err != nil || atomic.LoadInt32(&tokens) != 1 || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("expected the call to obtain credentials, got %d token requests, %d calls", tokens, calls)
+	}
+	if p = preview(); p["unresolvedCredentials"] != nil || atomic.LoadInt32(&tokens) != 1 {
+		t.Errorf("expected the cached credentials to be used, got %v", p["unresolvedCredentials"])
+	}
+}
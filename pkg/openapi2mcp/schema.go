@@ -38,8 +38,8 @@ func unescapeParameterName(escaped string, originalNames map[string]string) stri
 	return escaped // Return as-is if not found in mapping
 }
 
-// buildParameterNameMapping creates a mapping from escaped parameter names to original names.
-// This is used to reverse the escaping when looking up parameter values.
+// buildParameterNameMapping creates a mapping from tool argument names (escaped names or x-mcp-name) to original names.
+// This is used to reverse the escaping and renaming when looking up parameter values.
 func buildParameterNameMapping(params openapi3.Parameters) map[string]string {
 	mapping := make(map[string]string)
 	for _, paramRef := range params {
@@ -47,9 +47,9 @@ func buildParameterNameMapping(params openapi3.Parameters) map[string]string {
 			continue
 		}
 		p := paramRef.Value
-		escaped := escapeParameterName(p.Name)
-		if escaped != p.Name {
-			mapping[escaped] = p.Name
+		argName := parameterArgumentName(p)
+		if argName != p.Name {
+			mapping[argName] = p.Name
 		}
 	}
 	return mapping
@@ -123,6 +123,38 @@ func extractProperty(s *openapi3.SchemaRef) map[string]any {
 	if val.Example != nil {
 		prop["example"] = val.Example
 	}
+	// Numeric, string and array constraints (patterns are left out: they use ECMA-262 syntax,
+	// which the validator's regular expressions do not fully support)
+	if val.Min != nil {
+		prop["minimum"] = *val.Min
+		if val.ExclusiveMin {
+			prop["exclusiveMinimum"] = true
+		}
+	}
+	if val.Max != nil {
+		prop["maximum"] = *val.Max
+		if val.ExclusiveMax {
+			prop["exclusiveMaximum"] = true
+		}
+	}
+	if val.MultipleOf != nil {
+		prop["multipleOf"] = *val.MultipleOf
+	}
+	if val.MinLength > 0 {
+		prop["minLength"] = val.MinLength
+	}
+	if val.MaxLength != nil {
+		prop["maxLength"] = *val.MaxLength
+	}
+	if val.MinItems > 0 {
+		prop["minItems"] = val.MinItems
+	}
+	if val.MaxItems != nil {
+		prop["maxItems"] = *val.MaxItems
+	}
+	if val.UniqueItems {
+		prop["uniqueItems"] = true
+	}
 	// Object properties
 	if val.Type != nil && val.Type.Is("object") && val.Properties != nil {
 		objProps := map[string]any{}
@@ -163,6 +195,9 @@ func BuildInputSchema(params openapi3.Parameters, requestBody *openapi3.RequestB
 			continue
 		}
 		p := paramRef.Value
+		if isParameterHidden(p) {
+			continue
+		}
 		if p.Schema != nil && p.Schema.Value != nil {
 			if p.Schema.Value.Type != nil && p.Schema.Value.Type.Is("string") && p.Schema.Value.Format == "binary" {
 				fmt.Fprintf(os.Stderr, "[WARN] Parameter '%s' uses 'string' with 'binary' format. Non-JSON body types are not fully supported.\n", p.Name)
@@ -171,8 +206,14 @@ func BuildInputSchema(params openapi3.Parameters, requestBody *openapi3.RequestB
 			if p.Description != "" {
 				prop["description"] = p.Description
 			}
-			// Use escaped parameter name for MCP schema compatibility
-			escapedName := escapeParameterName(p.Name)
+			if mcpDesc, ok := extString(p.Extensions, extMCPDescription); ok {
+				prop["description"] = mcpDesc
+			}
+			if examples, ok := p.Extensions[extMCPExamples].([]any); ok {
+				prop["examples"] = examples
+			}
+			// Use escaped parameter name (or x-mcp-name) for MCP schema compatibility
+			escapedName := parameterArgumentName(p)
 			properties[escapedName] = prop
 			if p.Required {
 				required = append(required, escapedName)
@@ -0,0 +1,303 @@
+// security.go
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	mcpserver "github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// ParseCredential parses a "schemeName=value" credential binding, as used by --credential.
+func ParseCredential(s string) (string, string, error) {
+	eq := strings.Index(s, "=")
+	if eq < 1 {
+		return "", "", fmt.Errorf("invalid credential %q (expected schemeName=value)", s)
+	}
+	return s[:eq], s[eq+1:], nil
+}
+
+// schemeCredential is a credential resolved for one security scheme of a requirement.
+type schemeCredential struct {
+	name   string
+	scheme *openapi3.SecurityScheme
+	// value is empty when the client already supplies the credential in its own headers
+	value string
+	// oauth2Key identifies the cached OAuth 2.0 token, if value is one
+	oauth2Key string
+	// signer computes the scheme's headers over the final request; value is then its credential
+	signer RequestSigner
+	// source is the bound credential source value came from, if any
+	source CredentialSource
+	// placeholder is set in previews when value stands for a credential that was not obtained yet
+	placeholder bool
+}
+
+// MissingCredentialsError reports that none of an operation's security requirements can be satisfied
+// with the configured credentials.
+type MissingCredentialsError struct {
+	Code string `json:"error"` // always "missing_credentials"
+	Tool string `json:"tool"`
+	// Alternatives lists, for each security requirement, the schemes it lacks credentials for.
+	// Any single alternative is enough to call the tool.
+	Alternatives [][]MissingCredential `json:"alternatives"`
+}
+
+// MissingCredential describes a security scheme without a credential.
+type MissingCredential struct {
+	Scheme      string `json:"scheme"`
+	Description string `json:"description"` // e.g. "API key in header X-App-Key"
+	Hint        string `json:"hint"`        // how to provide it, e.g. "--credential appKey=... or API_KEY"
+}
+
+func (e *MissingCredentialsError) Error() string {
+	var sb strings.Builder
+	tool := e.Tool
+	if tool == "" {
+		tool = "this operation"
+	}
+	fmt.Fprintf(&sb, "Missing credentials for %s. ", tool)
+	if len(e.Alternatives) == 1 {
+		sb.WriteString("Provide:\n")
+	} else {
+		sb.WriteString("Provide one of:\n")
+	}
+	for _, alternative := range e.Alternatives {
+		parts := make([]string, 0, len(alternative))
+		for _, m := range alternative {
+			parts = append(parts, fmt.Sprintf("%s (%s; %s)", m.Scheme, m.Description, m.Hint))
+		}
+		sb.WriteString("  - " + strings.Join(parts, " AND ") + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// describeSecurityScheme returns a short description of what a scheme expects.
+func describeSecurityScheme(scheme *openapi3.SecurityScheme) string {
+	if scheme == nil {
+		return "undefined security scheme"
+	}
+	switch scheme.Type {
+	case "apiKey":
+		in := scheme.In
+		if in == "query" {
+			in = "query parameter"
+		}
+		return fmt.Sprintf("API key in %s %s", in, scheme.Name)
+	case "http":
+		return fmt.Sprintf("HTTP %s authentication", strings.ToLower(scheme.Scheme))
+	case "oauth2":
+		return "OAuth 2.0 access token"
+	case "openIdConnect":
+		return "OpenID Connect access token"
+	case "mutualTLS":
+		return "mutual TLS (not supported)"
+	}
+	return scheme.Type + " (not supported)"
+}
+
+// legacyCredentialEnv returns the environment variable used for a scheme without a named credential.
+func legacyCredentialEnv(scheme *openapi3.SecurityScheme) string {
+	switch scheme.Type {
+	case "apiKey":
+		return "API_KEY"
+	case "http":
+		switch strings.ToLower(scheme.Scheme) {
+		case "bearer":
+			return "BEARER_TOKEN"
+		case "basic":
+			return "BASIC_AUTH"
+		}
+	case "oauth2", "openIdConnect":
+		return "BEARER_TOKEN"
+	}
+	return ""
+}
+
+// credentialHint tells how to provide a credential for a scheme.
+func credentialHint(name string, scheme *openapi3.SecurityScheme) string {
+	hint := "--credential " + name + "=..."
+	if scheme == nil {
+		return hint
+	}
+	if env := legacyCredentialEnv(scheme); env != "" {
+		hint += " or " + env
+	}
+	if scheme.Type == "oauth2" {
+		hint += " or an OAuth 2.0 client/login"
+	}
+	return hint
+}
+
+// resolveCredential returns the credential for a security scheme: the one bound to its name (or its
+// signer's default), an OAuth 2.0 token, the legacy environment variable for its type, or the client's own header.
+// It returns nil if none is available.
+func (t *operationTemplate) resolveCredential(ctx context.Context, name string, scopes []string) (*schemeCredential, error) {
+	if t.doc == nil || t.doc.Components == nil {
+		return nil, nil
+	}
+	ref, ok := t.doc.Components.SecuritySchemes[name]
+	if !ok || ref == nil || ref.Value == nil {
+		return nil, nil
+	}
+	scheme := ref.Value
+	source := t.credentials[name]
+	var bound string
+	placeholder := false
+	if source != nil && scheme.Type != "mutualTLS" {
+		var err error
+		bound, err = boundCredential(ctx, source)
+		if errors.Is(err, errCredentialNotResolved) {
+			bound, placeholder = credentialPlaceholder(name), true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if signer := t.signers[name]; signer != nil {
+		value := signerCredential(signer, bound)
+		if value == "" {
+			return nil, nil
+		}
+		cred := &schemeCredential{name: name, scheme: scheme, value: value, signer: signer, placeholder: placeholder}
+		if bound != "" {
+			cred.source = source
+		}
+		return cred, nil
+	}
+	if scheme.Type == "mutualTLS" || scheme.Type == "apiKey" && scheme.Name == "" {
+		return nil, nil
+	}
+	cred := &schemeCredential{name: name, scheme: scheme, value: bound, placeholder: placeholder}
+	if bound != "" {
+		cred.source = source
+	}
+	if cred.value == "" && scheme.Type == "oauth2" {
+		token, key, err := t.oauth2.token(ctx, name, scheme.Flows, scopes)
+		if errors.Is(err, errCredentialNotResolved) {
+			token, cred.placeholder = credentialPlaceholder(name), true
+		} else if err != nil {
+			return nil, err
+		}
+		cred.value, cred.oauth2Key = token, key
+	}
+	if env := legacyCredentialEnv(scheme); cred.value == "" && env != "" {
+		cred.value = os.Getenv(env)
+	}
+	if cred.value != "" {
+		return cred, nil
+	}
+
+	// Clients may send the credential themselves, in headers forwarded to the API
+	clientHeaders, _ := ctx.Value(mcpserver.ClientHeadersKey{}).(map[string]string)
+	header := "Authorization"
+	if scheme.Type == "apiKey" {
+		if scheme.In != "header" {
+			return nil, nil
+		}
+		header = scheme.Name
+	}
+	for key, value := range clientHeaders {
+		if strings.EqualFold(key, header) && value != "" {
+			return cred, nil
+		}
+	}
+	return nil, nil
+}
+
+// resolveSecurity picks the first of an operation's security requirements (alternatives) for which
+// every scheme has a credential. An empty requirement means authentication is optional.
+// If no requirement can be satisfied, it returns a *MissingCredentialsError listing what each one lacks.
+func (t *operationTemplate) resolveSecurity(ctx context.Context) ([]*schemeCredential, error) {
+	security := t.op.Security
+	if len(security) == 0 {
+		return nil, nil
+	}
+	missing := &MissingCredentialsError{Code: "missing_credentials"}
+	for _, requirement := range security {
+		names := sortedKeys(requirement)
+		var creds []*schemeCredential
+		var lacking []MissingCredential
+		for _, name := range names {
+			cred, err := t.resolveCredential(ctx, name, requirement[name])
+			if err != nil {
+				return nil, err
+			}
+			if cred == nil {
+				var scheme *openapi3.SecurityScheme
+				if t.doc.Components != nil {
+					if ref := t.doc.Components.SecuritySchemes[name]; ref != nil {
+						scheme = ref.Value
+					}
+				}
+				m := MissingCredential{Scheme: name, Description: describeSecurityScheme(scheme), Hint: credentialHint(name, scheme)}
+				if signer := t.signers[name]; signer != nil {
+					m.Description, m.Hint = "signed request", "--credential "+name+"=..."
+					if _, ok := signer.(*sigV4Signer); ok {
+						m.Description = "AWS Signature Version 4"
+						m.Hint += " or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY"
+					}
+				}
+				lacking = append(lacking, m)
+				continue
+			}
+			creds = append(creds, cred)
+		}
+		if len(lacking) == 0 {
+			return creds, nil
+		}
+		missing.Alternatives = append(missing.Alternatives, lacking)
+	}
+	return nil, missing
+}
+
+// apply adds a credential to a request. Cookie credentials are appended to cookies, for the caller
+// to send along with cookie parameters. Credentials supplied by the client are left untouched.
+func (c *schemeCredential) apply(req *http.Request, cookies *[]string) {
+	if c.value == "" || c.signer != nil {
+		return
+	}
+	switch c.scheme.Type {
+	case "apiKey":
+		switch c.scheme.In {
+		case "header":
+			req.Header.Set(c.scheme.Name, c.value)
+		case "query":
+			q := req.URL.Query()
+			q.Set(c.scheme.Name, c.value)
+			req.URL.RawQuery = q.Encode()
+		case "cookie":
+			*cookies = append(*cookies, c.scheme.Name+"="+c.value)
+		}
+	case "http":
+		switch strings.ToLower(c.scheme.Scheme) {
+		case "basic":
+			req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(c.value)))
+		case "bearer":
+			req.Header.Set("Authorization", "Bearer "+c.value)
+		default:
+			req.Header.Set("Authorization", c.scheme.Scheme+" "+c.value)
+		}
+	case "oauth2", "openIdConnect":
+		req.Header.Set("Authorization", "Bearer "+c.value)
+	}
+}
+
+// describeSecurityRequirements summarizes an operation's security requirements for tool descriptions,
+// e.g. "appKey AND userToken, OR bearerAuth".
+func describeSecurityRequirements(security openapi3.SecurityRequirements) string {
+	var alternatives []string
+	for _, requirement := range security {
+		if len(requirement) == 0 {
+			alternatives = append(alternatives, "none")
+			continue
+		}
+		alternatives = append(alternatives, strings.Join(sortedKeys(requirement), " AND "))
+	}
+	return strings.Join(alternatives, ", OR ")
+}
@@ -0,0 +1,139 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+const securityTestSpec = `
+openapi: 3.0.0
+info: {title: Security, version: "1.0"}
+components:
+  securitySchemes:
+    appKey: {type: apiKey, in: header, name: X-App-Key}
+    userToken: {type: apiKey, in: query, name: token}
+    session: {type: apiKey, in: cookie, name: sid}
+    bearer: {type: http, scheme: bearer}
+paths:
+  /both:
+    get:
+      operationId: both
+      security: [{appKey: [], userToken: []}]
+      responses: {"200": {description: OK}}
+  /either:
+    get:
+      operationId: either
+      security: [{appKey: [], userToken: []}, {bearer: []}]
+      responses: {"200": {description: OK}}
+  /optional:
+    get:
+      operationId: optional
+      security: [{session: []}, {}]
+      parameters:
+        - {name: theme, in: cookie, schema: {type: string}}
+      responses: {"200": {description: OK}}
+  /public:
+    get:
+      operationId: public
+      responses: {"200": {description: OK}}
+`
+
+func TestNamedCredentials(t *testing.T) {
+	var last *http.Request
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	t.Setenv("OPENAPI_BASE_URL", api.URL)
+	t.Setenv("API_KEY", "global-key")
+	t.Setenv("BEARER_TOKEN", "")
+	t.Setenv("BASIC_AUTH", "")
+	t.Setenv("APP_KEY", "app-secret")
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(securityTestSpec))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	register := func(credentials map[string]string) *server.MCPServer {
+		srv := server.NewMCPServer("test", "1.0.0")
+		RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{Credentials: credentials})
+		return srv
+	}
+	call := func(srv *server.MCPServer, tool string) mcp.CallToolResult {
+		t.Helper()
+		last = nil
+		msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": map[string]any{"name": tool, "arguments": map[string]any{"theme": "dark"}}})
+		resp, ok := srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("%s: call failed", tool)
+		}
+		return resp.Result.(mcp.CallToolResult)
+	}
+
+	// Each scheme of an AND requirement gets its own credential
+	srv := register(map[string]string{"appKey": "env:APP_KEY", "userToken": "user-token", "session": "s1"})
+	if result := call(srv, "both"); result.IsError || last == nil {
+		t.Fatalf("expected the call to succeed: %+v", result.Content)
+	}
+	if last.Header.Get("X-App-Key") != "app-secret" || last.URL.Query().Get("token") != "user-token" {
+		t.Errorf("unexpected credentials: header %q, query %q", last.Header.Get("X-App-Key"), last.URL.RawQuery)
+	}
+	// Cookie credentials are sent along with cookie parameters
+	call(srv, "optional")
+	if cookie := last.Header.Get("Cookie"); cookie != "sid=s1; theme=dark" {
+		t.Errorf("unexpected cookies %q", cookie)
+	}
+	// The stale default header is no longer sent to operations without security requirements
+	call(srv, "public")
+	if last.Header.Get("Fastly-Key") != "" || last.Header.Get("X-App-Key") != "" {
+		t.Errorf("no API key should be sent to operations without security requirements: %v", last.Header)
+	}
+
+	// Only appKey has a credential: the AND requirement is not satisfied, so the OR alternative is
+	// used, and no credential of the unsatisfied requirement is sent
+	t.Setenv("API_KEY", "")
+	t.Setenv("BEARER_TOKEN", "bearer-token")
+	srv = register(map[string]string{"appKey": "app-secret"})
+	if result := call(srv, "either"); result.IsError || last.Header.Get("Authorization") != "Bearer bearer-token" || last.Header.Get("X-App-Key") != "" {
+		t.Errorf("expected only the bearer alternative to be used: %v %+v", last.Header, result.Content)
+	}
+	// Optional authentication: the empty requirement is satisfied without credentials
+	if result := call(srv, "optional"); result.IsError || last.Header.Get("Cookie") != "theme=dark" {
+		t.Errorf("expected the call to proceed without credentials")
+	}
+
+	// Without any satisfiable requirement, the call is not sent and the missing credentials are listed
+	t.Setenv("BEARER_TOKEN", "")
+	srv = register(nil)
+	result := call(srv, "either")
+	if !result.IsError || last != nil {
+		t.Fatalf("expected the call to fail before being sent")
+	}
+	missing, ok := result.StructuredContent.(*MissingCredentialsError)
+	if !ok || missing.Tool != "either" || len(missing.Alternatives) != 2 || len(missing.Alternatives[0]) != 2 || missing.Alternatives[1][0].Scheme != "bearer" {
+		t.Fatalf("unexpected structured content: %#v", result.StructuredContent)
+	}
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"Missing credentials for either", "appKey (API key in header X-App-Key; --credential appKey=... or API_KEY) AND userToken", "bearer (HTTP bearer authentication"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestDescribeSecurityRequirements(t *testing.T) {
+	security := openapi3.SecurityRequirements{{"b": {}, "a": {}}, {"c": {}}, {}}
+	if got := describeSecurityRequirements(security); got != "a AND b, OR c, OR none" {
+		t.Errorf("unexpected description %q", got)
+	}
+}
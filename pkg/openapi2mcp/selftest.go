@@ -33,6 +33,11 @@ func SelfTestOpenAPIMCP(doc *openapi3.T, toolNames []string) error {
 		}
 	}
 
+	// Check x-mcp-* vendor extension values
+	extErrors, extWarnings := printLintIssues(lintMCPExtensions(doc))
+	failures += extErrors
+	warnings += extWarnings
+
 	for _, op := range ops {
 		if _, ok := toolMap[op.OperationID]; !ok && op.OperationID != "" {
 			fmt.Fprintf(os.Stderr, "[ERROR] Tool '%s' (operationId) is missing from MCP server.\n", op.OperationID)
@@ -301,6 +306,10 @@ func SelfTestOpenAPIMCPWithOptions(doc *openapi3.T, toolNames []string, detailed
 		}
 	}
 
+	// Check x-mcp-* vendor extension values
+	extErrors, _ := printLintIssues(lintMCPExtensions(doc))
+	failures += extErrors
+
 	for _, op := range ops {
 		if _, ok := toolMap[op.OperationID]; !ok && op.OperationID != "" {
 			fmt.Fprintf(os.Stderr, "[ERROR] Tool '%s' (operationId) is missing from MCP server.\n", op.OperationID)
@@ -459,6 +468,9 @@ func captureLintIssues(doc *openapi3.T, toolNames []string, detailedSuggestions
 		}
 	}
 
+	// Check x-mcp-* vendor extension values
+	issues = append(issues, lintMCPExtensions(doc)...)
+
 	if !detailedSuggestions {
 		// Basic validation only - check tool presence
 		for _, op := range ops {
@@ -83,6 +83,64 @@ func (c *authContext) restoreEnv() {
 	}
 }
 
+// HTTPOption configures the MCP HTTP transports: ServeHTTP, ServeStreamableHTTP and their handlers.
+type HTTPOption func(*httpOptions)
+
+type httpOptions struct {
+	auth     Authenticator
+	resource *ProtectedResourceMetadata
+	tls      *TLSCertificates
+}
+
+// WithAuthenticator requires MCP clients to authenticate (see NewAuthenticator and RequireAuthentication).
+// The client's identity is then available to tool handlers and hooks through IdentityFromContext.
+func WithAuthenticator(auth Authenticator) HTTPOption {
+	return func(o *httpOptions) {
+		o.auth = auth
+	}
+}
+
+func newHTTPOptions(opts []HTTPOption) *httpOptions {
+	o := &httpOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// handler wraps an MCP transport handler with the configured authentication.
+func (o *httpOptions) handler(h http.Handler) http.Handler {
+	if o.auth != nil {
+		h = RequireAuthentication(o.auth, h)
+	}
+	if o.tls != nil {
+		h = clientCertificateIdentity(h)
+	}
+	if o.resource != nil {
+		next := h
+		h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), resourceKey{}, o.resource)))
+		})
+	}
+	return h
+}
+
+// mux serves an MCP transport handler at pattern, along with the protected resource metadata, at its
+// own well-known path and at the root one.
+func (o *httpOptions) mux(pattern string, h http.Handler) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle(pattern, o.handler(h))
+	if o.resource != nil {
+		metadata := ProtectedResourceHandler(*o.resource)
+		path := ProtectedResourceMetadataPath(o.resource.Resource)
+		mux.Handle(path, metadata)
+		if path != protectedResourceWellKnown {
+			mux.Handle(protectedResourceWellKnown, metadata)
+		}
+	}
+	return mux
+}
+
 // NewServer creates a new MCP server, registers all OpenAPI tools, and returns the server.
 // Equivalent to calling RegisterOpenAPITools with all operations from the spec.
 // Example usage for NewServer:
@@ -111,12 +169,13 @@ func NewServerWithOps(name, version string, doc *openapi3.T, ops []OpenAPIOperat
 }
 
 // ServeStdio starts the MCP server using stdio (wraps mcpserver.ServeStdio).
+// Requests are handled concurrently; mcpserver.WithWorkerLimit sets how many at most.
 // Returns an error if the server fails to start.
 // Example usage for ServeStdio:
 //
 //	openapi2mcp.ServeStdio(srv)
-func ServeStdio(server *mcpserver.MCPServer) error {
-	return mcpserver.ServeStdio(server)
+func ServeStdio(server *mcpserver.MCPServer, opts ...mcpserver.StdioOption) error {
+	return mcpserver.ServeStdio(server, opts...)
 }
 
 // ServeHTTP starts the MCP server using HTTP SSE (wraps mcpserver.NewSSEServer and Start).
@@ -127,7 +186,7 @@ func ServeStdio(server *mcpserver.MCPServer) error {
 //
 //	srv, _ := openapi2mcp.NewServer("petstore", "1.0.0", doc)
 //	openapi2mcp.ServeHTTP(srv, ":8080", "/custom-base")
-func ServeHTTP(server *mcpserver.MCPServer, addr string, basePath string) error {
+func ServeHTTP(server *mcpserver.MCPServer, addr string, basePath string, opts ...HTTPOption) error {
 	// Convert the authContextFunc to SSEContextFunc signature
 	sseAuthContextFunc := func(ctx context.Context, r *http.Request) context.Context {
 		return authContextFunc(ctx, r)
@@ -142,7 +201,11 @@ func ServeHTTP(server *mcpserver.MCPServer, addr string, basePath string) error
 		mcpserver.WithStaticBasePath(basePath),
 		mcpserver.WithSSEEndpoint("/sse"),
 		mcpserver.WithMessageEndpoint("/message"))
-	return sseServer.Start(addr)
+	o := newHTTPOptions(opts)
+	if o.auth == nil && o.resource == nil && o.tls == nil {
+		return sseServer.Start(addr)
+	}
+	return ListenAndServe(addr, o.mux("/", sseServer), opts...)
 }
 
 // GetSSEURL returns the URL for establishing an SSE connection to the MCP server.
@@ -210,7 +273,7 @@ func normalizeAddrToHost(addr string) string {
 //
 //	handler := openapi2mcp.HandlerForBasePath(srv, "/petstore")
 //	mux.Handle("/petstore/", handler)
-func HandlerForBasePath(server *mcpserver.MCPServer, basePath string) http.Handler {
+func HandlerForBasePath(server *mcpserver.MCPServer, basePath string, opts ...HTTPOption) http.Handler {
 	sseAuthContextFunc := func(ctx context.Context, r *http.Request) context.Context {
 		return authContextFunc(ctx, r)
 	}
@@ -223,7 +286,7 @@ func HandlerForBasePath(server *mcpserver.MCPServer, basePath string) http.Handl
 		mcpserver.WithSSEEndpoint("/sse"),
 		mcpserver.WithMessageEndpoint("/message"),
 	)
-	return sseServer
+	return newHTTPOptions(opts).handler(sseServer)
 }
 
 // ServeStreamableHTTP starts the MCP server using HTTP StreamableHTTP (wraps mcpserver.NewStreamableHTTPServer and Start).
@@ -234,7 +297,7 @@ func HandlerForBasePath(server *mcpserver.MCPServer, basePath string) http.Handl
 //
 //	srv, _ := openapi2mcp.NewServer("petstore", "1.0.0", doc)
 //	openapi2mcp.ServeStreamableHTTP(srv, ":8080", "/custom-base")
-func ServeStreamableHTTP(server *mcpserver.MCPServer, addr string, basePath string) error {
+func ServeStreamableHTTP(server *mcpserver.MCPServer, addr string, basePath string, opts ...HTTPOption) error {
 	streamableAuthContextFunc := func(ctx context.Context, r *http.Request) context.Context {
 		return authContextFunc(ctx, r)
 	}
@@ -247,7 +310,11 @@ func ServeStreamableHTTP(server *mcpserver.MCPServer, addr string, basePath stri
 		mcpserver.WithHTTPContextFunc(streamableAuthContextFunc),
 		mcpserver.WithEndpointPath(basePath),
 	)
-	return streamableServer.Start(addr)
+	o := newHTTPOptions(opts)
+	if o.auth == nil && o.resource == nil && o.tls == nil {
+		return streamableServer.Start(addr)
+	}
+	return ListenAndServe(addr, o.mux(basePath, streamableServer), opts...)
 }
 
 // HandlerForStreamableHTTP returns an http.Handler that serves the given MCP server at the specified basePath using StreamableHTTP.
@@ -256,7 +323,7 @@ func ServeStreamableHTTP(server *mcpserver.MCPServer, addr string, basePath stri
 //
 //	handler := openapi2mcp.HandlerForStreamableHTTP(srv, "/petstore")
 //	mux.Handle("/petstore", handler)
-func HandlerForStreamableHTTP(server *mcpserver.MCPServer, basePath string) http.Handler {
+func HandlerForStreamableHTTP(server *mcpserver.MCPServer, basePath string, opts ...HTTPOption) http.Handler {
 	streamableAuthContextFunc := func(ctx context.Context, r *http.Request) context.Context {
 		return authContextFunc(ctx, r)
 	}
@@ -267,5 +334,5 @@ func HandlerForStreamableHTTP(server *mcpserver.MCPServer, basePath string) http
 		mcpserver.WithHTTPContextFunc(streamableAuthContextFunc),
 		mcpserver.WithEndpointPath(basePath),
 	)
-	return streamableServer
+	return newHTTPOptions(opts).handler(streamableServer)
 }
@@ -1,7 +1,16 @@
 package openapi2mcp
 
 import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
 )
 
 func TestGetSSEURL(t *testing.T) {
@@ -94,3 +103,46 @@ func TestGetMessageURL(t *testing.T) {
 		})
 	}
 }
+
+func TestStdioCancellationAbortsUpstreamRequest(t *testing.T) {
+	received, aborted := make(chan struct{}), make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-r.Context().Done()
+		close(aborted)
+	}))
+	defer upstream.Close()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Reports, version: "1.0"}
+servers: [{url: "` + upstream.URL + `"}]
+paths:
+  /report:
+    get:
+      operationId: buildReport
+      responses: {"200": {description: OK}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, nil)
+
+	stdinReader, stdin := io.Pipe()
+	stdout, stdoutWriter := io.Pipe()
+	go server.NewStdioServer(srv).Listen(context.Background(), stdinReader, stdoutWriter)
+	defer stdin.Close()
+	responses := bufio.NewScanner(stdout)
+	io.WriteString(stdin, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"buildReport","arguments":{}}}`+"\n")
+	io.WriteString(stdin, `{"jsonrpc":"2.0","id":2,"method":"ping"}`+"\n")
+	if !responses.Scan() {
+		t.Fatal("expected a ping response")
+	}
+	<-received
+	io.WriteString(stdin, `{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":1}}`+"\n")
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the upstream request to be aborted")
+	}
+}
@@ -0,0 +1,385 @@
+// signing.go
+package openapi2mcp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RequestSigner computes authentication headers over the final upstream request, after parameters,
+// headers, the body and request interceptors have been applied. credential is the value bound to the
+// security scheme (see ToolGenOptions.Credentials).
+//
+// A signer may also implement DefaultCredential() string to supply a credential when none is bound,
+// e.g. from well-known environment variables. A signed scheme without any credential is reported missing.
+type RequestSigner interface {
+	SignRequest(req *http.Request, body []byte, credential string) error
+}
+
+// RequestSignerFunc adapts a function to a RequestSigner.
+type RequestSignerFunc func(req *http.Request, body []byte, credential string) error
+
+// SignRequest calls f(req, body, credential).
+func (f RequestSignerFunc) SignRequest(req *http.Request, body []byte, credential string) error {
+	return f(req, body, credential)
+}
+
+// SignerFactory creates a RequestSigner from its configuration, the properties of a security scheme's
+// x-mcp-auth extension or of a --signer flag.
+type SignerFactory func(config map[string]string) (RequestSigner, error)
+
+var (
+	signerFactoriesMu sync.RWMutex
+	signerFactories   = map[string]SignerFactory{
+		"aws-sigv4": newSigV4Signer,
+		"hmac":      newHMACSigner,
+	}
+)
+
+// RegisterSigner makes a signer kind available to x-mcp-auth extensions and --signer flags.
+// The built-in kinds are "aws-sigv4" and "hmac"; registering one of them replaces it.
+func RegisterSigner(kind string, factory SignerFactory) {
+	signerFactoriesMu.Lock()
+	defer signerFactoriesMu.Unlock()
+	signerFactories[kind] = factory
+}
+
+// NewRequestSigner creates a signer of a registered kind.
+func NewRequestSigner(kind string, config map[string]string) (RequestSigner, error) {
+	signerFactoriesMu.RLock()
+	factory, ok := signerFactories[kind]
+	signerFactoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signer %q", kind)
+	}
+	return factory(config)
+}
+
+// ParseSigner parses a "schemeName=kind[,key=value...]" binding, as used by --signer.
+func ParseSigner(s string) (string, RequestSigner, error) {
+	eq := strings.Index(s, "=")
+	if eq < 1 || eq == len(s)-1 {
+		return "", nil, fmt.Errorf("invalid signer %q (expected schemeName=kind[,key=value...])", s)
+	}
+	parts := strings.Split(s[eq+1:], ",")
+	config := map[string]string{}
+	for _, part := range parts[1:] {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || key == "" {
+			return "", nil, fmt.Errorf("invalid signer option %q in %q (expected key=value)", part, s)
+		}
+		config[key] = value
+	}
+	signer, err := NewRequestSigner(parts[0], config)
+	if err != nil {
+		return "", nil, err
+	}
+	return s[:eq], signer, nil
+}
+
+// schemeSigners returns the signers for a spec's security schemes: those configured by name, then those
+// declared with an x-mcp-auth extension ({type: <kind>, ...options}), then API Gateway schemes marked
+// with x-amazon-apigateway-authtype: awsSigv4.
+func schemeSigners(doc *openapi3.T, configured map[string]RequestSigner) map[string]RequestSigner {
+	signers := map[string]RequestSigner{}
+	for name, signer := range configured {
+		signers[name] = signer
+	}
+	if doc == nil || doc.Components == nil {
+		return signers
+	}
+	for name, ref := range doc.Components.SecuritySchemes {
+		if _, ok := signers[name]; ok || ref == nil || ref.Value == nil {
+			continue
+		}
+		var kind string
+		config := map[string]string{}
+		if ext, ok := ref.Value.Extensions["x-mcp-auth"].(map[string]any); ok {
+			for key, value := range ext {
+				config[key] = fmt.Sprint(value)
+			}
+			kind = config["type"]
+		} else if authType, _ := ref.Value.Extensions["x-amazon-apigateway-authtype"].(string); strings.EqualFold(authType, "awsSigv4") {
+			kind = "aws-sigv4"
+		}
+		if kind == "" {
+			continue
+		}
+		signer, err := NewRequestSigner(kind, config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Security scheme '%s': %v\n", name, err)
+			continue
+		}
+		signers[name] = signer
+	}
+	return signers
+}
+
+// signerCredential returns the credential for a signed scheme: the bound one, or the signer's default.
+func signerCredential(signer RequestSigner, bound string) string {
+	if bound != "" {
+		return bound
+	}
+	if d, ok := signer.(interface{ DefaultCredential() string }); ok {
+		return d.DefaultCredential()
+	}
+	return ""
+}
+
+// --- AWS Signature Version 4 ---
+
+// sigV4Signer signs requests with AWS Signature Version 4, as required by API Gateway IAM authorization.
+// Its credential is "accessKeyId:secretAccessKey[:sessionToken]".
+type sigV4Signer struct {
+	region  string
+	service string
+	now     func() time.Time
+}
+
+// newSigV4Signer creates an AWS SigV4 signer. Options: region (default: AWS_REGION or AWS_DEFAULT_REGION)
+// and service (default: execute-api).
+func newSigV4Signer(config map[string]string) (RequestSigner, error) {
+	s := &sigV4Signer{region: config["region"], service: config["service"], now: time.Now}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_REGION")
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		return nil, fmt.Errorf("aws-sigv4: no region (set region, or AWS_REGION)")
+	}
+	if s.service == "" {
+		s.service = "execute-api"
+	}
+	return s, nil
+}
+
+// DefaultCredential returns the credentials from the standard AWS environment variables.
+func (s *sigV4Signer) DefaultCredential() string {
+	id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if id == "" || secret == "" {
+		return ""
+	}
+	cred := id + ":" + secret
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		cred += ":" + token
+	}
+	return cred
+}
+
+func (s *sigV4Signer) SignRequest(req *http.Request, body []byte, credential string) error {
+	parts := strings.SplitN(credential, ":", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("aws-sigv4: the credential must be accessKeyId:secretAccessKey[:sessionToken]")
+	}
+	accessKeyID, secretKey := parts[0], parts[1]
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if len(parts) == 3 && parts[2] != "" {
+		req.Header.Set("X-Amz-Security-Token", parts[2])
+	}
+	payloadHash := hexSHA256(body)
+	if s.service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	// Signed headers: host, content-type and all x-amz-* headers
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[lower] = strings.Join(trimmed, ",")
+		}
+	}
+	names := sortedKeys(headers)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Path segments are encoded twice for every service but S3
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if s.service != "s3" {
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			segments[i] = awsURIEncode(segment)
+		}
+		path = strings.Join(segments, "/")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.region + "/" + s.service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256([]byte(canonicalRequest))
+
+	key := hmacSum(sha256.New, []byte("AWS4"+secretKey), date)
+	key = hmacSum(sha256.New, key, s.region)
+	key = hmacSum(sha256.New, key, s.service)
+	key = hmacSum(sha256.New, key, "aws4_request")
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// awsCanonicalQuery encodes query parameters sorted by name, then value.
+func awsCanonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, awsURIEncode(name)+"="+awsURIEncode(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode percent-encodes everything but RFC 3986 unreserved characters.
+func awsURIEncode(s string) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		if 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z' || '0' <= b && b <= '9' || b == '-' || b == '_' || b == '.' || b == '~' {
+			sb.WriteByte(b)
+		} else {
+			fmt.Fprintf(&sb, "%%%02X", b)
+		}
+	}
+	return sb.String()
+}
+
+// --- HMAC signatures ---
+
+// hmacSigner signs requests with an HMAC over selected request components, in the format of the
+// "Signature" HTTP authentication scheme (draft-cavage-http-signatures):
+//
+//	Authorization: Signature keyId="k",algorithm="hmac-sha256",headers="(request-target) host date digest",signature="..."
+//
+// Its credential is the shared secret, or "keyId:secret" if no keyId option is set.
+type hmacSigner struct {
+	keyID      string
+	algorithm  string
+	newHash    func() hash.Hash
+	header     string   // header receiving the signature
+	components []string // signed components, in order
+	now        func() time.Time
+}
+
+// newHMACSigner creates an HMAC signer. Options: keyId, algorithm (hmac-sha256 or hmac-sha512,
+// default hmac-sha256), header (Authorization or Signature, default Authorization) and headers, the
+// space-separated components to sign (default "(request-target) host date digest"). Date and Digest
+// headers are added to the request when signed but missing.
+func newHMACSigner(config map[string]string) (RequestSigner, error) {
+	s := &hmacSigner{keyID: config["keyId"], algorithm: strings.ToLower(config["algorithm"]), header: config["header"], now: time.Now}
+	switch s.algorithm {
+	case "", "hmac-sha256":
+		s.algorithm, s.newHash = "hmac-sha256", sha256.New
+	case "hmac-sha512":
+		s.newHash = sha512.New
+	default:
+		return nil, fmt.Errorf("hmac: unsupported algorithm %q", s.algorithm)
+	}
+	if s.header == "" {
+		s.header = "Authorization"
+	}
+	components := config["headers"]
+	if components == "" {
+		components = "(request-target) host date digest"
+	}
+	s.components = strings.Fields(strings.ToLower(components))
+	return s, nil
+}
+
+func (s *hmacSigner) SignRequest(req *http.Request, body []byte, credential string) error {
+	keyID, secret := s.keyID, credential
+	if keyID == "" {
+		var ok bool
+		if keyID, secret, ok = strings.Cut(credential, ":"); !ok {
+			return fmt.Errorf("hmac: the credential must be keyId:secret when no keyId option is set")
+		}
+	}
+	if secret == "" {
+		return fmt.Errorf("hmac: empty secret")
+	}
+
+	lines := make([]string, 0, len(s.components))
+	for _, component := range s.components {
+		var value string
+		switch component {
+		case "(request-target)":
+			value = strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			value = req.Host
+			if value == "" {
+				value = req.URL.Host
+			}
+		case "date":
+			if req.Header.Get("Date") == "" {
+				req.Header.Set("Date", s.now().UTC().Format(http.TimeFormat))
+			}
+			value = req.Header.Get("Date")
+		case "digest":
+			if req.Header.Get("Digest") == "" {
+				sum := sha256.Sum256(body)
+				req.Header.Set("Digest", "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+			}
+			value = req.Header.Get("Digest")
+		default:
+			value = strings.Join(req.Header.Values(component), ", ")
+		}
+		lines = append(lines, component+": "+value)
+	}
+	signature := base64.StdEncoding.EncodeToString(hmacSum(s.newHash, []byte(secret), strings.Join(lines, "\n")))
+	params := fmt.Sprintf(`keyId="%s",algorithm="%s",headers="%s",signature="%s"`, keyID, s.algorithm, strings.Join(s.components, " "), signature)
+	if strings.EqualFold(s.header, "Authorization") {
+		params = "Signature " + params
+	}
+	req.Header.Set(s.header, params)
+	return nil
+}
+
+func hmacSum(newHash func() hash.Hash, key []byte, data string) []byte {
+	mac := hmac.New(newHash, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
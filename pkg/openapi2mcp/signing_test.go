@@ -0,0 +1,195 @@
+package openapi2mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// Test vectors from the AWS Signature Version 4 test suite
+func TestSigV4Signer(t *testing.T) {
+	const credential = "AKIDEXAMPLE:wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	fixed := func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+	tests := []struct {
+		name        string
+		service     string
+		url         string
+		contentType string
+		want        string
+	}{
+		{"get-vanilla", "service", "https://example.amazonaws.com/", "",
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query-order-key-case", "service", "https://example.amazonaws.com/?Param2=value2&Param1=value1", "",
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+		{"iam-list-users", "iam", "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", "application/x-www-form-urlencoded; charset=utf-8",
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newSigV4Signer(map[string]string{"region": "us-east-1", "service": tt.service})
+			if err != nil {
+				t.Fatal(err)
+			}
+			signer.(*sigV4Signer).now = fixed
+			req, _ := http.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if err := signer.SignRequest(req, nil, credential); err != nil {
+				t.Fatal(err)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHMACSigner(t *testing.T) {
+	signer, err := NewRequestSigner("hmac", map[string]string{"keyId": "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"a":1}`)
+	req, _ := http.NewRequest(http.MethodPost, "https://api.example.com/orders?x=1", bytes.NewReader(body))
+	req.Header.Set("Date", "Tue, 07 Jun 2014 20:51:35 GMT")
+	if err := signer.SignRequest(req, body, "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Digest"); got != "SHA-256=AVq9f1zFei3ZS3WQ8ErYCEJzkF7jPsXOvq5iJ2qX+GI=" {
+		t.Errorf("unexpected digest %s", got)
+	}
+	want := `Signature keyId="test",algorithm="hmac-sha256",headers="(request-target) host date digest",signature="37qUL20rRjvIu6fB8oR5CfxaHZ6LGrv+EdmmCY81lbs="`
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if _, err := NewRequestSigner("hmac", map[string]string{"algorithm": "md5"}); err == nil {
+		t.Errorf("expected an unsupported algorithm to be rejected")
+	}
+}
+
+func TestSignedSecuritySchemes(t *testing.T) {
+	var last *http.Request
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		last = r
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+	t.Setenv("OPENAPI_BASE_URL", api.URL)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Signed, version: "1.0"}
+components:
+  securitySchemes:
+    sigv4:
+      type: apiKey
+      name: Authorization
+      in: header
+      x-amazon-apigateway-authtype: awsSigv4
+    partner:
+      type: apiKey
+      name: X-Signature
+      in: header
+      x-mcp-auth: {type: hmac, keyId: partner-1, header: X-Signature, headers: "(request-target) date digest"}
+    custom:
+      type: apiKey
+      name: X-Custom
+      in: header
+paths:
+  /aws:
+    post:
+      operationId: aws
+      security: [{sigv4: []}]
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object}
+      responses: {"200": {description: OK}}
+  /partner:
+    get:
+      operationId: partner
+      security: [{partner: []}]
+      responses: {"200": {description: OK}}
+  /custom:
+    get:
+      operationId: custom
+      security: [{custom: []}]
+      responses: {"200": {description: OK}}
+`))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	t.Setenv("AWS_REGION", "eu-west-1")
+	srv := server.NewMCPServer("test", "1.0.0")
+	// In-house signers plug in by scheme name, and see the request after interceptors
+	custom := RequestSignerFunc(func(req *http.Request, body []byte, credential string) error {
+		req.Header.Set("X-Custom", credential+":"+req.Header.Get("X-Intercepted"))
+		return nil
+	})
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{
+		Credentials: map[string]string{"partner": "partner-secret", "custom": "in-house"},
+		Signers:     map[string]RequestSigner{"custom": custom},
+		RequestInterceptors: []RequestInterceptor{RequestInterceptorFunc(func(ctx context.Context, op OpenAPIOperation, req *http.Request) error {
+			req.Header.Set("X-Intercepted", "yes")
+			return nil
+		})},
+	})
+	call := func(tool string, args map[string]any) mcp.CallToolResult {
+		t.Helper()
+		msg, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": map[string]any{"name": tool, "arguments": args}})
+		return srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	}
+
+	if result := call("aws", map[string]any{"requestBody": map[string]any{"a": 1}, "__confirmed": true}); result.IsError {
+		t.Fatalf("aws call failed: %+v", result.Content)
+	}
+	if auth := last.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/execute-api/aws4_request, SignedHeaders=content-type;host;x-amz-date,") {
+		t.Errorf("unexpected SigV4 Authorization header %q", auth)
+	}
+
+	call("partner", map[string]any{})
+	if sig := last.Header.Get("X-Signature"); !strings.HasPrefix(sig, `keyId="partner-1",algorithm="hmac-sha256",headers="(request-target) date digest"`) || last.Header.Get("Digest") == "" {
+		t.Errorf("unexpected HMAC signature %q", sig)
+	}
+
+	call("custom", map[string]any{})
+	if got := last.Header.Get("X-Custom"); got != "in-house:yes" {
+		t.Errorf("the custom signer should run after interceptors, got %q", got)
+	}
+
+	// Without AWS credentials, the missing signature is reported
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	result := call("aws", map[string]any{"__confirmed": true})
+	if !result.IsError || !strings.Contains(result.Content[0].(mcp.TextContent).Text, "sigv4 (AWS Signature Version 4; --credential sigv4=... or AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)") {
+		t.Errorf("expected missing AWS credentials to be reported: %+v", result.Content)
+	}
+}
+
+func TestParseSigner(t *testing.T) {
+	name, signer, err := ParseSigner("api=aws-sigv4,region=us-west-2,service=lambda")
+	if err != nil || name != "api" {
+		t.Fatalf("unexpected result %q %v", name, err)
+	}
+	if s := signer.(*sigV4Signer); s.region != "us-west-2" || s.service != "lambda" {
+		t.Errorf("unexpected signer options %+v", s)
+	}
+	for _, bad := range []string{"api", "api=unknown", "api=hmac,keyId"} {
+		if _, _, err := ParseSigner(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
@@ -145,6 +145,8 @@ func LoadOpenAPISpecFromBytes(data []byte) (*openapi3.T, error) {
 }
 
 // ExtractOpenAPIOperations extracts all operations from the OpenAPI spec, merging path-level and operation-level parameters.
+// Operations marked x-mcp-hidden, or tagged with a tag marked x-mcp-exclude, are skipped; x-mcp-name and
+// x-mcp-description replace the operationId and description.
 // Returns a slice of OpenAPIOperation describing each operation.
 // Example usage for ExtractOpenAPIOperations:
 //
@@ -153,13 +155,22 @@ func LoadOpenAPISpecFromBytes(data []byte) (*openapi3.T, error) {
 //	ops := openapi2mcp.ExtractOpenAPIOperations(doc)
 func ExtractOpenAPIOperations(doc *openapi3.T) []OpenAPIOperation {
 	var ops []OpenAPIOperation
+	excluded := excludedTags(doc)
 	for path, pathItem := range doc.Paths.Map() {
 		for method, op := range pathItem.Operations() {
+			if isOperationHidden(op, excluded) {
+				continue
+			}
 			id := op.OperationID
-			if id == "" {
+			if name, ok := extString(op.Extensions, extMCPName); ok {
+				id = name
+			} else if id == "" {
 				id = fmt.Sprintf("%s_%s", method, path)
 			}
 			desc := op.Description
+			if mcpDesc, ok := extString(op.Extensions, extMCPDescription); ok {
+				desc = mcpDesc
+			}
 
 			// Merge path-level and operation-level parameters
 			mergedParams := openapi3.Parameters{}
@@ -183,6 +194,17 @@ func ExtractOpenAPIOperations(doc *openapi3.T) []OpenAPIOperation {
 				servers = *op.Servers
 			}
 
+			// Invalid extension values are ignored here and reported by lint
+			var dangerous *bool
+			if v, ok := extBool(op.Extensions, extMCPDangerous); ok {
+				dangerous = &v
+			}
+			timeout, _ := parseMCPTimeout(op.Extensions[extMCPTimeout])
+			examples, _ := operationExamples(op.Extensions)
+			async, _ := parseAsyncExtension(op.Extensions[extMCPAsync])
+			stream, _ := parseStreamExtension(op.Extensions[extMCPStream])
+			pagination, _ := parsePaginationExtension(op.Extensions[extMCPPagination])
+
 			ops = append(ops, OpenAPIOperation{
 				OperationID: id,
 				Summary:     op.Summary,
@@ -191,9 +213,16 @@ func ExtractOpenAPIOperations(doc *openapi3.T) []OpenAPIOperation {
 				Method:      method,
 				Parameters:  mergedParams,
 				RequestBody: op.RequestBody,
+				Responses:   op.Responses,
 				Tags:        tags,
 				Servers:     servers,
 				Security:    security,
+				Dangerous:   dangerous,
+				Timeout:     timeout,
+				Examples:    examples,
+				Async:       async,
+				Stream:      stream,
+				Pagination:  pagination,
 			})
 		}
 	}
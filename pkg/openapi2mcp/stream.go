@@ -0,0 +1,316 @@
+// stream.go
+package openapi2mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	mcpserver "github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+// StreamOptions limits how a tool relays a streaming response: server-sent events (text/event-stream)
+// or newline-delimited JSON (application/x-ndjson and the like). Such responses are read as they arrive
+// rather than to their end: each event is relayed to the client as a progress notification and a log
+// message, and the tool result is an aggregated summary of the events. Zero fields take their defaults.
+type StreamOptions struct {
+	MaxEvents   int           // stop after this many events (default 1000)
+	MaxDuration time.Duration // stop reading after this long (default 1m)
+	Terminator  string        // regular expression: stop at the first event whose data matches, e.g. `^\[DONE\]$`
+}
+
+const (
+	defaultStreamMaxEvents   = 1000
+	defaultStreamMaxDuration = time.Minute
+	// maxStreamResultText is the largest amount of event data included in a tool result.
+	maxStreamResultText = 64 << 10
+	// maxStreamLineSize is the longest event line or NDJSON record read.
+	maxStreamLineSize = 1 << 20
+)
+
+// streamConfig is the compiled StreamOptions of an operation.
+type streamConfig struct {
+	maxEvents   int
+	maxDuration time.Duration
+	terminator  *regexp.Regexp
+}
+
+// streamConfigFor returns the stream limits of an operation: its x-mcp-stream, overridden by the
+// non-zero fields of configured[operationId] or else configured["*"], with defaults applied.
+// An invalid terminator is ignored with a warning.
+func streamConfigFor(op OpenAPIOperation, configured map[string]StreamOptions) *streamConfig {
+	var options StreamOptions
+	if op.Stream != nil {
+		options = *op.Stream
+	}
+	override, ok := configured[op.OperationID]
+	if !ok {
+		override = configured["*"]
+	}
+	if override.MaxEvents > 0 {
+		options.MaxEvents = override.MaxEvents
+	}
+	if override.MaxDuration > 0 {
+		options.MaxDuration = override.MaxDuration
+	}
+	if override.Terminator != "" {
+		options.Terminator = override.Terminator
+	}
+	config := &streamConfig{maxEvents: options.MaxEvents, maxDuration: options.MaxDuration}
+	if config.maxEvents <= 0 {
+		config.maxEvents = defaultStreamMaxEvents
+	}
+	if config.maxDuration <= 0 {
+		config.maxDuration = defaultStreamMaxDuration
+	}
+	if options.Terminator != "" {
+		terminator, err := regexp.Compile(options.Terminator)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[WARN] Ignoring the stream terminator of %s: %v\n", op.OperationID, err)
+		}
+		config.terminator = terminator
+	}
+	return config
+}
+
+// streamFormat returns the streaming format of a response content type: "sse", "ndjson", or "" if it isn't one.
+func streamFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "text/event-stream":
+		return "sse"
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines", "application/stream+json":
+		return "ndjson"
+	}
+	return ""
+}
+
+// streamEvent is a server-sent event or an NDJSON record.
+type streamEvent struct {
+	Type string // SSE event type; empty for NDJSON records and "message" events
+	ID   string
+	Data string
+}
+
+// readStream reads the events of a streaming body and calls emit for each one until it returns false.
+// An event still incomplete at the end of the body is emitted too.
+func readStream(format string, body io.Reader, emit func(streamEvent) bool) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxStreamLineSize)
+	if format == "ndjson" {
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !emit(streamEvent{Data: line}) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+
+	var event streamEvent
+	var data []string
+	dispatch := func() bool {
+		if len(data) == 0 {
+			event = streamEvent{}
+			return true
+		}
+		event.Data = strings.Join(data, "\n")
+		if event.Type == "message" {
+			event.Type = ""
+		}
+		more := emit(event)
+		event, data = streamEvent{}, nil
+		return more
+	}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if !dispatch() {
+				return nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comment, e.g. a keep-alive
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data = append(data, value)
+		case "event":
+			event.Type = value
+		case "id":
+			event.ID = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	dispatch()
+	return nil
+}
+
+// streamSummary aggregates the events of a streaming response.
+type streamSummary struct {
+	events     int
+	bytes      int64
+	duration   time.Duration
+	stopReason string // end_of_stream, terminator, max_events, max_duration or error
+	stopDetail string
+	eventTypes map[string]int
+	lines      []string // event data included in the result, up to maxStreamResultText
+	omitted    int
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	n, err := r.Reader.Read(b)
+	r.n += int64(n)
+	return n, err
+}
+
+// relayStream reads a streaming response as it arrives, relays each event to the client as a progress
+// notification and a log message from the tool, and returns the summary of the events read until a stop condition.
+func relayStream(ctx context.Context, server *mcpserver.MCPServer, tool, format string, resp *http.Response, config *streamConfig, progress *callProgress) *streamSummary {
+	start := time.Now()
+	var expired atomic.Bool
+	timer := time.AfterFunc(config.maxDuration, func() {
+		expired.Store(true)
+		resp.Body.Close()
+	})
+	defer timer.Stop()
+
+	summary := &streamSummary{eventTypes: map[string]int{}}
+	body := &countingReader{Reader: resp.Body}
+	textSize := 0
+	err := readStream(format, body, func(event streamEvent) bool {
+		if config.terminator != nil && config.terminator.MatchString(event.Data) {
+			summary.stopReason = "terminator"
+			return false
+		}
+		summary.events++
+		line := event.Data
+		if event.Type != "" {
+			summary.eventTypes[event.Type]++
+			line = event.Type + ": " + line
+		}
+		if textSize+len(line) <= maxStreamResultText {
+			summary.lines = append(summary.lines, line)
+			textSize += len(line) + 1
+		} else {
+			summary.omitted++
+		}
+		message := line
+		if len(message) > 200 {
+			message = strings.ToValidUTF8(message[:200], "") + "..."
+		}
+		progress.step(fmt.Sprintf("Event %d: %s", summary.events, message))
+		if server != nil {
+			_ = server.SendLogMessageToClient(ctx, mcp.LoggingLevelInfo, tool, event.logData())
+		}
+		if summary.events >= config.maxEvents {
+			summary.stopReason = "max_events"
+			return false
+		}
+		return true
+	})
+	summary.bytes = body.n
+	summary.duration = time.Since(start)
+	if summary.stopReason == "" {
+		switch {
+		case expired.Load():
+			summary.stopReason = "max_duration"
+		case err != nil:
+			summary.stopReason, summary.stopDetail = "error", err.Error()
+		default:
+			summary.stopReason = "end_of_stream"
+		}
+	}
+	return summary
+}
+
+// logData returns the data of the log message relaying an event: its data, decoded if JSON.
+func (e streamEvent) logData() map[string]any {
+	var data any = e.Data
+	var decoded any
+	if json.Unmarshal([]byte(e.Data), &decoded) == nil {
+		data = decoded
+	}
+	logData := map[string]any{"data": data}
+	if e.Type != "" {
+		logData["event"] = e.Type
+	}
+	if e.ID != "" {
+		logData["id"] = e.ID
+	}
+	return logData
+}
+
+// stopDescription describes why the relay stopped reading the stream.
+func (s *streamSummary) stopDescription(config *streamConfig) string {
+	switch s.stopReason {
+	case "terminator":
+		return "received the terminator event"
+	case "max_events":
+		return fmt.Sprintf("reached the limit of %d events", config.maxEvents)
+	case "max_duration":
+		return fmt.Sprintf("reached the time limit of %s", config.maxDuration)
+	case "error":
+		return "read error: " + s.stopDetail
+	}
+	return "the stream ended"
+}
+
+// result returns the tool result summarizing a relayed stream. It is partial if a limit stopped the relay.
+func (s *streamSummary) result(method, fullURL string, status int, config *streamConfig, inputSchema, args map[string]any) *mcp.CallToolResult {
+	var text strings.Builder
+	fmt.Fprintf(&text, "HTTP %s %s\nStatus: %d\n", strings.ToUpper(method), fullURL, status)
+	fmt.Fprintf(&text, "Streamed %d events (%s) in %s; stopped: %s\n", s.events, formatBytes(s.bytes), s.duration.Round(time.Millisecond), s.stopDescription(config))
+	if len(s.lines) > 0 {
+		text.WriteString("Events:\n" + strings.Join(s.lines, "\n"))
+	}
+	if s.omitted > 0 {
+		fmt.Fprintf(&text, "\n... %d more events not shown", s.omitted)
+	}
+	structured := map[string]any{
+		"type":       "stream_summary",
+		"events":     s.events,
+		"bytes":      s.bytes,
+		"durationMs": s.duration.Milliseconds(),
+		"stopReason": s.stopReason,
+	}
+	if len(s.eventTypes) > 0 {
+		structured["eventTypes"] = s.eventTypes
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: text.String(),
+			},
+		},
+		StructuredContent: structured,
+		Schema:            inputSchema,
+		Arguments:         args,
+		Usage:             "call <tool> <json-args>",
+		NextSteps:         []string{"list", "schema <tool>"},
+		Partial:           s.stopReason == "max_events" || s.stopReason == "max_duration",
+		OutputFormat:      "unstructured",
+		OutputType:        "text",
+	}
+}
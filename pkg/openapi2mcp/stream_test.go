@@ -0,0 +1,140 @@
+package openapi2mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+func TestReadStream(t *testing.T) {
+	collect := func(format, body string) []streamEvent {
+		var events []streamEvent
+		if err := readStream(format, strings.NewReader(body), func(e streamEvent) bool {
+			events = append(events, e)
+			return true
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return events
+	}
+	sse := collect("sse", ": keep-alive\n\nid: 1\ndata: {\"a\":1}\n\nevent: log\r\ndata: line 1\r\ndata: line 2\r\n\r\nevent: message\ndata:last")
+	want := []streamEvent{{ID: "1", Data: `{"a":1}`}, {Type: "log", Data: "line 1\nline 2"}, {Data: "last"}}
+	if fmt.Sprint(sse) != fmt.Sprint(want) {
+		t.Errorf("unexpected SSE events %q", sse)
+	}
+	ndjson := collect("ndjson", "{\"n\":1}\n\n{\"n\":2}\n")
+	if len(ndjson) != 2 || ndjson[1].Data != `{"n":2}` {
+		t.Errorf("unexpected NDJSON events %q", ndjson)
+	}
+	if streamFormat("text/event-stream; charset=utf-8") != "sse" || streamFormat("application/x-ndjson") != "ndjson" || streamFormat("application/json") != "" {
+		t.Error("unexpected streaming formats")
+	}
+}
+
+// newStreamTestServer serves a tool whose upstream API streams events, then waits for the client to go away.
+func newStreamTestServer(t *testing.T, contentType string, events []string, stream string) *server.MCPServer {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		for _, event := range events {
+			fmt.Fprint(w, event)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(upstream.Close)
+	return newTestServer(t, `
+openapi: 3.0.0
+info: {title: Logs, version: "1.0"}
+servers: [{url: "`+upstream.URL+`"}]
+paths:
+  /logs:
+    get:
+      operationId: tailLogs
+      x-mcp-stream: `+stream+`
+      responses: {"200": {description: OK}}
+`, &ToolGenOptions{}, server.WithLogging())
+}
+
+func TestStreamStopConditions(t *testing.T) {
+	// The terminator ends the relay even though the upstream stream stays open
+	srv := newStreamTestServer(t, "text/event-stream", []string{"data: one\n\n", "event: progress\ndata: two\n\n", "data: [DONE]\n\n", "data: after\n\n"}, `{terminator: "^\\[DONE\\]$"}`)
+	result := callTool(t, srv, nil, "tailLogs", `{}`)
+	text := resultText(result)
+	summary := result.StructuredContent.(map[string]any)
+	if summary["stopReason"] != "terminator" || summary["events"] != 2 || result.Partial || !strings.Contains(text, "one\nprogress: two") || strings.Contains(text, "after") {
+		t.Errorf("unexpected result %v:\n%s", summary, text)
+	}
+
+	srv = newStreamTestServer(t, "application/x-ndjson", []string{"{\"n\":1}\n", "{\"n\":2}\n", "{\"n\":3}\n"}, `{maxEvents: 2}`)
+	result = callTool(t, srv, nil, "tailLogs", `{}`)
+	if summary := result.StructuredContent.(map[string]any); summary["stopReason"] != "max_events" || summary["events"] != 2 || !result.Partial {
+		t.Errorf("unexpected result %v:\n%s", summary, resultText(result))
+	}
+
+	start := time.Now()
+	srv = newStreamTestServer(t, "text/event-stream", []string{"data: tick\n\n"}, `{maxDuration: 0.2}`)
+	result = callTool(t, srv, nil, "tailLogs", `{}`)
+	if summary := result.StructuredContent.(map[string]any); summary["stopReason"] != "max_duration" || summary["events"] != 1 || time.Since(start) > 5*time.Second {
+		t.Errorf("unexpected result %v:\n%s", summary, resultText(result))
+	}
+}
+
+func TestStreamEventsRelayed(t *testing.T) {
+	srv := newStreamTestServer(t, "text/event-stream", []string{"data: {\"line\": \"starting\"}\n\n", "event: done\ndata: bye\n\n"}, `{maxEvents: 2}`)
+	ts := httptest.NewServer(HandlerForStreamableHTTP(srv, "/mcp"))
+	defer ts.Close()
+	post := func(session, body string) *http.Response {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if session != "" {
+			req.Header.Set("Mcp-Session-Id", session)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	resp := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`)
+	resp.Body.Close()
+	resp = post(resp.Header.Get("Mcp-Session-Id"), `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"tailLogs","arguments":{},"_meta":{"progressToken":"p"}}}`)
+	defer resp.Body.Close()
+
+	var logs []any
+	var progress []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var message struct {
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		json.Unmarshal([]byte(data), &message)
+		switch message.Method {
+		case "notifications/message":
+			if message.Params["logger"] != "tailLogs" || message.Params["level"] != "info" {
+				t.Errorf("unexpected log message %v", message.Params)
+			}
+			logs = append(logs, message.Params["data"])
+		case "notifications/progress":
+			progress = append(progress, message.Params["message"].(string))
+		}
+	}
+	if fmt.Sprint(logs) != "[map[data:map[line:starting]] map[data:bye event:done]]" {
+		t.Errorf("unexpected log messages %v", logs)
+	}
+	if len(progress) != 3 || progress[2] != "Event 2: done: bye" {
+		t.Errorf("unexpected progress messages %q", progress)
+	}
+}
@@ -0,0 +1,135 @@
+// template.go
+package openapi2mcp
+
+import (
+	"math/rand"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// operationTemplate holds everything about an operation's upstream request that does not depend on
+// the tool arguments. It is computed once at registration so that tool calls only fill in values.
+type operationTemplate struct {
+	op  OpenAPIOperation
+	doc *openapi3.T
+
+	// Parameters by location, in spec order
+	pathParams   []templateParam
+	queryParams  []templateParam
+	headerParams []templateParam
+	cookieParams []templateParam
+
+	// requestContentType is the JSON media type used for request bodies, or "" if the operation takes none
+	requestContentType string
+
+	// servers are the operation's (or the spec's) server URLs with their variables substituted,
+	// used when OPENAPI_BASE_URL is not set
+	servers []string
+
+	// oauth2 obtains tokens for oauth2 security schemes; nil when requests are not sent (mock mode)
+	oauth2 *oauth2Client
+
+	// credentials are bound to security scheme names (ToolGenOptions.Credentials and CredentialSources)
+	credentials map[string]CredentialSource
+	// signers sign requests for security scheme names (ToolGenOptions.Signers and x-mcp-auth)
+	signers map[string]RequestSigner
+}
+
+// templateParam is a parameter with the argument names it can be supplied under resolved in advance.
+type templateParam struct {
+	name      string // name in the spec, used on the wire
+	argName   string // tool argument name (x-mcp-name or escaped name)
+	isInteger bool
+}
+
+// newOperationTemplate precomputes the argument-independent parts of an operation's request.
+// serverVariables override the defaults of the server URL variables.
+func newOperationTemplate(op OpenAPIOperation, doc *openapi3.T, oauth2 *oauth2Client, credentials map[string]CredentialSource, signers map[string]RequestSigner, serverVariables map[string]string) *operationTemplate {
+	t := &operationTemplate{op: op, doc: doc, oauth2: oauth2, credentials: credentials, signers: signers}
+	for _, paramRef := range op.Parameters {
+		if paramRef == nil || paramRef.Value == nil {
+			continue
+		}
+		p := paramRef.Value
+		tp := templateParam{name: p.Name, argName: parameterArgumentName(p)}
+		if p.Schema != nil && p.Schema.Value != nil && p.Schema.Value.Type != nil {
+			tp.isInteger = p.Schema.Value.Type.Is("integer")
+		}
+		switch p.In {
+		case "path":
+			t.pathParams = append(t.pathParams, tp)
+		case "query":
+			t.queryParams = append(t.queryParams, tp)
+		case "header":
+			t.headerParams = append(t.headerParams, tp)
+		case "cookie":
+			t.cookieParams = append(t.cookieParams, tp)
+		}
+	}
+
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		// Check for application/json first, then application/vnd.api+json (including with parameters)
+		for _, contentType := range []string{"application/json", "application/vnd.api+json"} {
+			if mt := getContentByType(op.RequestBody.Value.Content, contentType); mt != nil {
+				if mt.Schema != nil && mt.Schema.Value != nil {
+					t.requestContentType = contentType
+				}
+				break
+			}
+		}
+	}
+
+	servers := op.Servers
+	if len(servers) == 0 && doc != nil {
+		servers = doc.Servers
+	}
+	for _, s := range servers {
+		if s != nil && s.URL != "" {
+			t.servers = append(t.servers, serverURL(s, serverVariables))
+		}
+	}
+	if len(t.servers) == 0 {
+		t.servers = []string{"http://localhost:8080"}
+	}
+	return t
+}
+
+// baseURL returns the base URL of a call: OPENAPI_BASE_URL, or one of the servers picked at random.
+// It is picked once per call, so that a preview shows the server the call is sent to.
+func (t *operationTemplate) baseURL() string {
+	if baseURL := os.Getenv("OPENAPI_BASE_URL"); baseURL != "" {
+		return baseURL
+	}
+	return t.servers[rand.Intn(len(t.servers))]
+}
+
+// serverURL substitutes the variables of a server URL template, such as https://{region}.api.example.com,
+// with the values of overrides or else their defaults.
+func serverURL(s *openapi3.Server, overrides map[string]string) string {
+	u := s.URL
+	for name, variable := range s.Variables {
+		value, ok := overrides[name]
+		if !ok && variable != nil {
+			value = variable.Default
+		}
+		u = strings.ReplaceAll(u, "{"+name+"}", value)
+	}
+	return u
+}
+
+// value returns the argument supplied for a parameter, under its tool argument name,
+// its escaped name, or (for backward compatibility) its original name.
+func (tp templateParam) value(args map[string]any) (any, bool) {
+	if val, ok := args[tp.argName]; ok {
+		return val, true
+	}
+	if escaped := escapeParameterName(tp.name); escaped != tp.argName {
+		if val, ok := args[escaped]; ok {
+			return val, true
+		}
+	}
+	val, ok := args[tp.name]
+	return val, ok
+}
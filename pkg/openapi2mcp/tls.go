@@ -0,0 +1,152 @@
+// tls.go
+package openapi2mcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// TLSConfig configures HTTPS for the MCP HTTP listeners.
+type TLSConfig struct {
+	CertFile string // PEM certificate chain
+	KeyFile  string // PEM private key
+	// ClientCAFile, if set, requires clients to present a certificate issued by one of its PEM CAs (mTLS).
+	// The certificate's subject then identifies the client (see IdentityFromContext).
+	ClientCAFile string
+}
+
+// TLSCertificates holds the server certificate and client CAs of a TLSConfig, and can load them
+// again, e.g. after a certificate renewal. Connections in progress keep their certificates.
+type TLSCertificates struct {
+	config TLSConfig
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// LoadTLSCertificates loads the certificates of a TLSConfig.
+func LoadTLSCertificates(cfg TLSConfig) (*TLSCertificates, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("TLS requires both a certificate and a key file")
+	}
+	c := &TLSCertificates{config: cfg}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the certificate, key and client CA files again. On error, the previous ones are kept.
+func (c *TLSCertificates) Reload() error {
+	cert, err := tls.LoadX509KeyPair(c.config.CertFile, c.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if c.config.ClientCAFile != "" {
+		data, err := os.ReadFile(c.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("loading client CAs: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(data) {
+			return fmt.Errorf("loading client CAs: no PEM certificate in %s", c.config.ClientCAFile)
+		}
+	}
+	c.mu.Lock()
+	c.cert, c.clientCAs = &cert, clientCAs
+	c.mu.Unlock()
+	return nil
+}
+
+// ReloadOnSIGHUP reloads the certificates whenever the process receives SIGHUP.
+// Failures are reported on stderr.
+func (c *TLSCertificates) ReloadOnSIGHUP() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			if err := c.Reload(); err != nil {
+				fmt.Fprintf(os.Stderr, "[WARN] keeping the previous TLS certificates: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(os.Stderr, "Reloaded TLS certificates")
+		}
+	}()
+}
+
+// Config returns a tls.Config serving the current certificates.
+func (c *TLSCertificates) Config() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+			return c.cert, nil
+		},
+	}
+	if c.config.ClientCAFile != "" {
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+		base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+			config := base.Clone()
+			config.GetConfigForClient = nil
+			config.ClientCAs = c.clientCAs
+			return config, nil
+		}
+	}
+	return base
+}
+
+// WithTLS serves the MCP HTTP transports over HTTPS. Clients authenticated by a certificate get its
+// subject as their identity, unless an authenticator (see WithAuthenticator) identifies them otherwise.
+func WithTLS(certs *TLSCertificates) HTTPOption {
+	return func(o *httpOptions) {
+		o.tls = certs
+	}
+}
+
+// clientCertificateIdentity adds the identity of the verified client certificate, if any, to the
+// request context.
+func clientCertificateIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			cert := r.TLS.VerifiedChains[0][0]
+			identity := &Identity{
+				Subject: cert.Subject.String(),
+				Method:  "mtls",
+				Groups:  cert.Subject.OrganizationalUnit,
+				Claims:  map[string]any{"cn": cert.Subject.CommonName, "serial": cert.SerialNumber.String()},
+			}
+			if len(cert.DNSNames) > 0 {
+				identity.Claims["dns_names"] = cert.DNSNames
+			}
+			if len(cert.EmailAddresses) > 0 {
+				identity.Claims["emails"] = cert.EmailAddresses
+			}
+			r = r.WithContext(ContextWithIdentity(r.Context(), identity))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ListenAndServe serves handler on addr, over HTTPS if WithTLS is among the options.
+// Other options are ignored: they apply to the handlers built by HandlerForBasePath and
+// HandlerForStreamableHTTP.
+func ListenAndServe(addr string, handler http.Handler, opts ...HTTPOption) error {
+	o := newHTTPOptions(opts)
+	if o.tls == nil {
+		return http.ListenAndServe(addr, handler)
+	}
+	server := &http.Server{Addr: addr, Handler: handler, TLSConfig: o.tls.Config()}
+	return server.ListenAndServeTLS("", "")
+}
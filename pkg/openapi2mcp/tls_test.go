@@ -0,0 +1,115 @@
+package openapi2mcp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// issueTestCertificate returns a certificate for template, signed by parent (self-signed if nil).
+func issueTestCertificate(t *testing.T, template *x509.Certificate, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerCert := any(key), template
+	if parent != nil {
+		signer, signerCert = parent.PrivateKey, parent.Leaf
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, _ := x509.ParseCertificate(der)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func writeTestCertificate(t *testing.T, cert tls.Certificate, certPath, keyPath string) {
+	t.Helper()
+	keyDER, _ := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600)
+	if keyPath != "" {
+		os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600)
+	}
+}
+
+func TestTLSClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "Test CA"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil)
+	serverCert := func(serial int64) tls.Certificate {
+		return issueTestCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(serial), Subject: pkix.Name{CommonName: "localhost"}, IPAddresses: []net.IP{net.ParseIP("127.0.0.1")}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}}, &ca)
+	}
+	client := issueTestCertificate(t, &x509.Certificate{SerialNumber: big.NewInt(3), Subject: pkix.Name{CommonName: "ci", OrganizationalUnit: []string{"support"}}, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}, &ca)
+	certPath, keyPath, caPath := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key"), filepath.Join(dir, "ca.pem")
+	writeTestCertificate(t, serverCert(2), certPath, keyPath)
+	writeTestCertificate(t, ca, caPath, "")
+
+	certs, err := LoadTLSCertificates(TLSConfig{CertFile: certPath, KeyFile: keyPath, ClientCAFile: caPath})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Clients with a certificate need no other credentials
+	auth, _ := NewAuthenticator(HTTPAuthConfig{BearerTokens: map[string]string{"t0k3n": "bot"}})
+	var reached *Identity
+	o := newHTTPOptions([]HTTPOption{WithTLS(certs), WithAuthenticator(auth)})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{TLSConfig: certs.Config(), Handler: o.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = IdentityFromContext(r.Context())
+	}))}
+	go srv.ServeTLS(ln, "", "")
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+	get := func(clientCerts ...tls.Certificate) (*http.Response, error) {
+		reached = nil
+		transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: clientCerts}}
+		defer transport.CloseIdleConnections()
+		resp, err := (&http.Client{Transport: transport}).Get("https://" + ln.Addr().String() + "/mcp")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	if _, err := get(); err == nil {
+		t.Error("expected clients without a certificate to be rejected")
+	}
+	resp, err := get(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reached == nil || reached.Subject != "CN=ci,OU=support" || reached.Method != "mtls" || len(reached.Groups) != 1 || reached.Groups[0] != "support" {
+		t.Errorf("unexpected identity %+v", reached)
+	}
+	if resp.TLS.PeerCertificates[0].SerialNumber.Int64() != 2 {
+		t.Errorf("unexpected server certificate %v", resp.TLS.PeerCertificates[0].SerialNumber)
+	}
+
+	// New connections get the reloaded certificate; an invalid one is not loaded
+	writeTestCertificate(t, serverCert(4), certPath, keyPath)
+	if err := certs.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(keyPath, []byte("garbage"), 0o600)
+	if err := certs.Reload(); err == nil {
+		t.Error("expected an invalid key to be rejected")
+	}
+	if resp, err := get(client); err != nil || resp.TLS.PeerCertificates[0].SerialNumber.Int64() != 4 {
+		t.Errorf("expected the reloaded certificate to be served: %v", err)
+	}
+}
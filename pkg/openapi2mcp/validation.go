@@ -0,0 +1,347 @@
+// validation.go
+package openapi2mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ValidationError is the structured payload returned (as structuredContent) when tool arguments
+// do not match the tool's input schema.
+type ValidationError struct {
+	Code       string                `json:"error"` // always "invalid_arguments"
+	Tool       string                `json:"tool"`
+	Violations []ValidationViolation `json:"violations"`
+	// SuggestedArguments are the received arguments with every violation that has a suggestion fixed
+	SuggestedArguments map[string]any `json:"suggestedArguments,omitempty"`
+}
+
+// ValidationViolation describes one argument that failed validation.
+type ValidationViolation struct {
+	Pointer    string         `json:"pointer"`              // JSON Pointer (RFC 6901) into the arguments
+	Keyword    string         `json:"keyword"`              // violated constraint, e.g. "required", "enum", "number_lte"
+	Message    string         `json:"message"`              // human-readable description
+	Expected   map[string]any `json:"expected,omitempty"`   // schema constraints that apply at Pointer
+	Received   any            `json:"received,omitempty"`   // value found at Pointer, if any
+	Suggestion any            `json:"suggestion,omitempty"` // replacement value derived from the schema, if any
+}
+
+// expectedKeywords lists, for each validator keyword, the schema constraints reported as expected.
+var expectedKeywords = map[string][]string{
+	"required":             {"type", "format", "enum"},
+	"invalid_type":         {"type", "format"},
+	"enum":                 {"enum"},
+	"const":                {"const"},
+	"string_gte":           {"minLength"},
+	"string_lte":           {"maxLength"},
+	"pattern":              {"pattern"},
+	"format":               {"format"},
+	"number_gte":           {"minimum", "exclusiveMinimum"},
+	"number_gt":            {"minimum", "exclusiveMinimum"},
+	"number_lte":           {"maximum", "exclusiveMaximum"},
+	"number_lt":            {"maximum", "exclusiveMaximum"},
+	"multiple_of":          {"multipleOf"},
+	"array_min_items":      {"minItems"},
+	"array_max_items":      {"maxItems"},
+	"unique":               {"uniqueItems"},
+	"array_min_properties": {"minProperties"},
+	"array_max_properties": {"maxProperties"},
+	"number_one_of":        {"oneOf"},
+	"number_any_of":        {"anyOf"},
+}
+
+// newValidationError builds the structured description of validation failures.
+// schema is the tool's decoded input schema and args the received arguments.
+func newValidationError(tool string, schema map[string]any, args map[string]any, errs []gojsonschema.ResultError) *ValidationError {
+	ve := &ValidationError{Code: "invalid_arguments", Tool: tool, Violations: []ValidationViolation{}}
+	var suggested map[string]any
+	for _, verr := range errs {
+		loc := argumentLocation(args, verr.Context())
+		keyword := verr.Type()
+		if prop, ok := verr.Details()["property"].(string); ok && (keyword == "required" || keyword == "additional_property_not_allowed") {
+			loc = append(loc, prop)
+		}
+		node := schemaAt(schema, loc)
+		v := ValidationViolation{
+			Pointer:  jsonPointer(loc),
+			Keyword:  keyword,
+			Message:  validationMessage(verr, node, loc),
+			Expected: expectedConstraints(keyword, node, schemaAt(schema, loc[:max(len(loc)-1, 0)])),
+		}
+		received, hasReceived := lookupAt(args, loc)
+		if hasReceived {
+			v.Received = received
+		}
+
+		switch suggestion, ok := suggestValue(keyword, node, received, hasReceived); {
+		case keyword == "additional_property_not_allowed" && len(loc) > 0:
+			if suggested == nil {
+				suggested = deepCopyValue(args).(map[string]any)
+			}
+			removeAt(suggested, loc)
+		case ok && len(loc) > 0:
+			v.Suggestion = suggestion
+			if suggested == nil {
+				suggested = deepCopyValue(args).(map[string]any)
+			}
+			setAtCreating(suggested, loc, suggestion)
+		}
+		ve.Violations = append(ve.Violations, v)
+	}
+	ve.SuggestedArguments = suggested
+	return ve
+}
+
+// Text renders the violations for clients that only display text content.
+func (ve *ValidationError) Text() string {
+	var b strings.Builder
+	for _, v := range ve.Violations {
+		pointer := v.Pointer
+		if pointer == "" {
+			pointer = "(root)"
+		}
+		fmt.Fprintf(&b, "%s: %s", pointer, v.Message)
+		if v.Suggestion != nil {
+			suggestion, _ := json.Marshal(v.Suggestion)
+			fmt.Fprintf(&b, " Suggested value: %s.", suggestion)
+		}
+		b.WriteString("\n")
+	}
+	if ve.SuggestedArguments != nil {
+		suggested, _ := json.Marshal(ve.SuggestedArguments)
+		fmt.Fprintf(&b, "\nTry again with: call %s %s", ve.Tool, suggested)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// validationMessage describes a violation, adding the property description for missing parameters.
+func validationMessage(verr gojsonschema.ResultError, node map[string]any, loc []any) string {
+	if verr.Type() != "required" || len(loc) == 0 {
+		return verr.Description()
+	}
+	missing := fmt.Sprint(loc[len(loc)-1])
+	var info []string
+	if desc, _ := node["description"].(string); desc != "" {
+		info = append(info, desc)
+	}
+	if typeStr := schemaType(node); typeStr != "" {
+		info = append(info, "type: "+typeStr)
+	}
+	if len(info) == 0 {
+		return "Missing required parameter: '" + missing + "'"
+	}
+	return "Missing required parameter: '" + missing + "' (" + strings.Join(info, ", ") + "). Please provide this parameter."
+}
+
+// expectedConstraints returns the schema constraints relevant to a violated keyword.
+func expectedConstraints(keyword string, node, parent map[string]any) map[string]any {
+	expected := map[string]any{}
+	if keyword == "additional_property_not_allowed" {
+		if props, ok := parent["properties"].(map[string]any); ok {
+			expected["properties"] = sortedKeys(props)
+		}
+		return expected
+	}
+	if keyword == "required" {
+		expected["required"] = true
+	}
+	for _, key := range expectedKeywords[keyword] {
+		if value, ok := node[key]; ok {
+			expected[key] = value
+		}
+	}
+	if len(expected) == 0 {
+		return nil
+	}
+	return expected
+}
+
+// suggestValue derives a replacement value from the schema: first by repairing the received value
+// (converting its type, clamping it to bounds, picking the closest enum value), then from the schema's
+// examples, default or enum. Generic placeholders are never suggested.
+func suggestValue(keyword string, node map[string]any, received any, hasReceived bool) (any, bool) {
+	if node == nil {
+		return nil, false
+	}
+	if hasReceived {
+		switch keyword {
+		case "invalid_type":
+			if converted, ok := convertValue(schemaType(node), received); ok {
+				return converted, true
+			}
+		case "enum":
+			if s, ok := received.(string); ok {
+				enum, _ := node["enum"].([]any)
+				for _, allowed := range enum {
+					if a, ok := allowed.(string); ok && strings.EqualFold(strings.TrimSpace(s), a) {
+						return a, true
+					}
+				}
+			}
+		case "number_gte", "number_gt":
+			if minimum, ok := toFloat(node["minimum"]); ok {
+				return boundValue(node, minimum, keyword == "number_gt", 1)
+			}
+		case "number_lte", "number_lt":
+			if maximum, ok := toFloat(node["maximum"]); ok {
+				return boundValue(node, maximum, keyword == "number_lt", -1)
+			}
+		case "string_lte":
+			if s, ok := received.(string); ok {
+				if maxLength, ok := toFloat(node["maxLength"]); ok && int(maxLength) < len([]rune(s)) {
+					return string([]rune(s)[:int(maxLength)]), true
+				}
+			}
+		case "array_max_items":
+			if a, ok := received.([]any); ok {
+				if maxItems, ok := toFloat(node["maxItems"]); ok && int(maxItems) < len(a) {
+					return a[:int(maxItems)], true
+				}
+			}
+		}
+	}
+	if examples, ok := node["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0], true
+	}
+	if example, ok := node["example"]; ok && example != nil {
+		return example, true
+	}
+	if def, ok := node["default"]; ok && def != nil {
+		return def, true
+	}
+	if enum, ok := node["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0], true
+	}
+	if c, ok := node["const"]; ok {
+		return c, true
+	}
+	if format, _ := node["format"].(string); format != "" && schemaType(node) == "string" {
+		if example, ok := generateExampleValue(node).(string); ok && example != "example_string" {
+			return example, true
+		}
+	}
+	return nil, false
+}
+
+// boundValue returns the closest valid value to a minimum or maximum, stepping inside exclusive bounds
+// for integers (direction is +1 for a minimum and -1 for a maximum).
+func boundValue(node map[string]any, bound float64, exclusive bool, direction float64) (any, bool) {
+	exclusiveKey := "exclusiveMinimum"
+	if direction < 0 {
+		exclusiveKey = "exclusiveMaximum"
+	}
+	if excl, _ := node[exclusiveKey].(bool); excl {
+		exclusive = true
+	}
+	if schemaType(node) == "integer" {
+		if exclusive {
+			bound += direction
+		}
+		return int64(bound), true
+	}
+	if exclusive {
+		return nil, false
+	}
+	return bound, true
+}
+
+// argumentLocation converts a validator context ("(root).a.0") into a location in the arguments,
+// with array indices as ints.
+func argumentLocation(args map[string]any, ctx *gojsonschema.JsonContext) []any {
+	if ctx == nil {
+		return nil
+	}
+	segments := strings.Split(ctx.String("\x00"), "\x00")[1:]
+	loc := make([]any, 0, len(segments))
+	var node any = args
+	for _, seg := range segments {
+		if a, ok := node.([]any); ok {
+			if i, err := strconv.Atoi(seg); err == nil {
+				loc = append(loc, i)
+				if i < len(a) {
+					node = a[i]
+				} else {
+					node = nil
+				}
+				continue
+			}
+		}
+		loc = append(loc, seg)
+		m, _ := node.(map[string]any)
+		node = m[seg]
+	}
+	return loc
+}
+
+// jsonPointer formats a location as an RFC 6901 JSON Pointer.
+func jsonPointer(loc []any) string {
+	var b strings.Builder
+	for _, k := range loc {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(fmt.Sprint(k)))
+	}
+	return b.String()
+}
+
+// schemaAt returns the subschema describing the value at a location, or nil.
+func schemaAt(schema map[string]any, loc []any) map[string]any {
+	node := schema
+	for _, k := range loc {
+		if node == nil {
+			return nil
+		}
+		switch key := k.(type) {
+		case string:
+			props, _ := node["properties"].(map[string]any)
+			node, _ = props[key].(map[string]any)
+		case int:
+			node, _ = node["items"].(map[string]any)
+		}
+	}
+	return node
+}
+
+// lookupAt returns the value at a location and whether it exists.
+func lookupAt(root any, loc []any) (any, bool) {
+	if len(loc) == 0 {
+		return root, true
+	}
+	switch key := loc[len(loc)-1].(type) {
+	case string:
+		m, ok := getAt(root, loc[:len(loc)-1]).(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		v, ok := m[key]
+		return v, ok
+	case int:
+		a, ok := getAt(root, loc[:len(loc)-1]).([]any)
+		if !ok || key >= len(a) {
+			return nil, false
+		}
+		return a[key], true
+	}
+	return nil, false
+}
+
+// setAtCreating sets the value at a location, creating missing intermediate objects.
+func setAtCreating(root map[string]any, loc []any, value any) {
+	for i := 0; i < len(loc)-1; i++ {
+		key, ok := loc[i].(string)
+		if !ok {
+			break
+		}
+		parent, _ := getAt(root, loc[:i]).(map[string]any)
+		if parent == nil {
+			return
+		}
+		if _, exists := parent[key]; !exists {
+			parent[key] = map[string]any{}
+		}
+	}
+	setAt(root, loc, value)
+}
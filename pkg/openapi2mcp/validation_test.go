@@ -0,0 +1,125 @@
+package openapi2mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/mcp"
+	"github.com/jedisct1/openapi-mcp/pkg/mcp/server"
+)
+
+const validationTestSpec = `
+openapi: 3.0.0
+info: {title: Validation, version: "1.0"}
+paths:
+  /orders:
+    post:
+      operationId: createOrder
+      parameters:
+        - {name: region, in: query, required: true, description: Data region, schema: {type: string, enum: [eu, us]}}
+        - {name: limit, in: query, schema: {type: integer, minimum: 1, maximum: 50}}
+        - {name: currency, in: query, schema: {type: string, default: EUR}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [sku, contact]
+              properties:
+                sku: {type: string, example: SKU-123}
+                quantity: {type: integer}
+                contact: {type: string, format: email}
+                tags: {type: array, items: {type: string, maxLength: 3}}
+      responses:
+        "200": {description: OK}
+`
+
+func TestStructuredValidationErrors(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(validationTestSpec))
+	if err != nil {
+		t.Fatalf("loading spec: %v", err)
+	}
+	srv := server.NewMCPServer("test", "1.0.0")
+	RegisterOpenAPITools(srv, ExtractOpenAPIOperations(doc), doc, &ToolGenOptions{})
+
+	msg := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"createOrder","arguments":{
+		"region": "EU",
+		"limit": 500,
+		"currency": 3,
+		"requestBody": {"quantity": "2", "tags": ["ok", "toolong"], "contact": "a@b.c"}
+	}}}`)
+	result := srv.HandleMessage(context.Background(), msg).(mcp.JSONRPCResponse).Result.(mcp.CallToolResult)
+	if !result.IsError {
+		t.Fatalf("expected a validation error")
+	}
+	ve, ok := result.StructuredContent.(*ValidationError)
+	if !ok {
+		t.Fatalf("expected structured content, got %T", result.StructuredContent)
+	}
+
+	violations := map[string]ValidationViolation{}
+	for _, v := range ve.Violations {
+		violations[v.Pointer] = v
+	}
+	tests := []struct {
+		pointer    string
+		keyword    string
+		suggestion any
+	}{
+		{"/region", "enum", "eu"},
+		{"/limit", "number_lte", int64(50)},
+		{"/currency", "invalid_type", "3"},
+		{"/requestBody/sku", "required", "SKU-123"},
+		{"/requestBody/quantity", "invalid_type", int64(2)},
+		{"/requestBody/tags/1", "string_lte", "too"},
+	}
+	for _, tt := range tests {
+		v, ok := violations[tt.pointer]
+		if !ok {
+			t.Errorf("%s: no violation reported (got %v)", tt.pointer, ve.Violations)
+			continue
+		}
+		if v.Keyword != tt.keyword || v.Suggestion != tt.suggestion {
+			t.Errorf("%s: got keyword %q suggestion %#v, want %q %#v", tt.pointer, v.Keyword, v.Suggestion, tt.keyword, tt.suggestion)
+		}
+	}
+	if v := violations["/limit"]; v.Received != float64(500) || v.Expected["maximum"] != float64(50) {
+		t.Errorf("unexpected received/expected for /limit: %+v", v)
+	}
+	if v := violations["/requestBody/sku"]; v.Received != nil || v.Expected["required"] != true {
+		t.Errorf("unexpected received/expected for a missing property: %+v", v)
+	}
+
+	// The suggested arguments fix every violation
+	suggested, _ := json.Marshal(ve.SuggestedArguments)
+	var suggestedArgs map[string]any
+	_ = json.Unmarshal(suggested, &suggestedArgs)
+	params, _ := json.Marshal(map[string]any{"name": "createOrder", "arguments": suggestedArgs})
+	retry := srv.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":`+string(params)+`}`))
+	if resp, ok := retry.(mcp.JSONRPCResponse); ok {
+		if r := resp.Result.(mcp.CallToolResult); r.StructuredContent != nil {
+			t.Errorf("suggested arguments still fail validation: %s", r.Content[0].(mcp.TextContent).Text)
+		}
+	}
+
+	// The text form is kept for clients that only show content
+	text := result.Content[0].(mcp.TextContent).Text
+	for _, want := range []string{"/requestBody/sku: Missing required parameter: 'sku'", `Suggested value: "eu"`, "Try again with: call createOrder {"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text content missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "example_string") {
+		t.Errorf("generic placeholders should not be suggested:\n%s", text)
+	}
+}
+
+func TestJSONPointer(t *testing.T) {
+	if got := jsonPointer([]any{"requestBody", "a/b", "m~n", 2}); got != "/requestBody/a~1b/m~0n/2" {
+		t.Errorf("unexpected pointer %q", got)
+	}
+}